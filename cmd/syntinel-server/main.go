@@ -9,14 +9,20 @@ import (
 	"syscall"
 	"time"
 
+	"github.com/jackc/pgx/v5/pgtype"
 	_ "github.com/lib/pq"
+	"github.com/spf13/viper"
 
+	"github.com/SyntinelNyx/syntinel-server/internal/capability"
 	"github.com/SyntinelNyx/syntinel-server/internal/config"
 	"github.com/SyntinelNyx/syntinel-server/internal/database"
 		"github.com/SyntinelNyx/syntinel-server/internal/database/query"
 	"github.com/SyntinelNyx/syntinel-server/internal/grpc"
+	"github.com/SyntinelNyx/syntinel-server/internal/liveness"
 	"github.com/SyntinelNyx/syntinel-server/internal/logger"
+	"github.com/SyntinelNyx/syntinel-server/internal/pki"
 	"github.com/SyntinelNyx/syntinel-server/internal/router"
+	"github.com/SyntinelNyx/syntinel-server/internal/scan"
 	"github.com/SyntinelNyx/syntinel-server/internal/telemetry"
 )
 
@@ -42,6 +48,45 @@ func main() {
 	router := router.SetupRouter(queries, config.AllowedOrigins)
 	server := config.SetupServer(port, router, flags)
 	grpc.LoadCreds()
+	if err := pki.Load(); err != nil {
+		logger.Error("Agent enrollment will not issue certificates: %v", err)
+	}
+	grpc.SetIdentityLookup(func(ctx context.Context, assetID pgtype.UUID) (grpc.Identity, bool, error) {
+		cert, err := queries.GetAssetCertificate(ctx, assetID)
+		if err != nil {
+			return grpc.Identity{}, false, err
+		}
+		if !cert.CertFingerprint.Valid {
+			return grpc.Identity{}, false, nil
+		}
+		return grpc.Identity{Fingerprint: cert.CertFingerprint.String, Revoked: cert.CertRevokedAt.Valid}, true, nil
+	})
+
+	if err := capability.Start(queries); err != nil {
+		logger.Error("Failed to load agent capabilities: %v", err)
+	}
+	if err := liveness.Start(queries); err != nil {
+		logger.Error("Failed to start agent liveness monitor: %v", err)
+	}
+	if err := scan.StartJobs(scan.NewHandler(queries)); err != nil {
+		logger.Error("Failed to resume scan jobs: %v", err)
+	}
+	if err := scan.StartScheduler(scan.NewHandler(queries)); err != nil {
+		logger.Error("Failed to load scan schedules: %v", err)
+	}
+
+	tunnelAddress := viper.GetString("agent.tunnel.address")
+	if tunnelAddress == "" {
+		tunnelAddress = ":50052"
+	}
+	tunnelServer, err := grpc.ServeTunnel(tunnelAddress, func(ctx context.Context, assetID pgtype.UUID) error {
+		_, err := queries.GetIPByAssetID(ctx, assetID)
+		return err
+	})
+	if err != nil {
+		logger.Fatal("Failed to start agent tunnel server: %v", err)
+	}
+	logger.Info("Agent tunnel server listening on %s...", tunnelAddress)
 
 	stop := make(chan os.Signal, 1)
 	signal.Notify(stop, os.Interrupt, syscall.SIGTERM)
@@ -99,5 +144,10 @@ func main() {
 		logger.Error("Error shutting down HTTP server: %v", err)
 	}
 
+	tunnelServer.Stop()
+	liveness.Stop()
+	scan.ShutdownScheduler()
+	grpc.Close()
+
 	logger.Info("Shutdown complete.")
 }
@@ -3,17 +3,23 @@ package action
 import (
 	"context"
 	"encoding/json"
-	"fmt"
+	"errors"
 	"net/http"
 	"path/filepath"
 
+	"github.com/SyntinelNyx/syntinel-server/internal/capability"
 	"github.com/SyntinelNyx/syntinel-server/internal/commands"
+	"github.com/SyntinelNyx/syntinel-server/internal/endpoint"
+	"github.com/SyntinelNyx/syntinel-server/internal/grpc"
+	"github.com/SyntinelNyx/syntinel-server/internal/liveness"
 	"github.com/SyntinelNyx/syntinel-server/internal/proto/controlpb"
-	"github.com/SyntinelNyx/syntinel-server/internal/request"
 	"github.com/SyntinelNyx/syntinel-server/internal/response"
 	"github.com/jackc/pgx/v5/pgtype"
 )
 
+// uploadDir is where agents store files received through Upload.
+const uploadDir = "/etc/syntinel/upload"
+
 type RunRequest struct {
 	Actions []struct {
 		ActionID string `json:"actionId"`
@@ -43,13 +49,11 @@ func (h *Handler) Run(w http.ResponseWriter, r *http.Request) {
 		actionData, err := h.queries.GetActionById(context.Background(), uuid)
 		if err != nil {
 			response.RespondWithError(w, r, http.StatusInternalServerError, "Failed to get action from UUID", err)
+			return
 		}
 
 		if actionData.ActionType == "command" {
-			execCommands = append(execCommands, &controlpb.ControlMessage{
-				Command: "exec",
-				Payload: actionData.ActionPayload,
-			})
+			execCommands = append(execCommands, commands.Shell(actionData.ActionPayload))
 		} else if actionData.ActionType == "file" {
 			for _, asset := range req.Assets {
 				var uuid pgtype.UUID
@@ -58,29 +62,35 @@ func (h *Handler) Run(w http.ResponseWriter, r *http.Request) {
 					return
 				}
 
-				ipAddr, err := h.queries.GetIPByAssetID(context.Background(), uuid)
-				if err != nil {
-					response.RespondWithError(w, r, http.StatusInternalServerError, "Failed to get asset from UUID", err)
-					return
-				}
-
-				target, err := request.ParseIP(ipAddr)
+				target, err := endpoint.Resolve(context.Background(), h.queries, uuid)
 				if err != nil {
-					response.RespondWithError(w, r, http.StatusInternalServerError, "Failed to parse IP address", err)
+					response.RespondWithError(w, r, http.StatusInternalServerError, "Failed to resolve agent endpoint", err)
 					return
 				}
 
-				_, err = commands.Upload(target, actionData.ActionPayload)
-				if err != nil {
+				if err := commands.Upload(r.Context(), uuid, target, actionData.ActionPayload); err != nil {
+					if errors.Is(err, liveness.ErrAgentOffline) {
+						response.RespondWithError(w, r, http.StatusServiceUnavailable, "Asset is offline", err)
+						return
+					}
+					if commands.IsTimeout(err) {
+						response.RespondWithError(w, r, http.StatusGatewayTimeout, "Timed out uploading file to agent", err)
+						return
+					}
+					if errors.Is(err, capability.ErrMissingCapability) {
+						response.RespondWithError(w, r, http.StatusUnprocessableEntity, "Asset cannot run this action", err)
+						return
+					}
+					if errors.Is(err, grpc.ErrDigestMismatch) {
+						response.RespondWithError(w, r, http.StatusBadGateway, "Uploaded file failed integrity check on agent", err)
+						return
+					}
 					response.RespondWithError(w, r, http.StatusInternalServerError, "Failed to upload file to agent", err)
 					return
 				}
 			}
 			path := filepath.Base(actionData.ActionPayload)
-			execCommands = append(execCommands, &controlpb.ControlMessage{
-				Command: "exec",
-				Payload: fmt.Sprintf("bash /etc/syntinel/upload/%s", path),
-			})
+			execCommands = append(execCommands, commands.ExecScript("bash", filepath.Join(uploadDir, path)))
 		}
 	}
 
@@ -91,23 +101,34 @@ func (h *Handler) Run(w http.ResponseWriter, r *http.Request) {
 			return
 		}
 
-		ipAddr, err := h.queries.GetIPByAssetID(context.Background(), uuid)
+		target, err := endpoint.Resolve(context.Background(), h.queries, uuid)
 		if err != nil {
-			response.RespondWithError(w, r, http.StatusInternalServerError, "Failed to get asset from UUID", err)
+			response.RespondWithError(w, r, http.StatusInternalServerError, "Failed to resolve agent endpoint", err)
 			return
 		}
 
-		target, err := request.ParseIP(ipAddr)
-		if err != nil {
-			response.RespondWithError(w, r, http.StatusInternalServerError, "Failed to parse IP address", err)
+		responses, err := commands.Command(r.Context(), uuid, target, execCommands)
+		if errors.Is(err, liveness.ErrAgentOffline) {
+			response.RespondWithError(w, r, http.StatusServiceUnavailable, "Asset is offline", err)
+			return
+		}
+		if commands.IsTimeout(err) {
+			response.RespondWithError(w, r, http.StatusGatewayTimeout, "Timed out waiting for agent", err)
+			return
+		}
+		if errors.Is(err, capability.ErrMissingCapability) {
+			response.RespondWithError(w, r, http.StatusUnprocessableEntity, "Asset cannot run this action", err)
 			return
 		}
-
-		_, err = commands.Command(target, execCommands)
 		if err != nil {
 			response.RespondWithError(w, r, http.StatusInternalServerError, "Failed to execute command", err)
 			return
 		}
+
+		if err := commands.CheckAll(responses); err != nil {
+			response.RespondWithError(w, r, http.StatusInternalServerError, "Action failed on asset", err)
+			return
+		}
 	}
 
 	response.RespondWithJSON(w, http.StatusOK, "Workflow ran successfully")
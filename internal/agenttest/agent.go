@@ -0,0 +1,231 @@
+// Package agenttest provides an in-process AgentService that can be scripted
+// with canned responses, for testing server features end to end without
+// real hosts.
+package agenttest
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"path"
+	"sync"
+	"time"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	"github.com/SyntinelNyx/syntinel-server/internal/grpc"
+	"github.com/SyntinelNyx/syntinel-server/internal/proto/controlpb"
+)
+
+// Reply is one scripted answer to a ControlMessage. The agent waits for
+// Delay before answering, and fails the whole stream with Err if it is set.
+type Reply struct {
+	Response *controlpb.ControlResponse
+	Delay    time.Duration
+	Err      error
+}
+
+// Output is a successful reply with stdout.
+func Output(stdout string) Reply {
+	return Reply{Response: &controlpb.ControlResponse{Result: stdout, Status: "success", Stdout: stdout}}
+}
+
+// Failure is a reply for a command that exited with exitCode.
+func Failure(exitCode int32, stderr string) Reply {
+	return Reply{Response: &controlpb.ControlResponse{Result: stderr, Status: "failure", ExitCode: exitCode, Stderr: stderr}}
+}
+
+// Agent is a fake AgentService. Replies are scripted per operation name, as
+// returned by grpc.Operation; unscripted operations fail with exit code 127.
+type Agent struct {
+	controlpb.UnimplementedAgentServiceServer
+
+	mu           sync.Mutex
+	capabilities *controlpb.Capabilities
+	replies      map[string][]Reply
+	received     []*controlpb.ControlMessage
+	files        map[string][]byte
+}
+
+func NewAgent() *Agent {
+	return &Agent{
+		replies: make(map[string][]Reply),
+		files:   make(map[string][]byte),
+	}
+}
+
+// On queues replies for operation. Once the queue is down to its last reply,
+// that reply is repeated for every further command.
+func (a *Agent) On(operation string, replies ...Reply) *Agent {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	a.replies[operation] = append(a.replies[operation], replies...)
+	return a
+}
+
+// SetCapabilities makes the agent answer the Handshake RPC. Without it the
+// agent behaves like one that predates the handshake.
+func (a *Agent) SetCapabilities(capabilities *controlpb.Capabilities) *Agent {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	a.capabilities = capabilities
+	return a
+}
+
+// Received returns every ControlMessage the agent has been sent.
+func (a *Agent) Received() []*controlpb.ControlMessage {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	return append([]*controlpb.ControlMessage{}, a.received...)
+}
+
+// PutFile makes data available to Download at path.
+func (a *Agent) PutFile(path string, data []byte) *Agent {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	a.files[path] = data
+	return a
+}
+
+// File returns the contents of a file uploaded to the agent under name.
+func (a *Agent) File(name string) ([]byte, bool) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	data, ok := a.files[name]
+	return data, ok
+}
+
+func (a *Agent) next(cmd *controlpb.ControlMessage) Reply {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	a.received = append(a.received, cmd)
+
+	operation := grpc.Operation(cmd)
+	queue := a.replies[operation]
+	if len(queue) == 0 {
+		return Failure(127, fmt.Sprintf("agenttest: no reply scripted for %s", operation))
+	}
+	if len(queue) > 1 {
+		a.replies[operation] = queue[1:]
+	}
+
+	return queue[0]
+}
+
+func (a *Agent) Control(stream controlpb.AgentService_ControlServer) error {
+	for {
+		cmd, err := stream.Recv()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		reply := a.next(cmd)
+		started := time.Now()
+		if reply.Delay > 0 {
+			select {
+			case <-time.After(reply.Delay):
+			case <-stream.Context().Done():
+				return stream.Context().Err()
+			}
+		}
+		if reply.Err != nil {
+			return reply.Err
+		}
+
+		resp := proto.Clone(reply.Response).(*controlpb.ControlResponse)
+		resp.StartedAt = timestamppb.New(started)
+		resp.FinishedAt = timestamppb.Now()
+		if err := stream.Send(resp); err != nil {
+			return err
+		}
+	}
+}
+
+func (a *Agent) Handshake(ctx context.Context, _ *controlpb.HandshakeRequest) (*controlpb.Capabilities, error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	if a.capabilities == nil {
+		return nil, status.Error(codes.Unimplemented, "method Handshake not implemented")
+	}
+	return a.capabilities, nil
+}
+
+// Upload accepts a file from offset zero and stores it once complete.
+func (a *Agent) Upload(stream controlpb.AgentService_UploadServer) error {
+	first, err := stream.Recv()
+	if err != nil {
+		return err
+	}
+	if err := stream.Send(&controlpb.UploadAck{FileId: first.FileId}); err != nil {
+		return err
+	}
+
+	var data []byte
+	for int64(len(data)) < first.TotalSize {
+		chunk, err := stream.Recv()
+		if err != nil {
+			return err
+		}
+		data = append(data, chunk.Data...)
+		if err := stream.Send(&controlpb.UploadAck{FileId: first.FileId, Offset: int64(len(data))}); err != nil {
+			return err
+		}
+	}
+
+	digest := sha256.Sum256(data)
+	a.mu.Lock()
+	a.files[first.Name] = data
+	a.mu.Unlock()
+
+	return stream.Send(&controlpb.UploadAck{
+		FileId:   first.FileId,
+		Offset:   int64(len(data)),
+		Complete: true,
+		Sha256:   hex.EncodeToString(digest[:]),
+	})
+}
+
+// Download streams a file added with PutFile in chunks of grpc.ChunkSize.
+func (a *Agent) Download(req *controlpb.FileTransfer, stream controlpb.AgentService_DownloadServer) error {
+	data, ok := a.File(req.Path)
+	if !ok {
+		return status.Errorf(codes.NotFound, "no such file: %s", req.Path)
+	}
+	if req.MaxSize > 0 && int64(len(data)) > req.MaxSize {
+		return status.Errorf(codes.FailedPrecondition, "file is %d bytes, limit is %d", len(data), req.MaxSize)
+	}
+
+	digest := sha256.Sum256(data)
+	first := &controlpb.FileChunk{
+		Name:      path.Base(req.Path),
+		TotalSize: int64(len(data)),
+		Sha256:    hex.EncodeToString(digest[:]),
+	}
+	if err := stream.Send(first); err != nil {
+		return err
+	}
+
+	for offset := int64(0); offset < int64(len(data)); offset += grpc.ChunkSize {
+		end := min(offset+grpc.ChunkSize, int64(len(data)))
+		if err := stream.Send(&controlpb.FileChunk{Offset: offset, Data: data[offset:end]}); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
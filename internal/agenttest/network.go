@@ -0,0 +1,56 @@
+package agenttest
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"sync"
+	"testing"
+
+	googlegrpc "google.golang.org/grpc"
+	"google.golang.org/grpc/test/bufconn"
+
+	"github.com/SyntinelNyx/syntinel-server/internal/grpc"
+	"github.com/SyntinelNyx/syntinel-server/internal/proto/controlpb"
+)
+
+// Network routes agent connections to in-memory listeners by address.
+type Network struct {
+	mu        sync.Mutex
+	listeners map[string]*bufconn.Listener
+}
+
+// Install points the grpc package at a new Network for the rest of the test,
+// so commands.Command and friends reach agents added with Serve.
+func Install(t testing.TB) *Network {
+	n := &Network{listeners: make(map[string]*bufconn.Listener)}
+	t.Cleanup(grpc.UseDialer(n.Dial))
+
+	return n
+}
+
+// Serve makes agent reachable at address, e.g. "10.0.0.5:50051".
+func (n *Network) Serve(t testing.TB, address string, agent *Agent) {
+	listener := bufconn.Listen(1024 * 1024)
+	server := googlegrpc.NewServer()
+	controlpb.RegisterAgentServiceServer(server, agent)
+	go server.Serve(listener)
+	t.Cleanup(server.Stop)
+
+	n.mu.Lock()
+	n.listeners[address] = listener
+	n.mu.Unlock()
+}
+
+// Dial connects to the agent served at address. Addresses without an agent
+// are refused, like a host with nothing listening.
+func (n *Network) Dial(ctx context.Context, address string) (net.Conn, error) {
+	n.mu.Lock()
+	listener, ok := n.listeners[address]
+	n.mu.Unlock()
+
+	if !ok {
+		return nil, fmt.Errorf("dial %s: connection refused", address)
+	}
+	return listener.DialContext(ctx)
+}
@@ -0,0 +1,61 @@
+package asset
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/SyntinelNyx/syntinel-server/internal/auth"
+	"github.com/SyntinelNyx/syntinel-server/internal/grpc"
+	"github.com/SyntinelNyx/syntinel-server/internal/response"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+type ConnectionsResponse struct {
+	Metrics     grpc.Metrics     `json:"metrics"`
+	Connections []grpc.ConnStats `json:"connections"`
+}
+
+func (h *Handler) Connections(w http.ResponseWriter, r *http.Request) {
+	var rootId pgtype.UUID
+	var err error
+
+	account := auth.GetClaims(r.Context())
+	switch account.AccountType {
+	case "root":
+		rootId = account.AccountID
+	case "iam":
+		rootId, err = h.queries.GetRootAccountIDForIAMUser(context.Background(), account.AccountID)
+		if err != nil {
+			response.RespondWithError(w, r, http.StatusInternalServerError, "Failed to get associated root account for IAM account", err)
+			return
+		}
+	default:
+		response.RespondWithError(w, r, http.StatusBadRequest, "Failed to validate claims in JWT", err)
+		return
+	}
+
+	row, err := h.queries.GetAllAssets(context.Background(), rootId)
+	if err != nil {
+		response.RespondWithError(w, r, http.StatusInternalServerError, "Error when retrieving assets information", err)
+		return
+	}
+
+	assets := make(map[string]struct{})
+	for _, asset := range row {
+		assets[response.UuidToString(asset.AssetID)] = struct{}{}
+	}
+
+	metrics, stats := grpc.Stats()
+
+	connections := []grpc.ConnStats{}
+	for _, stat := range stats {
+		if _, ok := assets[stat.AssetID]; ok {
+			connections = append(connections, stat)
+		}
+	}
+
+	response.RespondWithJSON(w, http.StatusOK, ConnectionsResponse{
+		Metrics:     metrics,
+		Connections: connections,
+	})
+}
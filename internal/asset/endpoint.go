@@ -0,0 +1,77 @@
+package asset
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+
+	"github.com/SyntinelNyx/syntinel-server/internal/database/query"
+	"github.com/SyntinelNyx/syntinel-server/internal/endpoint"
+	"github.com/SyntinelNyx/syntinel-server/internal/grpc"
+	"github.com/SyntinelNyx/syntinel-server/internal/response"
+)
+
+type UpdateEndpointRequest struct {
+	Hostname string `json:"hostname"`
+	Port     int32  `json:"port"`
+	Override string `json:"override"`
+}
+
+// UpdateEndpoint changes where the server dials the asset's agent, e.g. for
+// agents behind NAT or listening on a non-default port. The pooled connection
+// is dropped so the next command dials the new address.
+func (h *Handler) UpdateEndpoint(w http.ResponseWriter, r *http.Request) {
+	rootId, err := h.rootAccountID(r)
+	if err != nil {
+		response.RespondWithError(w, r, http.StatusInternalServerError, "Failed to get associated root account", err)
+		return
+	}
+
+	var assetID pgtype.UUID
+	if err := assetID.Scan(chi.URLParam(r, "assetID")); err != nil {
+		response.RespondWithError(w, r, http.StatusBadRequest, "Invalid AssetID format", err)
+		return
+	}
+
+	var req UpdateEndpointRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		response.RespondWithError(w, r, http.StatusBadRequest, "Invalid Request Body", err)
+		return
+	}
+	if req.Port == 0 {
+		req.Port = endpoint.DefaultPort
+	}
+	if err := endpoint.Validate(req.Hostname, req.Port, req.Override); err != nil {
+		response.RespondWithError(w, r, http.StatusBadRequest, "Invalid agent endpoint", err)
+		return
+	}
+
+	updated, err := h.queries.UpdateAssetEndpoint(context.Background(), query.UpdateAssetEndpointParams{
+		AssetID:               assetID,
+		RootAccountID:         rootId,
+		AgentHostname:         pgtype.Text{String: req.Hostname, Valid: req.Hostname != ""},
+		AgentPort:             req.Port,
+		AgentEndpointOverride: pgtype.Text{String: req.Override, Valid: req.Override != ""},
+	})
+	if err != nil {
+		response.RespondWithError(w, r, http.StatusInternalServerError, "Failed to update agent endpoint", err)
+		return
+	}
+	if updated == 0 {
+		response.RespondWithError(w, r, http.StatusNotFound, "Asset not found", nil)
+		return
+	}
+
+	grpc.Disconnect(assetID)
+
+	target, err := endpoint.Resolve(context.Background(), h.queries, assetID)
+	if err != nil {
+		response.RespondWithError(w, r, http.StatusInternalServerError, "Failed to resolve agent endpoint", err)
+		return
+	}
+
+	response.RespondWithJSON(w, http.StatusOK, map[string]string{"message": "Agent endpoint updated", "target": target})
+}
@@ -2,13 +2,21 @@ package asset
 
 import (
 	"context"
+	"crypto/subtle"
 	"encoding/json"
+	"errors"
+	"fmt"
 	"net/http"
 	"net/netip"
+	"os"
 
 	"github.com/SyntinelNyx/syntinel-server/internal/database/query"
+	"github.com/SyntinelNyx/syntinel-server/internal/endpoint"
+	"github.com/SyntinelNyx/syntinel-server/internal/logger"
+	"github.com/SyntinelNyx/syntinel-server/internal/pki"
 	"github.com/SyntinelNyx/syntinel-server/internal/request"
 	"github.com/SyntinelNyx/syntinel-server/internal/response"
+	"github.com/jackc/pgx/v5/pgconn"
 	"github.com/jackc/pgx/v5/pgtype"
 )
 
@@ -16,6 +24,17 @@ type EnrollRequest struct {
 	UUID      string   `json:"uuid"`
 	Info      HostInfo `json:"hostInfo"`
 	Root_User string   `json:"rootUser"`
+	CSR       string   `json:"csr"`
+	Token     string   `json:"enrollmentToken"`
+
+	Endpoint *EnrollEndpoint `json:"endpoint,omitempty"`
+}
+
+// EnrollEndpoint is where the agent listens, when it is not reachable on
+// DefaultPort at the address it enrolled from.
+type EnrollEndpoint struct {
+	Hostname string `json:"hostname"`
+	Port     int32  `json:"port"`
 }
 
 type HostInfo struct {
@@ -72,12 +91,56 @@ func (h *Handler) Enroll(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	agentHostname := pgtype.Text{}
+	agentPort := int32(endpoint.DefaultPort)
+	if enrollReq.Endpoint != nil {
+		if enrollReq.Endpoint.Port != 0 {
+			agentPort = enrollReq.Endpoint.Port
+		}
+		if err := endpoint.Validate(enrollReq.Endpoint.Hostname, agentPort, ""); err != nil {
+			response.RespondWithError(w, r, http.StatusBadRequest, "Invalid agent endpoint", err)
+			return
+		}
+		if enrollReq.Endpoint.Hostname != "" {
+			if err := endpoint.CheckReported(r.Context(), enrollReq.Endpoint.Hostname, parsedIP); err != nil {
+				response.RespondWithError(w, r, http.StatusBadRequest, "Agent endpoint does not match the enrollment address", err)
+				return
+			}
+		}
+		agentHostname = pgtype.Text{String: enrollReq.Endpoint.Hostname, Valid: enrollReq.Endpoint.Hostname != ""}
+	}
+
+	if status, err := checkEnrollmentToken(enrollReq.Token); err != nil {
+		response.RespondWithError(w, r, status, "Enrollment not authorized", err)
+		return
+	}
+
 	rootAccount, err := h.queries.GetRootAccountByUsername(context.Background(), enrollReq.Root_User)
 	if err != nil {
 		response.RespondWithError(w, r, http.StatusExpectationFailed, "Failed to find root user", err)
 		return
 	}
 
+	// Agents that predate per-agent certificates do not send a CSR. They are
+	// only enrolled, without a pinned certificate, in legacy mode.
+	issued := &pki.Issued{}
+	if enrollReq.CSR != "" {
+		issued, err = pki.SignAgentCSR([]byte(enrollReq.CSR), enrollReq.UUID)
+		if errors.Is(err, pki.ErrNotLoaded) {
+			response.RespondWithError(w, r, http.StatusInternalServerError, "Failed to issue agent certificate", err)
+			return
+		}
+		if err != nil {
+			response.RespondWithError(w, r, http.StatusBadRequest, "Invalid certificate signing request", err)
+			return
+		}
+	} else if pki.LegacyAgents() {
+		logger.Warn("Enrolling asset %s without a certificate because legacy agents are allowed", enrollReq.UUID)
+	} else {
+		response.RespondWithError(w, r, http.StatusBadRequest, "Missing certificate signing request", nil)
+		return
+	}
+
 	params := query.AddAssetParams{
 		Hostname:             pgtype.Text{String: enrollReq.Info.Host.Hostname, Valid: true},
 		Uptime:               pgtype.Int8{Int64: int64(enrollReq.Info.Host.Uptime), Valid: true},
@@ -102,12 +165,64 @@ func (h *Handler) Enroll(w http.ResponseWriter, r *http.Request) {
 		AssetID:              uuid,
 		IpAddress:            parsedIP,
 		RootAccountID:        rootAccount.AccountID,
+		CertFingerprint:      pgtype.Text{String: issued.Fingerprint, Valid: issued.Fingerprint != ""},
+		CertSerial:           pgtype.Text{String: issued.Serial, Valid: issued.Serial != ""},
+		CertNotAfter:         pgtype.Timestamptz{Time: issued.NotAfter, Valid: !issued.NotAfter.IsZero()},
+		AgentHostname:        agentHostname,
+		AgentPort:            agentPort,
 	}
 
-	if err := h.queries.AddAsset(context.Background(), params); err != nil {
-		response.RespondWithError(w, r, http.StatusInternalServerError, "Failed to insert asset", err)
+	// An asset that is already enrolled in the account, for example after its
+	// certificate was revoked, is given the new certificate instead.
+	reenrolled, err := h.queries.ReenrollAsset(r.Context(), query.ReenrollAssetParams{
+		IpAddress:       params.IpAddress,
+		CertFingerprint: params.CertFingerprint,
+		CertSerial:      params.CertSerial,
+		CertNotAfter:    params.CertNotAfter,
+		AgentHostname:   params.AgentHostname,
+		AgentPort:       params.AgentPort,
+		AssetID:         params.AssetID,
+		RootAccountID:   params.RootAccountID,
+	})
+	if err != nil {
+		response.RespondWithError(w, r, http.StatusInternalServerError, "Failed to update asset", err)
 		return
 	}
 
-	response.RespondWithJSON(w, http.StatusOK, map[string]string{"message": "Enrollment Successful"})
+	if reenrolled == 0 {
+		err := h.queries.AddAsset(r.Context(), params)
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) && pgErr.Code == "23505" {
+			response.RespondWithError(w, r, http.StatusConflict, "Asset is enrolled in another account", err)
+			return
+		}
+		if err != nil {
+			response.RespondWithError(w, r, http.StatusInternalServerError, "Failed to insert asset", err)
+			return
+		}
+	}
+
+	response.RespondWithJSON(w, http.StatusOK, map[string]string{
+		"message":       "Enrollment Successful",
+		"certificate":   string(issued.CertPEM),
+		"caCertificate": string(pki.CACertPEM()),
+	})
+}
+
+// checkEnrollmentToken compares token with AGENT_ENROLLMENT_TOKEN, so that
+// knowing a root username is not enough to enroll assets into its account.
+func checkEnrollmentToken(token string) (int, error) {
+	expected := os.Getenv("AGENT_ENROLLMENT_TOKEN")
+	if expected == "" {
+		if pki.LegacyAgents() {
+			logger.Warn("Enrolling asset without an enrollment token because legacy agents are allowed")
+			return http.StatusOK, nil
+		}
+		return http.StatusInternalServerError, fmt.Errorf("AGENT_ENROLLMENT_TOKEN is not set")
+	}
+
+	if subtle.ConstantTimeCompare([]byte(token), []byte(expected)) != 1 {
+		return http.StatusUnauthorized, fmt.Errorf("invalid enrollment token")
+	}
+	return http.StatusOK, nil
 }
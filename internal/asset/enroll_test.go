@@ -0,0 +1,145 @@
+package asset
+
+import (
+	"bytes"
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/json"
+	"encoding/pem"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/joho/godotenv"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/SyntinelNyx/syntinel-server/internal/database"
+	"github.com/SyntinelNyx/syntinel-server/internal/database/query"
+	"github.com/SyntinelNyx/syntinel-server/internal/pki"
+)
+
+func setupTestDB(t *testing.T) (*Handler, *pgxpool.Pool) {
+	if os.Getenv("NONLOCAL_TESTS") != "" {
+		t.Skip("Skipping test meant for local environments.")
+	}
+
+	if err := godotenv.Load("../../.env"); err != nil {
+		t.Fatal("Error loading .env file")
+	}
+
+	testDBURL := os.Getenv("TEST_DATABASE_URL")
+
+	if testDBURL == "" {
+		t.Fatal("TEST_DATABASE_URL environment variable not set")
+	}
+
+	queries, conn, err := database.InitDatabaseWithURL(testDBURL)
+	require.NoError(t, err, "Failed to initialize database")
+
+	database.RunMigrationWithURL(testDBURL)
+
+	return NewHandler(queries), conn
+}
+
+func cleanupTestDB(t *testing.T, conn *pgxpool.Pool) {
+	_, err := conn.Exec(context.Background(), "DROP TABLE IF EXISTS assets, system_information, root_accounts CASCADE;")
+	require.NoError(t, err, "Failed to drop tables")
+}
+
+// loadTestCA writes a throwaway CA to DATA_PATH and loads it, so that
+// enrollment issues certificates.
+func loadTestCA(t *testing.T) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "test-ca"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+		KeyUsage:              x509.KeyUsageCertSign,
+	}
+	certDER, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	require.NoError(t, err)
+	keyDER, err := x509.MarshalPKCS8PrivateKey(key)
+	require.NoError(t, err)
+
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "ca_cert.pem"), pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: certDER}), 0o600))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "ca_key.pem"), pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: keyDER}), 0o600))
+	t.Setenv("DATA_PATH", dir)
+	require.NoError(t, pki.Load())
+}
+
+func newCSR(t *testing.T) string {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	der, err := x509.CreateCertificateRequest(rand.Reader, &x509.CertificateRequest{}, key)
+	require.NoError(t, err)
+
+	return string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE REQUEST", Bytes: der}))
+}
+
+func TestReenrollAfterRevocation(t *testing.T) {
+	handler, conn := setupTestDB(t)
+	defer cleanupTestDB(t, conn)
+	ctx := context.Background()
+
+	loadTestCA(t)
+	t.Setenv("AGENT_ENROLLMENT_TOKEN", "token")
+
+	rootAccount, err := handler.queries.CreateRootAccount(ctx, query.CreateRootAccountParams{Email: "a@a.com", Username: "a"})
+	require.NoError(t, err)
+	_, err = handler.queries.CreateRootAccount(ctx, query.CreateRootAccountParams{Email: "b@b.com", Username: "b"})
+	require.NoError(t, err)
+
+	assetID := "3f1c2f7e-8a4b-4e0c-9d1a-5b6e7f8a9b0c"
+	var asset pgtype.UUID
+	require.NoError(t, asset.Scan(assetID))
+
+	enroll := func(rootUser string, token string) int {
+		body, err := json.Marshal(EnrollRequest{UUID: assetID, Root_User: rootUser, CSR: newCSR(t), Token: token})
+		require.NoError(t, err)
+
+		rr := httptest.NewRecorder()
+		handler.Enroll(rr, httptest.NewRequest(http.MethodPost, "/agent/enroll", bytes.NewReader(body)))
+		return rr.Code
+	}
+
+	require.Equal(t, http.StatusOK, enroll("a", "token"))
+	enrolled, err := handler.queries.GetAssetCertificate(ctx, asset)
+	require.NoError(t, err)
+
+	revoked, err := handler.queries.RevokeAssetCertificate(ctx, query.RevokeAssetCertificateParams{
+		AssetID:       asset,
+		RootAccountID: rootAccount.AccountID,
+	})
+	require.NoError(t, err)
+	require.Equal(t, int64(1), revoked)
+
+	assert.Equal(t, http.StatusUnauthorized, enroll("a", "wrong"))
+
+	require.Equal(t, http.StatusOK, enroll("a", "token"))
+	reenrolled, err := handler.queries.GetAssetCertificate(ctx, asset)
+	require.NoError(t, err)
+	assert.NotEqual(t, enrolled.CertFingerprint, reenrolled.CertFingerprint)
+	assert.False(t, reenrolled.CertRevokedAt.Valid)
+
+	// Another account cannot take the asset over by enrolling it.
+	assert.Equal(t, http.StatusConflict, enroll("b", "token"))
+	unchanged, err := handler.queries.GetAssetCertificate(ctx, asset)
+	require.NoError(t, err)
+	assert.Equal(t, reenrolled.CertFingerprint, unchanged.CertFingerprint)
+}
@@ -0,0 +1,261 @@
+package asset
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/spf13/viper"
+
+	"github.com/SyntinelNyx/syntinel-server/internal/auth"
+	"github.com/SyntinelNyx/syntinel-server/internal/capability"
+	"github.com/SyntinelNyx/syntinel-server/internal/commands"
+	"github.com/SyntinelNyx/syntinel-server/internal/database/query"
+	"github.com/SyntinelNyx/syntinel-server/internal/endpoint"
+	"github.com/SyntinelNyx/syntinel-server/internal/grpc"
+	"github.com/SyntinelNyx/syntinel-server/internal/liveness"
+	"github.com/SyntinelNyx/syntinel-server/internal/response"
+)
+
+const defaultMaxFileSize = 100 * 1024 * 1024
+
+type PullFileRequest struct {
+	Path string `json:"path"`
+}
+
+type AssetFile struct {
+	FileID      string `json:"fileId"`
+	AssetID     string `json:"assetId"`
+	RemotePath  string `json:"remotePath"`
+	Name        string `json:"name"`
+	SizeBytes   int64  `json:"sizeBytes"`
+	Sha256      string `json:"sha256"`
+	RetrievedBy string `json:"retrievedBy"`
+	RetrievedAt string `json:"retrievedAt"`
+}
+
+func maxFileSize() int64 {
+	if viper.IsSet("agent.files.max_size") {
+		return viper.GetInt64("agent.files.max_size")
+	}
+	return defaultMaxFileSize
+}
+
+func toAssetFile(file query.AssetFile) AssetFile {
+	return AssetFile{
+		FileID:      response.UuidToString(file.FileID),
+		AssetID:     response.UuidToString(file.AssetID),
+		RemotePath:  file.RemotePath,
+		Name:        file.Name,
+		SizeBytes:   file.SizeBytes,
+		Sha256:      file.Sha256,
+		RetrievedBy: response.UuidToString(file.RetrievedBy),
+		RetrievedAt: file.RetrievedAt.Time.Format(time.RFC3339),
+	}
+}
+
+func (h *Handler) rootAccountID(r *http.Request) (pgtype.UUID, error) {
+	account := auth.GetClaims(r.Context())
+	switch account.AccountType {
+	case "root":
+		return account.AccountID, nil
+	case "iam":
+		return h.queries.GetRootAccountIDForIAMUser(context.Background(), account.AccountID)
+	default:
+		return pgtype.UUID{}, fmt.Errorf("unknown account type %q", account.AccountType)
+	}
+}
+
+// PullFile fetches a file from the asset's agent and stores it under
+// DATA_PATH/files/<assetID>.
+func (h *Handler) PullFile(w http.ResponseWriter, r *http.Request) {
+	rootId, err := h.rootAccountID(r)
+	if err != nil {
+		response.RespondWithError(w, r, http.StatusInternalServerError, "Failed to get associated root account", err)
+		return
+	}
+
+	var assetID pgtype.UUID
+	if err := assetID.Scan(chi.URLParam(r, "assetID")); err != nil {
+		response.RespondWithError(w, r, http.StatusBadRequest, "Invalid AssetID format", err)
+		return
+	}
+
+	var req PullFileRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		response.RespondWithError(w, r, http.StatusBadRequest, "Invalid Request Body", err)
+		return
+	}
+	if !filepath.IsAbs(req.Path) {
+		response.RespondWithError(w, r, http.StatusBadRequest, "File path must be absolute", nil)
+		return
+	}
+
+	row, err := h.queries.GetAssetEndpointForRootAccount(context.Background(), query.GetAssetEndpointForRootAccountParams{
+		AssetID:       assetID,
+		RootAccountID: rootId,
+	})
+	if errors.Is(err, pgx.ErrNoRows) {
+		response.RespondWithError(w, r, http.StatusNotFound, "Asset not found", err)
+		return
+	}
+	if err != nil {
+		response.RespondWithError(w, r, http.StatusInternalServerError, "Failed to get asset from UUID", err)
+		return
+	}
+
+	target, err := endpoint.New(row.IpAddress, row.AgentHostname, row.AgentPort, row.AgentEndpointOverride).Target()
+	if err != nil {
+		response.RespondWithError(w, r, http.StatusInternalServerError, "Failed to resolve agent endpoint", err)
+		return
+	}
+
+	assetDir := filepath.Join("files", response.UuidToString(assetID))
+	if err := os.MkdirAll(filepath.Join(os.Getenv("DATA_PATH"), assetDir), 0700); err != nil {
+		response.RespondWithError(w, r, http.StatusInternalServerError, "Failed to create files directory", err)
+		return
+	}
+
+	tmp, err := os.CreateTemp(filepath.Join(os.Getenv("DATA_PATH"), assetDir), ".pull-*")
+	if err != nil {
+		response.RespondWithError(w, r, http.StatusInternalServerError, "Failed to create destination file", err)
+		return
+	}
+	defer os.Remove(tmp.Name())
+
+	downloaded, err := commands.Download(r.Context(), assetID, target, req.Path, maxFileSize(), tmp)
+	if closeErr := tmp.Close(); err == nil && closeErr != nil {
+		err = closeErr
+	}
+	if err != nil {
+		switch {
+		case errors.Is(err, liveness.ErrAgentOffline):
+			response.RespondWithError(w, r, http.StatusServiceUnavailable, "Asset is offline", err)
+		case errors.Is(err, capability.ErrMissingCapability):
+			response.RespondWithError(w, r, http.StatusUnprocessableEntity, "Asset agent does not support file retrieval", err)
+		case commands.IsTimeout(err):
+			response.RespondWithError(w, r, http.StatusGatewayTimeout, "Timed out retrieving file from agent", err)
+		case errors.Is(err, grpc.ErrFileTooLarge):
+			response.RespondWithError(w, r, http.StatusRequestEntityTooLarge, "File exceeds the size limit", err)
+		case errors.Is(err, grpc.ErrDigestMismatch):
+			response.RespondWithError(w, r, http.StatusBadGateway, "Retrieved file failed integrity check", err)
+		default:
+			response.RespondWithError(w, r, http.StatusInternalServerError, "Failed to retrieve file from agent", err)
+		}
+		return
+	}
+
+	fileID := uuid.New()
+	storagePath := filepath.Join(assetDir, fileID.String())
+	if err := os.Rename(tmp.Name(), filepath.Join(os.Getenv("DATA_PATH"), storagePath)); err != nil {
+		response.RespondWithError(w, r, http.StatusInternalServerError, "Failed to save retrieved file", err)
+		return
+	}
+
+	name := downloaded.Name
+	if name == "" {
+		name = filepath.Base(req.Path)
+	}
+
+	file, err := h.queries.CreateAssetFile(context.Background(), query.CreateAssetFileParams{
+		FileID:        pgtype.UUID{Bytes: fileID, Valid: true},
+		AssetID:       assetID,
+		RootAccountID: rootId,
+		RemotePath:    req.Path,
+		Name:          filepath.Base(name),
+		SizeBytes:     downloaded.Size,
+		Sha256:        downloaded.SHA256,
+		StoragePath:   storagePath,
+		RetrievedBy:   auth.GetClaims(r.Context()).AccountID,
+	})
+	if err != nil {
+		os.Remove(filepath.Join(os.Getenv("DATA_PATH"), storagePath))
+		response.RespondWithError(w, r, http.StatusInternalServerError, "Failed to record retrieved file", err)
+		return
+	}
+
+	response.RespondWithJSON(w, http.StatusOK, toAssetFile(file))
+}
+
+func (h *Handler) ListFiles(w http.ResponseWriter, r *http.Request) {
+	rootId, err := h.rootAccountID(r)
+	if err != nil {
+		response.RespondWithError(w, r, http.StatusInternalServerError, "Failed to get associated root account", err)
+		return
+	}
+
+	var assetID pgtype.UUID
+	if err := assetID.Scan(chi.URLParam(r, "assetID")); err != nil {
+		response.RespondWithError(w, r, http.StatusBadRequest, "Invalid AssetID format", err)
+		return
+	}
+
+	rows, err := h.queries.ListAssetFiles(context.Background(), query.ListAssetFilesParams{
+		AssetID:       assetID,
+		RootAccountID: rootId,
+	})
+	if err != nil {
+		response.RespondWithError(w, r, http.StatusInternalServerError, "Failed to list asset files", err)
+		return
+	}
+
+	files := []AssetFile{}
+	for _, row := range rows {
+		files = append(files, toAssetFile(row))
+	}
+
+	response.RespondWithJSON(w, http.StatusOK, files)
+}
+
+func (h *Handler) DownloadFile(w http.ResponseWriter, r *http.Request) {
+	rootId, err := h.rootAccountID(r)
+	if err != nil {
+		response.RespondWithError(w, r, http.StatusInternalServerError, "Failed to get associated root account", err)
+		return
+	}
+
+	var assetID, fileID pgtype.UUID
+	if err := assetID.Scan(chi.URLParam(r, "assetID")); err != nil {
+		response.RespondWithError(w, r, http.StatusBadRequest, "Invalid AssetID format", err)
+		return
+	}
+	if err := fileID.Scan(chi.URLParam(r, "fileID")); err != nil {
+		response.RespondWithError(w, r, http.StatusBadRequest, "Invalid FileID format", err)
+		return
+	}
+
+	file, err := h.queries.GetAssetFile(context.Background(), query.GetAssetFileParams{
+		FileID:        fileID,
+		AssetID:       assetID,
+		RootAccountID: rootId,
+	})
+	if errors.Is(err, pgx.ErrNoRows) {
+		response.RespondWithError(w, r, http.StatusNotFound, "File not found", err)
+		return
+	}
+	if err != nil {
+		response.RespondWithError(w, r, http.StatusInternalServerError, "Failed to get asset file", err)
+		return
+	}
+
+	f, err := os.Open(filepath.Join(os.Getenv("DATA_PATH"), file.StoragePath))
+	if err != nil {
+		response.RespondWithError(w, r, http.StatusInternalServerError, "Failed to open stored file", err)
+		return
+	}
+	defer f.Close()
+
+	w.Header().Set("Content-Type", "application/octet-stream")
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", file.Name))
+	w.Header().Set("X-Content-SHA256", file.Sha256)
+	http.ServeContent(w, r, file.Name, file.RetrievedAt.Time, f)
+}
@@ -17,6 +17,8 @@ type Asset struct {
 	PlatformVersion string `json:"platformVersion"`
 	IpAddress       string `json:"ipAddress"`
 	CreatedAt       string `json:"createdAt"`
+	Status          string `json:"status"`
+	LastSeenAt      string `json:"lastSeenAt,omitempty"`
 }
 
 func (h *Handler) Retrieve(w http.ResponseWriter, r *http.Request) {
@@ -53,9 +55,27 @@ func (h *Handler) Retrieve(w http.ResponseWriter, r *http.Request) {
 			PlatformVersion: asset.PlatformVersion.String,
 			IpAddress:       asset.IpAddress.String(),
 			CreatedAt:       asset.CreatedAt.Time.Format(time.RFC3339),
+			Status:          assetStatus(asset.Status),
+			LastSeenAt:      formatTime(asset.LastSeenAt),
 		},
 		)
 	}
 
 	response.RespondWithJSON(w, http.StatusOK, assets)
 }
+
+// assetStatus reports "unknown" for assets the liveness monitor has not
+// probed yet.
+func assetStatus(status pgtype.Text) string {
+	if !status.Valid {
+		return "unknown"
+	}
+	return status.String
+}
+
+func formatTime(t pgtype.Timestamptz) string {
+	if !t.Valid {
+		return ""
+	}
+	return t.Time.Format(time.RFC3339)
+}
@@ -2,21 +2,57 @@ package asset
 
 import (
 	"context"
+	"errors"
 	"net/http"
 	"time"
 
+	"github.com/SyntinelNyx/syntinel-server/internal/capability"
+	"github.com/SyntinelNyx/syntinel-server/internal/database/query"
+	"github.com/SyntinelNyx/syntinel-server/internal/endpoint"
 	"github.com/SyntinelNyx/syntinel-server/internal/response"
 	"github.com/go-chi/chi/v5"
+	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgtype"
 )
 
+const statusHistoryLimit = 50
+
 type AssetDetails struct {
-	AssetID           string            `json:"assetId"`
-	IpAddress         string            `json:"ipAddress"`
-	SysinfoID         string            `json:"sysinfoId"`
-	RootAccountID     string            `json:"rootAccountId"`
-	RegisteredAt      string            `json:"registeredAt"`
-	SystemInformation SystemInformation `json:"systemInformation"`
+	AssetID           string             `json:"assetId"`
+	IpAddress         string             `json:"ipAddress"`
+	SysinfoID         string             `json:"sysinfoId"`
+	RootAccountID     string             `json:"rootAccountId"`
+	RegisteredAt      string             `json:"registeredAt"`
+	Status            string             `json:"status"`
+	LastSeenAt        string             `json:"lastSeenAt,omitempty"`
+	StatusChangedAt   string             `json:"statusChangedAt,omitempty"`
+	StatusHistory     []StatusChange     `json:"statusHistory"`
+	AgentEndpoint     AgentEndpoint      `json:"agentEndpoint"`
+	Tags              []string           `json:"tags"`
+	Capabilities      *AgentCapabilities `json:"capabilities"`
+	SystemInformation SystemInformation  `json:"systemInformation"`
+}
+
+type StatusChange struct {
+	Status    string `json:"status"`
+	Reason    string `json:"reason"`
+	ChangedAt string `json:"changedAt"`
+}
+
+type AgentEndpoint struct {
+	Hostname string `json:"hostname"`
+	Port     int32  `json:"port"`
+	Override string `json:"override"`
+	Target   string `json:"target"`
+}
+
+type AgentCapabilities struct {
+	AgentVersion string            `json:"agentVersion"`
+	Os           string            `json:"os"`
+	Arch         string            `json:"arch"`
+	Operations   []string          `json:"operations"`
+	Tools        []capability.Tool `json:"tools"`
+	ReportedAt   string            `json:"reportedAt"`
 }
 
 type SystemInformation struct {
@@ -58,12 +94,77 @@ func (h *Handler) RetrieveData(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	history, err := h.queries.GetAssetStatusHistory(context.Background(), query.GetAssetStatusHistoryParams{
+		AssetID: uuid,
+		Limit:   statusHistoryLimit,
+	})
+	if err != nil {
+		response.RespondWithError(w, r, http.StatusInternalServerError, "Failed to get asset status history", err)
+		return
+	}
+
+	statusHistory := []StatusChange{}
+	for _, change := range history {
+		statusHistory = append(statusHistory, StatusChange{
+			Status:    change.Status,
+			Reason:    change.Reason.String,
+			ChangedAt: change.ChangedAt.Time.Format(time.RFC3339),
+		})
+	}
+
+	// Capabilities are null until the agent completes a handshake.
+	var agentCapabilities *AgentCapabilities
+	row, err := h.queries.GetAssetCapabilities(context.Background(), uuid)
+	if err != nil && !errors.Is(err, pgx.ErrNoRows) {
+		response.RespondWithError(w, r, http.StatusInternalServerError, "Failed to get asset capabilities", err)
+		return
+	}
+	if err == nil {
+		capabilities, err := capability.FromRow(row)
+		if err != nil {
+			response.RespondWithError(w, r, http.StatusInternalServerError, "Failed to read asset capabilities", err)
+			return
+		}
+		agentCapabilities = &AgentCapabilities{
+			AgentVersion: capabilities.AgentVersion,
+			Os:           capabilities.OS,
+			Arch:         capabilities.Arch,
+			Operations:   capabilities.Operations,
+			Tools:        capabilities.Tools,
+			ReportedAt:   capabilities.ReportedAt.Format(time.RFC3339),
+		}
+	}
+
+	tags, err := h.queries.GetAssetTags(context.Background(), uuid)
+	if err != nil {
+		response.RespondWithError(w, r, http.StatusInternalServerError, "Failed to get asset tags", err)
+		return
+	}
+	if tags == nil {
+		tags = []string{}
+	}
+
+	agentEndpoint := endpoint.New(assetInfo.IpAddress, assetInfo.AgentHostname, assetInfo.AgentPort, assetInfo.AgentEndpointOverride)
+	target, _ := agentEndpoint.Target()
+
 	assetDetails := AssetDetails{
-		AssetID:       response.UuidToString(assetInfo.AssetID),
-		IpAddress:     assetInfo.IpAddress.String(),
-		SysinfoID:     response.UuidToString(assetInfo.SysinfoID),
-		RootAccountID: response.UuidToString(assetInfo.RootAccountID),
-		RegisteredAt:  assetInfo.RegisteredAt.Time.Format(time.RFC3339),
+		AssetID:         response.UuidToString(assetInfo.AssetID),
+		IpAddress:       assetInfo.IpAddress.String(),
+		SysinfoID:       response.UuidToString(assetInfo.SysinfoID),
+		RootAccountID:   response.UuidToString(assetInfo.RootAccountID),
+		RegisteredAt:    assetInfo.RegisteredAt.Time.Format(time.RFC3339),
+		Status:          assetStatus(assetInfo.Status),
+		LastSeenAt:      formatTime(assetInfo.LastSeenAt),
+		StatusChangedAt: formatTime(assetInfo.StatusChangedAt),
+		StatusHistory:   statusHistory,
+		AgentEndpoint: AgentEndpoint{
+			Hostname: agentEndpoint.Hostname,
+			Port:     agentEndpoint.Port,
+			Override: agentEndpoint.Override,
+			Target:   target,
+		},
+		Tags:         tags,
+		Capabilities: agentCapabilities,
 		SystemInformation: SystemInformation{
 			Hostname:             assetInfo.Hostname.String,
 			Uptime:               assetInfo.Uptime.Int64,
@@ -0,0 +1,60 @@
+package asset
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+
+	"github.com/SyntinelNyx/syntinel-server/internal/auth"
+	"github.com/SyntinelNyx/syntinel-server/internal/database/query"
+	"github.com/SyntinelNyx/syntinel-server/internal/grpc"
+	"github.com/SyntinelNyx/syntinel-server/internal/response"
+)
+
+// RevokeCertificate marks the asset's enrolled certificate as revoked and
+// drops any open connection to it. The agent is reachable again once it
+// enrolls again with the enrollment token, into the same account.
+func (h *Handler) RevokeCertificate(w http.ResponseWriter, r *http.Request) {
+	var rootId pgtype.UUID
+	var err error
+
+	account := auth.GetClaims(r.Context())
+	switch account.AccountType {
+	case "root":
+		rootId = account.AccountID
+	case "iam":
+		rootId, err = h.queries.GetRootAccountIDForIAMUser(context.Background(), account.AccountID)
+		if err != nil {
+			response.RespondWithError(w, r, http.StatusInternalServerError, "Failed to get associated root account for IAM account", err)
+			return
+		}
+	default:
+		response.RespondWithError(w, r, http.StatusBadRequest, "Failed to validate claims in JWT", err)
+		return
+	}
+
+	var assetID pgtype.UUID
+	if err := assetID.Scan(chi.URLParam(r, "assetID")); err != nil {
+		response.RespondWithError(w, r, http.StatusBadRequest, "Invalid AssetID format", err)
+		return
+	}
+
+	revoked, err := h.queries.RevokeAssetCertificate(context.Background(), query.RevokeAssetCertificateParams{
+		AssetID:       assetID,
+		RootAccountID: rootId,
+	})
+	if err != nil {
+		response.RespondWithError(w, r, http.StatusInternalServerError, "Failed to revoke certificate", err)
+		return
+	}
+	if revoked == 0 {
+		response.RespondWithError(w, r, http.StatusNotFound, "No active certificate for asset", nil)
+		return
+	}
+
+	grpc.Disconnect(assetID)
+
+	response.RespondWithJSON(w, http.StatusOK, map[string]string{"message": "Certificate revoked"})
+}
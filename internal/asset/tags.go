@@ -0,0 +1,97 @@
+package asset
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"slices"
+	"strings"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+
+	"github.com/SyntinelNyx/syntinel-server/internal/database/query"
+	"github.com/SyntinelNyx/syntinel-server/internal/response"
+)
+
+const maxTagLength = 64
+
+type UpdateTagsRequest struct {
+	Tags []string `json:"tags"`
+}
+
+// UpdateTags replaces the tags on an asset. Tags group assets for scan
+// schedules and other bulk operations.
+func (h *Handler) UpdateTags(w http.ResponseWriter, r *http.Request) {
+	rootId, err := h.rootAccountID(r)
+	if err != nil {
+		response.RespondWithError(w, r, http.StatusInternalServerError, "Failed to get associated root account", err)
+		return
+	}
+
+	var assetID pgtype.UUID
+	if err := assetID.Scan(chi.URLParam(r, "assetID")); err != nil {
+		response.RespondWithError(w, r, http.StatusBadRequest, "Invalid AssetID format", err)
+		return
+	}
+
+	var req UpdateTagsRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		response.RespondWithError(w, r, http.StatusBadRequest, "Invalid Request Body", err)
+		return
+	}
+
+	tags, err := normaliseTags(req.Tags)
+	if err != nil {
+		response.RespondWithError(w, r, http.StatusBadRequest, "Invalid tags: "+err.Error(), err)
+		return
+	}
+
+	_, err = h.queries.GetAssetEndpointForRootAccount(r.Context(), query.GetAssetEndpointForRootAccountParams{
+		AssetID:       assetID,
+		RootAccountID: rootId,
+	})
+	if errors.Is(err, pgx.ErrNoRows) {
+		response.RespondWithError(w, r, http.StatusNotFound, "Asset not found", err)
+		return
+	}
+	if err != nil {
+		response.RespondWithError(w, r, http.StatusInternalServerError, "Failed to get asset", err)
+		return
+	}
+
+	// The asset keeps its old tags if the new ones cannot be stored, so that
+	// tag-targeted schedules do not lose it.
+	err = h.queries.InTx(r.Context(), func(tx *query.Queries) error {
+		if err := tx.DeleteAssetTags(r.Context(), assetID); err != nil {
+			return err
+		}
+		return tx.AddAssetTags(r.Context(), query.AddAssetTagsParams{AssetID: assetID, Tags: tags})
+	})
+	if err != nil {
+		response.RespondWithError(w, r, http.StatusInternalServerError, "Failed to update asset tags", err)
+		return
+	}
+
+	response.RespondWithJSON(w, http.StatusOK, map[string]any{"message": "Asset tags updated", "tags": tags})
+}
+
+func normaliseTags(tags []string) ([]string, error) {
+	normalised := []string{}
+	for _, tag := range tags {
+		tag = strings.TrimSpace(tag)
+		if tag == "" {
+			return nil, errors.New("tags must not be empty")
+		}
+		if len(tag) > maxTagLength {
+			return nil, fmt.Errorf("tag %q is longer than %d characters", tag, maxTagLength)
+		}
+		if !slices.Contains(normalised, tag) {
+			normalised = append(normalised, tag)
+		}
+	}
+	slices.Sort(normalised)
+	return normalised, nil
+}
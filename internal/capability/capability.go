@@ -0,0 +1,327 @@
+package capability
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgtype"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/SyntinelNyx/syntinel-server/internal/database/query"
+	"github.com/SyntinelNyx/syntinel-server/internal/endpoint"
+	"github.com/SyntinelNyx/syntinel-server/internal/grpc"
+	"github.com/SyntinelNyx/syntinel-server/internal/logger"
+	"github.com/SyntinelNyx/syntinel-server/internal/proto/controlpb"
+)
+
+var ErrMissingCapability = errors.New("agent is missing a required capability")
+
+const refreshConcurrency = 8
+
+type Tool struct {
+	Name    string `json:"name"`
+	Version string `json:"version"`
+}
+
+// Capabilities is what an agent reported in its last handshake.
+type Capabilities struct {
+	AgentVersion string
+	OS           string
+	Arch         string
+	Operations   []string
+	Tools        []Tool
+	ReportedAt   time.Time
+}
+
+func (c Capabilities) Supports(operation string) bool {
+	return slices.Contains(c.Operations, operation)
+}
+
+func (c Capabilities) HasTool(name string) bool {
+	return slices.ContainsFunc(c.Tools, func(tool Tool) bool { return tool.Name == name })
+}
+
+// Store caches the capabilities of every asset and persists new handshakes.
+type Store struct {
+	queries   *query.Queries
+	handshake func(ctx context.Context, assetID pgtype.UUID, target string) (*controlpb.Capabilities, error)
+
+	mu     sync.RWMutex
+	assets map[pgtype.UUID]Capabilities
+}
+
+var store *Store
+
+func NewStore(queries *query.Queries) *Store {
+	return &Store{
+		queries:   queries,
+		handshake: grpc.Handshake,
+		assets:    make(map[pgtype.UUID]Capabilities),
+	}
+}
+
+// Start loads the stored capabilities of every asset, then refreshes them
+// in the background since agents may have been upgraded while the server was
+// down. Agents that open a tunnel later are refreshed from their hello.
+func Start(queries *query.Queries) error {
+	s := NewStore(queries)
+	if err := s.load(context.Background()); err != nil {
+		return err
+	}
+
+	store = s
+	grpc.Tunnels.OnOpen(func(assetID pgtype.UUID) {
+		go s.refreshInBackground(assetID)
+	})
+	go s.refreshAll()
+
+	return nil
+}
+
+// Get returns the last capabilities reported by the asset's agent. ok is
+// false if the agent has never completed a handshake.
+func Get(assetID pgtype.UUID) (Capabilities, bool) {
+	if store == nil {
+		return Capabilities{}, false
+	}
+	return store.Get(assetID)
+}
+
+// Refresh performs a handshake with the asset's agent and stores the result.
+func Refresh(ctx context.Context, assetID pgtype.UUID) (Capabilities, bool, error) {
+	if store == nil {
+		return Capabilities{}, false, nil
+	}
+	return store.Refresh(ctx, assetID)
+}
+
+// Check returns ErrMissingCapability if the asset's agent has reported
+// capabilities that do not cover commands. Agents that have not completed a
+// handshake are assumed to support everything, as they did before.
+func Check(assetID pgtype.UUID, commands []*controlpb.ControlMessage) error {
+	if store == nil {
+		return nil
+	}
+	return store.Check(assetID, commands)
+}
+
+func (s *Store) Get(assetID pgtype.UUID) (Capabilities, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	capabilities, ok := s.assets[assetID]
+	return capabilities, ok
+}
+
+func (s *Store) Check(assetID pgtype.UUID, commands []*controlpb.ControlMessage) error {
+	capabilities, ok := s.Get(assetID)
+	if !ok {
+		return nil
+	}
+
+	var missing []string
+	for _, cmd := range commands {
+		operation, tools := Requirements(cmd)
+		if operation != "" && !capabilities.Supports(operation) {
+			missing = append(missing, "operation "+operation)
+		}
+		for _, tool := range tools {
+			if !capabilities.HasTool(tool) {
+				missing = append(missing, "tool "+tool)
+			}
+		}
+	}
+
+	if len(missing) > 0 {
+		return fmt.Errorf("%w: asset %s (agent %s) lacks %s", ErrMissingCapability, uuid.UUID(assetID.Bytes), capabilities.AgentVersion, strings.Join(missing, ", "))
+	}
+	return nil
+}
+
+// Requirements returns the operation cmd needs the agent to support and the
+// external tools the operation runs. Commands from before typed operations
+// have no requirements.
+func Requirements(cmd *controlpb.ControlMessage) (string, []string) {
+	if cmd.Command != "" {
+		return "", nil
+	}
+
+	operation := grpc.Operation(cmd)
+	switch op := cmd.Operation.(type) {
+	case *controlpb.ControlMessage_RunScan:
+		return operation, []string{op.RunScan.Scanner}
+	case *controlpb.ControlMessage_Snapshot, *controlpb.ControlMessage_ListSnapshots:
+		return operation, []string{"kopia"}
+	case nil:
+		return "", nil
+	default:
+		return operation, nil
+	}
+}
+
+func (s *Store) Refresh(ctx context.Context, assetID pgtype.UUID) (Capabilities, bool, error) {
+	reported, err := s.fetch(ctx, assetID)
+	if status.Code(err) == codes.Unimplemented {
+		logger.Info("Agent for asset %s does not support the handshake", uuid.UUID(assetID.Bytes))
+		return Capabilities{}, false, nil
+	}
+	if err != nil {
+		return Capabilities{}, false, fmt.Errorf("handshake with agent failed: %v", err)
+	}
+	if reported == nil {
+		return Capabilities{}, false, nil
+	}
+
+	capabilities := fromProto(reported, time.Now())
+	if err := s.save(ctx, assetID, capabilities); err != nil {
+		return Capabilities{}, false, err
+	}
+
+	return capabilities, true, nil
+}
+
+func (s *Store) refreshAll() {
+	assets, err := s.queries.GetAllAssetIPs(context.Background())
+	if err != nil {
+		logger.Error("Failed to get assets to refresh capabilities: %v", err)
+		return
+	}
+
+	sem := make(chan struct{}, refreshConcurrency)
+	var wg sync.WaitGroup
+	for _, asset := range assets {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func() {
+			defer wg.Done()
+			defer func() { <-sem }()
+
+			s.refreshInBackground(asset.AssetID)
+		}()
+	}
+	wg.Wait()
+}
+
+func (s *Store) refreshInBackground(assetID pgtype.UUID) {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	if _, _, err := s.Refresh(ctx, assetID); err != nil {
+		logger.Warn("Failed to refresh capabilities of asset %s: %v", uuid.UUID(assetID.Bytes), err)
+	}
+}
+
+// fetch reads the capabilities a tunneled agent sent in its hello, or calls
+// the Handshake RPC on agents that are dialed directly.
+func (s *Store) fetch(ctx context.Context, assetID pgtype.UUID) (*controlpb.Capabilities, error) {
+	if session := grpc.Tunnels.Get(assetID); session != nil {
+		return session.Capabilities(), nil
+	}
+
+	target, err := endpoint.Resolve(ctx, s.queries, assetID)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.handshake(ctx, assetID, target)
+}
+
+func (s *Store) save(ctx context.Context, assetID pgtype.UUID, capabilities Capabilities) error {
+	tools, err := json.Marshal(capabilities.Tools)
+	if err != nil {
+		return fmt.Errorf("failed to encode agent tools: %v", err)
+	}
+
+	err = s.queries.UpsertAssetCapabilities(ctx, query.UpsertAssetCapabilitiesParams{
+		AssetID:      assetID,
+		AgentVersion: capabilities.AgentVersion,
+		Os:           capabilities.OS,
+		Arch:         capabilities.Arch,
+		Operations:   capabilities.Operations,
+		Tools:        tools,
+		ReportedAt:   pgtype.Timestamptz{Time: capabilities.ReportedAt, Valid: true},
+	})
+	if err != nil {
+		return fmt.Errorf("failed to store agent capabilities: %v", err)
+	}
+
+	s.mu.Lock()
+	s.assets[assetID] = capabilities
+	s.mu.Unlock()
+
+	return nil
+}
+
+func (s *Store) load(ctx context.Context) error {
+	rows, err := s.queries.GetAllAssetCapabilities(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to load agent capabilities: %v", err)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, row := range rows {
+		capabilities, err := FromRow(row)
+		if err != nil {
+			logger.Warn("Ignoring stored capabilities of asset %s: %v", uuid.UUID(row.AssetID.Bytes), err)
+			continue
+		}
+		s.assets[row.AssetID] = capabilities
+	}
+
+	return nil
+}
+
+func FromRow(row query.AssetCapability) (Capabilities, error) {
+	var tools []Tool
+	if err := json.Unmarshal(row.Tools, &tools); err != nil {
+		return Capabilities{}, fmt.Errorf("invalid tools: %v", err)
+	}
+
+	return Capabilities{
+		AgentVersion: row.AgentVersion,
+		OS:           row.Os,
+		Arch:         row.Arch,
+		Operations:   row.Operations,
+		Tools:        tools,
+		ReportedAt:   row.ReportedAt.Time,
+	}, nil
+}
+
+func fromProto(reported *controlpb.Capabilities, now time.Time) Capabilities {
+	capabilities := Capabilities{
+		AgentVersion: reported.AgentVersion,
+		OS:           normalizeOS(reported.Os),
+		Arch:         reported.Arch,
+		Operations:   reported.Operations,
+		Tools:        []Tool{},
+		ReportedAt:   now,
+	}
+	if capabilities.Operations == nil {
+		capabilities.Operations = []string{}
+	}
+	for _, tool := range reported.Tools {
+		capabilities.Tools = append(capabilities.Tools, Tool{Name: tool.Name, Version: tool.Version})
+	}
+
+	return capabilities
+}
+
+// normalizeOS maps the GOOS reported by agents to the names used when
+// assets enroll.
+func normalizeOS(os string) string {
+	os = strings.ToLower(os)
+	if os == "darwin" {
+		return "mac"
+	}
+	return os
+}
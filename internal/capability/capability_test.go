@@ -0,0 +1,45 @@
+package capability
+
+import (
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/SyntinelNyx/syntinel-server/internal/proto/controlpb"
+)
+
+func TestCheck(t *testing.T) {
+	s := NewStore(nil)
+	known := pgtype.UUID{Bytes: [16]byte{1}, Valid: true}
+	unknown := pgtype.UUID{Bytes: [16]byte{2}, Valid: true}
+
+	s.assets[known] = fromProto(&controlpb.Capabilities{
+		AgentVersion: "1.4.0",
+		Os:           "darwin",
+		Operations:   []string{"run_scan", "sys_info"},
+		Tools:        []*controlpb.Tool{{Name: "trivy", Version: "0.58.1"}},
+	}, time.Now())
+
+	scan := &controlpb.ControlMessage{Operation: &controlpb.ControlMessage_RunScan{RunScan: &controlpb.RunScan{Scanner: "trivy"}}}
+	grype := &controlpb.ControlMessage{Operation: &controlpb.ControlMessage_RunScan{RunScan: &controlpb.RunScan{Scanner: "grype"}}}
+	snapshot := &controlpb.ControlMessage{Operation: &controlpb.ControlMessage_Snapshot{Snapshot: &controlpb.Snapshot{}}}
+	legacy := &controlpb.ControlMessage{Command: "exec", Payload: "uptime"}
+
+	assert.NoError(t, s.Check(known, []*controlpb.ControlMessage{scan, legacy}))
+	assert.NoError(t, s.Check(unknown, []*controlpb.ControlMessage{snapshot}))
+
+	err := s.Check(known, []*controlpb.ControlMessage{grype})
+	require.ErrorIs(t, err, ErrMissingCapability)
+	assert.Contains(t, err.Error(), "tool grype")
+
+	err = s.Check(known, []*controlpb.ControlMessage{snapshot})
+	require.ErrorIs(t, err, ErrMissingCapability)
+	assert.Contains(t, err.Error(), "operation snapshot, tool kopia")
+
+	capabilities, ok := s.Get(known)
+	require.True(t, ok)
+	assert.Equal(t, "mac", capabilities.OS)
+}
@@ -1,16 +1,53 @@
 package commands
 
 import (
+	"context"
 	"fmt"
 
+	"github.com/SyntinelNyx/syntinel-server/internal/capability"
 	"github.com/SyntinelNyx/syntinel-server/internal/grpc"
+	"github.com/SyntinelNyx/syntinel-server/internal/liveness"
 	"github.com/SyntinelNyx/syntinel-server/internal/proto/controlpb"
+	"github.com/jackc/pgx/v5/pgtype"
 )
 
-func Command(target string, commands []*controlpb.ControlMessage) ([]*controlpb.ControlResponse, error) {
-	response, err := grpc.Send(target, commands)
+// Command runs commands on the asset's agent. The call is bounded by ctx and
+// by the configured timeout of the longest-running operation in commands.
+func Command(ctx context.Context, assetID pgtype.UUID, target string, commands []*controlpb.ControlMessage) ([]*controlpb.ControlResponse, error) {
+	response, err := send(ctx, assetID, target, commands)
 	if err != nil {
-		return nil, fmt.Errorf("failed to send command to agent %s: %v", target, err)
+		return nil, fmt.Errorf("failed to send command to agent %s: %w", target, err)
 	}
 	return response, nil
 }
+
+// send prefers an agent-initiated tunnel for the asset and falls back to
+// dialing the agent directly at target. Assets known to be offline are
+// rejected without dialing.
+func send(ctx context.Context, assetID pgtype.UUID, target string, commands []*controlpb.ControlMessage) ([]*controlpb.ControlResponse, error) {
+	if err := liveness.Check(assetID); err != nil {
+		return nil, err
+	}
+	if err := capability.Check(assetID, commands); err != nil {
+		return nil, err
+	}
+
+	operation, timeout := timeoutFor(commands)
+	ctx, cancel, after := withDeadline(ctx, timeout)
+	defer cancel()
+
+	var responses []*controlpb.ControlResponse
+	var err error
+	if session := grpc.Tunnels.Get(assetID); session != nil {
+		responses, err = session.Send(ctx, commands)
+	} else if target == "" {
+		return nil, fmt.Errorf("asset has no reachable agent address and no open tunnel")
+	} else {
+		responses, err = grpc.Send(ctx, assetID, target, commands)
+	}
+
+	if err == nil {
+		liveness.Seen(assetID)
+	}
+	return responses, timeoutError(ctx, err, operation, after)
+}
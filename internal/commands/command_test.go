@@ -0,0 +1,106 @@
+package commands
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/SyntinelNyx/syntinel-server/internal/agenttest"
+	"github.com/SyntinelNyx/syntinel-server/internal/proto/controlpb"
+)
+
+const agentAddress = "10.0.0.5:50051"
+
+var testAsset = pgtype.UUID{Bytes: [16]byte{5}, Valid: true}
+
+func TestCommandAgainstFakeAgent(t *testing.T) {
+	network := agenttest.Install(t)
+	agent := agenttest.NewAgent().
+		On("sys_info", agenttest.Output(`{"cpu":12.5}`)).
+		On("exec_script", agenttest.Output("first\n"), agenttest.Failure(2, "second failed\n"))
+	network.Serve(t, agentAddress, agent)
+
+	responses, err := Command(context.Background(), testAsset, agentAddress, []*controlpb.ControlMessage{
+		SysInfo(),
+		ExecScript("true"),
+		ExecScript("false"),
+	})
+	require.NoError(t, err)
+	require.Len(t, responses, 3)
+
+	out, err := CompleteOutput(responses[0])
+	require.NoError(t, err)
+	assert.Equal(t, `{"cpu":12.5}`, out)
+
+	err = CheckAll(responses)
+	var execErr *ExecError
+	require.ErrorAs(t, err, &execErr)
+	assert.EqualValues(t, 2, execErr.ExitCode)
+
+	received := agent.Received()
+	require.Len(t, received, 3)
+	assert.Equal(t, []string{"false"}, received[2].GetExecScript().Argv)
+}
+
+func TestCommandTimesOut(t *testing.T) {
+	viper.Set("agent.timeouts.sys_info", 50*time.Millisecond)
+	t.Cleanup(func() { viper.Set("agent.timeouts.sys_info", nil) })
+
+	network := agenttest.Install(t)
+	network.Serve(t, agentAddress, agenttest.NewAgent().
+		On("sys_info", agenttest.Reply{Response: agenttest.Output("late").Response, Delay: time.Minute}))
+
+	_, err := Command(context.Background(), testAsset, agentAddress, []*controlpb.ControlMessage{SysInfo()})
+	require.True(t, IsTimeout(err), "expected timeout, got %v", err)
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+}
+
+func TestCommandUnreachableAgent(t *testing.T) {
+	agenttest.Install(t)
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	_, err := Command(ctx, testAsset, agentAddress, []*controlpb.ControlMessage{SysInfo()})
+	assert.Error(t, err)
+}
+
+func TestUploadToFakeAgent(t *testing.T) {
+	network := agenttest.Install(t)
+	agent := agenttest.NewAgent()
+	network.Serve(t, agentAddress, agent)
+
+	path := filepath.Join(t.TempDir(), "install.sh")
+	require.NoError(t, os.WriteFile(path, []byte("#!/bin/sh\necho installed\n"), 0o644))
+
+	require.NoError(t, Upload(context.Background(), testAsset, agentAddress, path))
+
+	data, ok := agent.File("install.sh")
+	require.True(t, ok)
+	assert.Equal(t, "#!/bin/sh\necho installed\n", string(data))
+}
+
+func TestDownloadFromFakeAgent(t *testing.T) {
+	network := agenttest.Install(t)
+	data := bytes.Repeat([]byte("0123456789"), 300_000)
+	network.Serve(t, agentAddress, agenttest.NewAgent().PutFile("/var/log/syslog", data))
+
+	var out bytes.Buffer
+	file, err := Download(context.Background(), testAsset, agentAddress, "/var/log/syslog", 10<<20, &out)
+	require.NoError(t, err)
+	assert.Equal(t, "syslog", file.Name)
+	assert.Equal(t, int64(len(data)), file.Size)
+	assert.Equal(t, data, out.Bytes())
+
+	_, err = Download(context.Background(), testAsset, agentAddress, "/var/log/missing", 10<<20, io.Discard)
+	assert.Error(t, err)
+}
@@ -0,0 +1,47 @@
+package commands
+
+import (
+	"context"
+	"fmt"
+	"io"
+
+	"github.com/jackc/pgx/v5/pgtype"
+
+	"github.com/SyntinelNyx/syntinel-server/internal/capability"
+	"github.com/SyntinelNyx/syntinel-server/internal/grpc"
+	"github.com/SyntinelNyx/syntinel-server/internal/liveness"
+	"github.com/SyntinelNyx/syntinel-server/internal/proto/controlpb"
+)
+
+// Download streams the file at path on the agent into w. Files larger than
+// maxSize are refused with grpc.ErrFileTooLarge, and a file whose contents do
+// not match the digest the agent announced fails with grpc.ErrDigestMismatch.
+// w may have been partially written when an error is returned.
+func Download(ctx context.Context, assetID pgtype.UUID, target string, path string, maxSize int64, w io.Writer) (grpc.DownloadedFile, error) {
+	if err := liveness.Check(assetID); err != nil {
+		return grpc.DownloadedFile{}, err
+	}
+	if err := capability.Check(assetID, []*controlpb.ControlMessage{FileTransfer(path, 0)}); err != nil {
+		return grpc.DownloadedFile{}, err
+	}
+
+	ctx, cancel, after := withDeadline(ctx, Timeout("file_transfer"))
+	defer cancel()
+
+	var file grpc.DownloadedFile
+	var err error
+	if session := grpc.Tunnels.Get(assetID); session != nil {
+		file, err = session.Download(ctx, path, maxSize, w)
+	} else if target == "" {
+		return grpc.DownloadedFile{}, fmt.Errorf("asset has no reachable agent address and no open tunnel")
+	} else {
+		file, err = grpc.Download(ctx, assetID, target, path, maxSize, w)
+	}
+
+	if err != nil {
+		return grpc.DownloadedFile{}, timeoutError(ctx, fmt.Errorf("failed to download %s from agent: %w", path, err), "file_transfer", after)
+	}
+
+	liveness.Seen(assetID)
+	return file, nil
+}
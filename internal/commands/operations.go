@@ -0,0 +1,61 @@
+package commands
+
+import (
+	"github.com/SyntinelNyx/syntinel-server/internal/proto/controlpb"
+)
+
+// ExecScript runs argv[0] on the agent with the remaining elements as its
+// arguments. No shell is involved unless argv asks for one.
+func ExecScript(argv ...string) *controlpb.ControlMessage {
+	return &controlpb.ControlMessage{
+		Operation: &controlpb.ControlMessage_ExecScript{ExecScript: &controlpb.ExecScript{Argv: argv}},
+	}
+}
+
+// Shell runs a user-supplied command line through /bin/sh, for the terminal
+// and command actions where a shell is what the user asked for.
+func Shell(command string) *controlpb.ControlMessage {
+	return ExecScript("/bin/sh", "-c", command)
+}
+
+func RunScan(scanner string, targetPath string, args []string) *controlpb.ControlMessage {
+	return &controlpb.ControlMessage{
+		Operation: &controlpb.ControlMessage_RunScan{RunScan: &controlpb.RunScan{
+			Scanner:    scanner,
+			TargetPath: targetPath,
+			Args:       args,
+		}},
+	}
+}
+
+func Snapshot(repository *controlpb.S3Repository, path string) *controlpb.ControlMessage {
+	return &controlpb.ControlMessage{
+		Operation: &controlpb.ControlMessage_Snapshot{Snapshot: &controlpb.Snapshot{
+			Repository: repository,
+			Path:       path,
+		}},
+	}
+}
+
+func ListSnapshots(repository *controlpb.S3Repository) *controlpb.ControlMessage {
+	return &controlpb.ControlMessage{
+		Operation: &controlpb.ControlMessage_ListSnapshots{ListSnapshots: &controlpb.ListSnapshots{
+			Repository: repository,
+		}},
+	}
+}
+
+func SysInfo() *controlpb.ControlMessage {
+	return &controlpb.ControlMessage{
+		Operation: &controlpb.ControlMessage_SysInfo{SysInfo: &controlpb.SysInfo{}},
+	}
+}
+
+func FileTransfer(path string, offset int64) *controlpb.ControlMessage {
+	return &controlpb.ControlMessage{
+		Operation: &controlpb.ControlMessage_FileTransfer{FileTransfer: &controlpb.FileTransfer{
+			Path:   path,
+			Offset: offset,
+		}},
+	}
+}
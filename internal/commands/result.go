@@ -0,0 +1,97 @@
+package commands
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/SyntinelNyx/syntinel-server/internal/proto/controlpb"
+)
+
+var ErrNoResponse = errors.New("no response from agent")
+
+var ErrTruncated = errors.New("agent output was truncated")
+
+type ExecError struct {
+	ExitCode int32
+	Stderr   string
+}
+
+func (e *ExecError) Error() string {
+	stderr := strings.TrimSpace(e.Stderr)
+	if stderr == "" {
+		return fmt.Sprintf("command exited with code %d", e.ExitCode)
+	}
+	return fmt.Sprintf("command exited with code %d: %s", e.ExitCode, stderr)
+}
+
+// structured reports whether the agent filled in the exit code and output
+// fields. Older agents only send Result and a free-text Status.
+func structured(resp *controlpb.ControlResponse) bool {
+	return resp.GetStartedAt() != nil
+}
+
+// Output returns the stdout of resp, or an *ExecError if the command failed.
+func Output(resp *controlpb.ControlResponse) (string, error) {
+	if resp == nil {
+		return "", ErrNoResponse
+	}
+
+	if !structured(resp) {
+		if resp.GetStatus() == "error" {
+			return "", &ExecError{ExitCode: -1, Stderr: resp.GetResult()}
+		}
+		return resp.GetResult(), nil
+	}
+
+	if resp.GetExitCode() != 0 {
+		return "", &ExecError{ExitCode: resp.GetExitCode(), Stderr: resp.GetStderr()}
+	}
+
+	return resp.GetStdout(), nil
+}
+
+// CompleteOutput is Output for callers that parse stdout and cannot work
+// with a partial result. Exit codes in okExitCodes are not failures, for tools
+// that report findings through their exit status.
+func CompleteOutput(resp *controlpb.ControlResponse, okExitCodes ...int32) (string, error) {
+	out, err := Output(resp)
+	var execErr *ExecError
+	if errors.As(err, &execErr) && structured(resp) && slices.Contains(okExitCodes, execErr.ExitCode) {
+		out, err = resp.GetStdout(), nil
+	}
+	if err != nil {
+		return "", err
+	}
+
+	if resp.GetTruncated() {
+		return "", ErrTruncated
+	}
+
+	return out, nil
+}
+
+// CheckAll returns the first failure in responses, if any.
+func CheckAll(responses []*controlpb.ControlResponse) error {
+	if len(responses) == 0 {
+		return ErrNoResponse
+	}
+
+	for _, resp := range responses {
+		if _, err := Output(resp); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func Duration(resp *controlpb.ControlResponse) time.Duration {
+	if !structured(resp) || resp.GetFinishedAt() == nil {
+		return 0
+	}
+
+	return resp.GetFinishedAt().AsTime().Sub(resp.GetStartedAt().AsTime())
+}
@@ -0,0 +1,60 @@
+package commands
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	"github.com/SyntinelNyx/syntinel-server/internal/proto/controlpb"
+)
+
+func TestOutput(t *testing.T) {
+	start := time.Now()
+	finish := start.Add(1500 * time.Millisecond)
+
+	ok := &controlpb.ControlResponse{
+		Stdout:     "hello\n",
+		StartedAt:  timestamppb.New(start),
+		FinishedAt: timestamppb.New(finish),
+	}
+	out, err := Output(ok)
+	require.NoError(t, err)
+	assert.Equal(t, "hello\n", out)
+	assert.Equal(t, 1500*time.Millisecond, Duration(ok))
+
+	failed := &controlpb.ControlResponse{
+		ExitCode:  2,
+		Stderr:    "no such file",
+		StartedAt: timestamppb.New(start),
+	}
+	_, err = Output(failed)
+	var execErr *ExecError
+	require.True(t, errors.As(err, &execErr))
+	assert.Equal(t, int32(2), execErr.ExitCode)
+	assert.Equal(t, "no such file", execErr.Stderr)
+
+	truncated := &controlpb.ControlResponse{Stdout: "{", Truncated: true, StartedAt: timestamppb.New(start)}
+	_, err = CompleteOutput(truncated)
+	assert.ErrorIs(t, err, ErrTruncated)
+	found := &controlpb.ControlResponse{ExitCode: 1, Stdout: "{}", StartedAt: timestamppb.New(start)}
+	out, err = CompleteOutput(found, 1)
+	require.NoError(t, err)
+	assert.Equal(t, "{}", out)
+	_, err = CompleteOutput(failed, 1)
+	assert.Error(t, err)
+}
+
+func TestOutputLegacyAgent(t *testing.T) {
+	out, err := Output(&controlpb.ControlResponse{Status: "success", Result: "ok"})
+	require.NoError(t, err)
+	assert.Equal(t, "ok", out)
+
+	_, err = Output(&controlpb.ControlResponse{Status: "error", Result: "boom"})
+	assert.Error(t, err)
+
+	assert.ErrorIs(t, CheckAll(nil), ErrNoResponse)
+}
@@ -0,0 +1,87 @@
+package commands
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/spf13/viper"
+
+	"github.com/SyntinelNyx/syntinel-server/internal/grpc"
+	"github.com/SyntinelNyx/syntinel-server/internal/proto/controlpb"
+)
+
+const defaultTimeout = 2 * time.Minute
+
+// defaultTimeouts are used when agent.timeouts.<operation> is not set.
+var defaultTimeouts = map[string]time.Duration{
+	"sys_info":       15 * time.Second,
+	"exec_script":    5 * time.Minute,
+	"list_snapshots": 2 * time.Minute,
+	"snapshot":       time.Hour,
+	"run_scan":       30 * time.Minute,
+	"file_transfer":  30 * time.Minute,
+	"upload":         30 * time.Minute,
+}
+
+type TimeoutError struct {
+	Operation string
+	After     time.Duration
+}
+
+func (e *TimeoutError) Error() string {
+	return fmt.Sprintf("agent did not complete %s within %s", e.Operation, e.After.Round(time.Second))
+}
+
+func (e *TimeoutError) Unwrap() error {
+	return context.DeadlineExceeded
+}
+
+func IsTimeout(err error) bool {
+	var timeoutErr *TimeoutError
+	return errors.As(err, &timeoutErr)
+}
+
+func Timeout(operation string) time.Duration {
+	key := "agent.timeouts." + operation
+	if viper.IsSet(key) {
+		return viper.GetDuration(key)
+	}
+	if timeout, ok := defaultTimeouts[operation]; ok {
+		return timeout
+	}
+	return defaultTimeout
+}
+
+// timeoutFor returns the longest timeout of the operations in commands, since
+// they all run on the same stream.
+func timeoutFor(commands []*controlpb.ControlMessage) (string, time.Duration) {
+	operation, timeout := "command", time.Duration(0)
+	for _, cmd := range commands {
+		name := grpc.Operation(cmd)
+		if t := Timeout(name); t > timeout {
+			operation, timeout = name, t
+		}
+	}
+	if timeout == 0 {
+		timeout = defaultTimeout
+	}
+	return operation, timeout
+}
+
+// withDeadline applies the operation's default timeout to ctx unless ctx
+// already ends sooner, and returns how long the call is allowed to run.
+func withDeadline(ctx context.Context, timeout time.Duration) (context.Context, context.CancelFunc, time.Duration) {
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	deadline, _ := ctx.Deadline()
+	return ctx, cancel, time.Until(deadline)
+}
+
+// timeoutError converts err into a *TimeoutError if ctx hit its deadline.
+func timeoutError(ctx context.Context, err error, operation string, after time.Duration) error {
+	if err != nil && errors.Is(ctx.Err(), context.DeadlineExceeded) {
+		return &TimeoutError{Operation: operation, After: after}
+	}
+	return err
+}
@@ -1,49 +1,84 @@
 package commands
 
 import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
 	"fmt"
 	"io"
 	"os"
 	"path/filepath"
 
+	"github.com/SyntinelNyx/syntinel-server/internal/capability"
 	"github.com/SyntinelNyx/syntinel-server/internal/grpc"
+	"github.com/SyntinelNyx/syntinel-server/internal/liveness"
+	"github.com/SyntinelNyx/syntinel-server/internal/logger"
 	"github.com/SyntinelNyx/syntinel-server/internal/proto/controlpb"
+	"github.com/jackc/pgx/v5/pgtype"
 )
 
-func Upload(target string, filepaths string) ([]*controlpb.ControlResponse, error) {
-	name := filepath.Base(filepaths)
+const uploadAttempts = 3
 
-	file, err := os.Open(filepaths)
+// Upload streams the file at path to the agent. Interrupted transfers are
+// retried from the last offset the agent acknowledged, and a digest mismatch
+// is returned as grpc.ErrDigestMismatch without retrying.
+func Upload(ctx context.Context, assetID pgtype.UUID, target string, path string) error {
+	file, err := os.Open(path)
 	if err != nil {
-		return nil, fmt.Errorf("error opening file: %v", err)
-
+		return fmt.Errorf("error opening file: %v", err)
 	}
 	defer file.Close()
 
-	var response []*controlpb.ControlResponse
-	buffer := make([]byte, 1024*1024)
-	for {
-		n, err := file.Read(buffer)
-		if err != nil && err != io.EOF {
-			return nil, fmt.Errorf("error reading file: %v", err)
+	hash := sha256.New()
+	size, err := io.Copy(hash, file)
+	if err != nil {
+		return fmt.Errorf("error reading file: %v", err)
+	}
+	digest := hex.EncodeToString(hash.Sum(nil))
+
+	upload := grpc.UploadFile{
+		ID:     digest,
+		Name:   filepath.Base(path),
+		Size:   size,
+		SHA256: digest,
+		Reader: file,
+	}
+
+	// Uploads use the same file transfer support that downloads require.
+	if err := capability.Check(assetID, []*controlpb.ControlMessage{FileTransfer(upload.Name, 0)}); err != nil {
+		return err
+	}
+
+	ctx, cancel, after := withDeadline(ctx, Timeout("upload"))
+	defer cancel()
+
+	for attempt := 1; attempt <= uploadAttempts; attempt++ {
+		err = sendFile(ctx, assetID, target, upload)
+		if ctx.Err() != nil {
+			return timeoutError(ctx, err, "upload", after)
 		}
-		if n == 0 {
-			break
+		if err == nil || errors.Is(err, grpc.ErrDigestMismatch) || errors.Is(err, liveness.ErrAgentOffline) {
+			return err
 		}
+		logger.Warn("Upload of %s to agent %s failed (attempt %d/%d): %v", upload.Name, target, attempt, uploadAttempts, err)
+	}
 
-		commands := []*controlpb.ControlMessage{
-			{
-				Command: "download",
-				Payload: name,
-				Misc:    buffer[:n],
-			},
-		}
+	return fmt.Errorf("failed to upload %s to agent %s: %w", upload.Name, target, err)
+}
 
-		response, err = grpc.Send(target, commands)
-		if err != nil {
-			return nil, fmt.Errorf("failed to send command to agent %s", target)
-		}
+func sendFile(ctx context.Context, assetID pgtype.UUID, target string, file grpc.UploadFile) error {
+	if err := liveness.Check(assetID); err != nil {
+		return err
+	}
+
+	if session := grpc.Tunnels.Get(assetID); session != nil {
+		return session.Upload(ctx, file)
+	}
+
+	if target == "" {
+		return fmt.Errorf("asset has no reachable agent address and no open tunnel")
 	}
 
-	return response, nil
+	return grpc.Upload(ctx, assetID, target, file)
 }
@@ -55,7 +55,12 @@ INSERT INTO assets (
     asset_id,
     ip_address,
     sysinfo_id,
-    root_account_id
+    root_account_id,
+    cert_fingerprint,
+    cert_serial,
+    cert_not_after,
+    agent_hostname,
+    agent_port
   )
 VALUES (
     $21,
@@ -64,7 +69,12 @@ VALUES (
       SELECT id
       FROM inserted_sysinfo
     ),
-    $23
+    $23,
+    $24,
+    $25,
+    $26,
+    $27,
+    $28
   );
 
 -- name: GetAllAssets :many
@@ -73,9 +83,12 @@ SELECT a.asset_id,
   s.os,
   s.platform_version,
   a.ip_address,
-  s.created_at
+  s.created_at,
+  l.status,
+  l.last_seen_at
 FROM assets a
   JOIN system_information s ON a.sysinfo_id = s.id
+  LEFT JOIN asset_liveness l ON l.asset_id = a.asset_id
 WHERE a.root_account_id = $1;
 
 -- name: GetAllAssetsMin :many
@@ -96,6 +109,9 @@ SELECT a.asset_id,
   a.sysinfo_id,
   a.root_account_id,
   a.registered_at,
+  a.agent_hostname,
+  a.agent_port,
+  a.agent_endpoint_override,
   s.id AS system_info_id,
   s.hostname,
   s.uptime,
@@ -117,17 +133,65 @@ SELECT a.asset_id,
   s.cpu_cache_size,
   s.memory,
   s.disk,
-  s.created_at AS system_info_created_at
+  s.created_at AS system_info_created_at,
+  l.status,
+  l.last_seen_at,
+  l.status_changed_at
 FROM assets a
   JOIN system_information s ON a.sysinfo_id = s.id
+  LEFT JOIN asset_liveness l ON l.asset_id = a.asset_id
 WHERE a.asset_id = $1;
 
--- name: GetAssetsByHostnames :many
-SELECT a.asset_id,
-  a.ip_address,
-  s.os,
-  a.root_account_id,
-  s.hostname
-FROM assets a
-  JOIN system_information s ON s.id = a.sysinfo_id
-WHERE s.hostname = ANY(@hostnames::text []);
\ No newline at end of file
+-- name: GetAssetCertificate :one
+SELECT cert_fingerprint,
+  cert_serial,
+  cert_not_after,
+  cert_revoked_at
+FROM assets
+WHERE asset_id = $1;
+
+-- name: RevokeAssetCertificate :execrows
+UPDATE assets
+SET cert_revoked_at = NOW()
+WHERE asset_id = $1
+  AND root_account_id = $2
+  AND cert_revoked_at IS NULL;
+
+-- name: ReenrollAsset :execrows
+-- Stores the certificate issued to an asset that enrolls again, which also
+-- lifts a revocation. Assets of other root accounts are left alone.
+UPDATE assets
+SET ip_address = @ip_address,
+  cert_fingerprint = @cert_fingerprint,
+  cert_serial = @cert_serial,
+  cert_not_after = @cert_not_after,
+  cert_revoked_at = NULL,
+  agent_hostname = @agent_hostname,
+  agent_port = @agent_port
+WHERE asset_id = @asset_id
+  AND root_account_id = @root_account_id;
+
+-- name: GetAssetEndpointForRootAccount :one
+SELECT ip_address,
+  agent_hostname,
+  agent_port,
+  agent_endpoint_override
+FROM assets
+WHERE asset_id = $1
+  AND root_account_id = $2;
+
+-- name: GetAssetEndpoint :one
+SELECT ip_address,
+  agent_hostname,
+  agent_port,
+  agent_endpoint_override
+FROM assets
+WHERE asset_id = $1;
+
+-- name: UpdateAssetEndpoint :execrows
+UPDATE assets
+SET agent_hostname = $3,
+  agent_port = $4,
+  agent_endpoint_override = $5
+WHERE asset_id = $1
+  AND root_account_id = $2;
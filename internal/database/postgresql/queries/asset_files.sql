@@ -0,0 +1,28 @@
+-- name: CreateAssetFile :one
+INSERT INTO asset_files (
+    file_id,
+    asset_id,
+    root_account_id,
+    remote_path,
+    name,
+    size_bytes,
+    sha256,
+    storage_path,
+    retrieved_by
+  )
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+RETURNING *;
+
+-- name: ListAssetFiles :many
+SELECT *
+FROM asset_files
+WHERE asset_id = $1
+  AND root_account_id = $2
+ORDER BY retrieved_at DESC;
+
+-- name: GetAssetFile :one
+SELECT *
+FROM asset_files
+WHERE file_id = $1
+  AND asset_id = $2
+  AND root_account_id = $3;
@@ -0,0 +1,15 @@
+-- name: AddAssetTags :exec
+INSERT INTO asset_tags (asset_id, tag)
+SELECT @asset_id::uuid,
+  unnest(@tags::text [])
+ON CONFLICT DO NOTHING;
+
+-- name: DeleteAssetTags :exec
+DELETE FROM asset_tags
+WHERE asset_id = $1;
+
+-- name: GetAssetTags :many
+SELECT tag
+FROM asset_tags
+WHERE asset_id = $1
+ORDER BY tag;
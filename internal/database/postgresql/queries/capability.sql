@@ -0,0 +1,27 @@
+-- name: UpsertAssetCapabilities :exec
+INSERT INTO asset_capabilities (
+    asset_id,
+    agent_version,
+    os,
+    arch,
+    operations,
+    tools,
+    reported_at
+  )
+VALUES ($1, $2, $3, $4, $5, $6, $7) ON CONFLICT (asset_id) DO
+UPDATE
+SET agent_version = EXCLUDED.agent_version,
+  os = EXCLUDED.os,
+  arch = EXCLUDED.arch,
+  operations = EXCLUDED.operations,
+  tools = EXCLUDED.tools,
+  reported_at = EXCLUDED.reported_at;
+
+-- name: GetAllAssetCapabilities :many
+SELECT *
+FROM asset_capabilities;
+
+-- name: GetAssetCapabilities :one
+SELECT *
+FROM asset_capabilities
+WHERE asset_id = $1;
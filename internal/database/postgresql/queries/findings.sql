@@ -0,0 +1,351 @@
+-- name: UpsertFindingData :exec
+INSERT INTO finding_data (
+        finding_type,
+        rule_id,
+        title,
+        description,
+        severity,
+        resolution,
+        reference
+    )
+SELECT DISTINCT ON (f->>'Type', f->>'RuleID') f->>'Type',
+    f->>'RuleID',
+    COALESCE(f->>'Title', ''),
+    COALESCE(f->>'Description', ''),
+    COALESCE(NULLIF(f->>'Severity', ''), 'Unknown'),
+    COALESCE(f->>'Resolution', ''),
+    CASE
+        WHEN jsonb_typeof(f->'References') = 'array' THEN ARRAY(
+            SELECT jsonb_array_elements_text(f->'References')
+        )
+        ELSE ARRAY []::text []
+    END
+FROM jsonb_array_elements(@findings::jsonb) AS f
+ORDER BY f->>'Type',
+    f->>'RuleID' ON CONFLICT (finding_type, rule_id) DO
+UPDATE
+SET title = EXCLUDED.title,
+    description = EXCLUDED.description,
+    severity = EXCLUDED.severity,
+    resolution = EXCLUDED.resolution,
+    reference = EXCLUDED.reference;
+
+-- name: BatchInsertAssetFindings :exec
+-- The location tells findings of one rule on an asset apart: the file and
+-- line for secrets and misconfigurations, the package and file for licenses.
+INSERT INTO asset_finding_scan (
+        root_account_id,
+        scan_id,
+        asset_id,
+        finding_data_id,
+        file_path,
+        start_line,
+        end_line,
+        license_name,
+        package_name,
+        target,
+        message,
+        location
+    )
+SELECT s.root_account_id,
+    @scan_id AS scan_id,
+    @asset_id AS asset_id,
+    fd.finding_data_id,
+    COALESCE(f->>'FilePath', ''),
+    COALESCE((f->>'StartLine')::int, 0),
+    COALESCE((f->>'EndLine')::int, 0),
+    COALESCE(f->>'LicenseName', ''),
+    COALESCE(f->>'PkgName', ''),
+    COALESCE(f->>'Target', ''),
+    COALESCE(f->>'Message', ''),
+    CASE
+        WHEN f->>'Type' = 'license' THEN COALESCE(f->>'PkgName', '') || '@' || COALESCE(f->>'FilePath', '')
+        ELSE COALESCE(f->>'FilePath', '') || ':' || COALESCE(f->>'StartLine', '0')
+    END
+FROM jsonb_array_elements(@findings::jsonb) AS f
+    JOIN finding_data fd ON fd.finding_type = f->>'Type'
+    AND fd.rule_id = f->>'RuleID'
+    JOIN scans s ON s.scan_id = @scan_id;
+
+-- name: BatchUpdateAssetFindingState :exec
+-- Only findings of the given types are updated, so a scan that did not look
+-- for secrets does not resolve the secrets found earlier. Each location of a
+-- finding goes through the states separately.
+WITH scan AS (
+    SELECT root_account_id
+    FROM scans
+    WHERE scan_id = @scan_id
+),
+seen AS (
+    SELECT DISTINCT afs.asset_id,
+        afs.finding_data_id,
+        afs.location
+    FROM asset_finding_scan afs
+        JOIN finding_data fd ON fd.finding_data_id = afs.finding_data_id
+    WHERE afs.scan_id = @scan_id
+        AND afs.asset_id = ANY(@asset_ids::uuid [])
+        AND fd.finding_type = ANY(@finding_types::text [])
+),
+latest_state_history AS (
+    SELECT DISTINCT ON (afsh.asset_id, afsh.finding_data_id, afsh.location) afsh.asset_id,
+        afsh.finding_data_id,
+        afsh.location,
+        afsh.finding_state
+    FROM asset_finding_state_history afsh
+        JOIN finding_data fd ON fd.finding_data_id = afsh.finding_data_id
+    WHERE afsh.root_account_id = (
+            SELECT root_account_id
+            FROM scan
+        )
+        AND afsh.asset_id = ANY(@asset_ids::uuid [])
+        AND fd.finding_type = ANY(@finding_types::text [])
+    ORDER BY afsh.asset_id,
+        afsh.finding_data_id,
+        afsh.location,
+        afsh.state_changed_at DESC
+)
+INSERT INTO asset_finding_state_history (
+        asset_id,
+        finding_data_id,
+        location,
+        finding_state,
+        root_account_id
+    )
+SELECT COALESCE(seen.asset_id, lsh.asset_id),
+    COALESCE(seen.finding_data_id, lsh.finding_data_id),
+    COALESCE(seen.location, lsh.location),
+    CASE
+        WHEN lsh.finding_data_id IS NULL THEN 'New'::vulnstate
+        WHEN seen.finding_data_id IS NULL THEN 'Resolved'::vulnstate
+        WHEN lsh.finding_state = 'New' THEN 'Active'::vulnstate
+        ELSE 'Resurfaced'::vulnstate
+    END,
+    (
+        SELECT root_account_id
+        FROM scan
+    )
+FROM seen
+    FULL OUTER JOIN latest_state_history lsh ON lsh.asset_id = seen.asset_id
+    AND lsh.finding_data_id = seen.finding_data_id
+    AND lsh.location = seen.location
+WHERE lsh.finding_data_id IS NULL
+    OR (
+        seen.finding_data_id IS NULL
+        AND lsh.finding_state != 'Resolved'
+    )
+    OR (
+        seen.finding_data_id IS NOT NULL
+        AND lsh.finding_state IN ('New', 'Resolved')
+    );
+
+-- name: BatchUpdateFindingState :exec
+-- Account-level states are derived from the per-asset states the same way
+-- as for vulnerabilities.
+WITH asset_states AS (
+    SELECT DISTINCT ON (asset_id, finding_data_id, location) asset_id,
+        finding_data_id,
+        finding_state
+    FROM asset_finding_state_history
+    WHERE root_account_id = @root_account_id
+    ORDER BY asset_id,
+        finding_data_id,
+        location,
+        state_changed_at DESC
+),
+derived_state AS (
+    SELECT finding_data_id,
+        bool_or(finding_state != 'Resolved') AS open,
+        bool_or(finding_state IN ('Active', 'Resurfaced')) AS seen_again
+    FROM asset_states
+    GROUP BY finding_data_id
+    HAVING bool_or(asset_id = ANY(@asset_ids::uuid []))
+),
+latest_state_history AS (
+    SELECT DISTINCT ON (finding_data_id) finding_data_id,
+        finding_state
+    FROM finding_state_history
+    WHERE root_account_id = @root_account_id
+    ORDER BY finding_data_id,
+        state_changed_at DESC
+),
+next_state AS (
+    SELECT ds.finding_data_id,
+        lsh.finding_state AS previous_state,
+        CASE
+            WHEN NOT ds.open THEN 'Resolved'::vulnstate
+            WHEN lsh.finding_state IS NULL THEN 'New'::vulnstate
+            WHEN lsh.finding_state = 'Resolved' THEN 'Resurfaced'::vulnstate
+            WHEN lsh.finding_state = 'New'
+            AND ds.seen_again THEN 'Active'::vulnstate
+            ELSE lsh.finding_state
+        END AS finding_state
+    FROM derived_state ds
+        LEFT JOIN latest_state_history lsh ON lsh.finding_data_id = ds.finding_data_id
+)
+INSERT INTO finding_state_history (
+        finding_data_id,
+        finding_state,
+        root_account_id
+    )
+SELECT finding_data_id,
+    finding_state,
+    @root_account_id
+FROM next_state
+WHERE previous_state IS DISTINCT FROM finding_state;
+
+-- name: GetFindings :many
+-- An empty finding type lists findings of every type.
+WITH latest_state_history AS (
+    SELECT DISTINCT ON (finding_data_id) finding_data_id,
+        finding_state
+    FROM finding_state_history
+    WHERE root_account_id = @root_account_id
+    ORDER BY finding_data_id,
+        state_changed_at DESC
+),
+affected AS (
+    SELECT afs.finding_data_id,
+        afs.asset_id,
+        si.hostname,
+        MAX(afs.scan_date) AS last_seen
+    FROM asset_finding_scan afs
+        JOIN assets a ON a.asset_id = afs.asset_id
+        JOIN system_information si ON si.id = a.sysinfo_id
+    WHERE afs.root_account_id = @root_account_id
+    GROUP BY afs.finding_data_id,
+        afs.asset_id,
+        si.hostname
+)
+SELECT fd.finding_data_id,
+    fd.finding_type,
+    fd.rule_id,
+    fd.title,
+    fd.severity,
+    lsh.finding_state,
+    array_agg(
+        affected.hostname
+        ORDER BY affected.hostname,
+            affected.asset_id
+    )::TEXT [] AS assets_affected,
+    array_agg(
+        affected.asset_id
+        ORDER BY affected.hostname,
+            affected.asset_id
+    )::UUID [] AS asset_uuids,
+    MAX(affected.last_seen)::TIMESTAMPTZ AS last_seen
+FROM finding_data fd
+    JOIN latest_state_history lsh ON lsh.finding_data_id = fd.finding_data_id
+    JOIN affected ON affected.finding_data_id = fd.finding_data_id
+WHERE @finding_type::text = ''
+    OR fd.finding_type = @finding_type::text
+GROUP BY fd.finding_data_id,
+    fd.finding_type,
+    fd.rule_id,
+    fd.title,
+    fd.severity,
+    lsh.finding_state
+ORDER BY CASE
+        WHEN fd.severity = 'Critical' THEN 4
+        WHEN fd.severity = 'High' THEN 3
+        WHEN fd.severity = 'Medium' THEN 2
+        WHEN fd.severity = 'Low' THEN 1
+        WHEN fd.severity = 'Unknown' THEN 0
+        ELSE -1
+    END DESC,
+    fd.finding_type,
+    fd.rule_id;
+
+-- name: GetFindingsByScan :many
+SELECT afs.asset_id,
+    si.hostname,
+    fd.finding_type,
+    fd.rule_id,
+    fd.title,
+    fd.severity,
+    afs.file_path,
+    afs.start_line,
+    afs.end_line,
+    afs.license_name,
+    afs.package_name,
+    afs.target,
+    afs.message
+FROM asset_finding_scan afs
+    JOIN finding_data fd ON fd.finding_data_id = afs.finding_data_id
+    JOIN assets a ON a.asset_id = afs.asset_id
+    JOIN system_information si ON si.id = a.sysinfo_id
+WHERE afs.scan_id = $1
+    AND afs.root_account_id = $2
+ORDER BY si.hostname,
+    fd.finding_type,
+    fd.rule_id,
+    afs.file_path,
+    afs.start_line;
+
+-- name: GetFindingsByAsset :many
+-- Each finding is listed with its state on the asset, taken from its open
+-- locations if it has any, and the locations it was found at by the latest
+-- scan of the asset that reported it.
+WITH location_states AS (
+    SELECT DISTINCT ON (finding_data_id, location) finding_data_id,
+        finding_state,
+        state_changed_at
+    FROM asset_finding_state_history
+    WHERE asset_id = $1
+        AND root_account_id = $2
+    ORDER BY finding_data_id,
+        location,
+        state_changed_at DESC
+),
+asset_states AS (
+    SELECT DISTINCT ON (finding_data_id) finding_data_id,
+        finding_state,
+        state_changed_at
+    FROM location_states
+    ORDER BY finding_data_id,
+        finding_state = 'Resolved',
+        state_changed_at DESC
+),
+latest AS (
+    SELECT DISTINCT ON (finding_data_id) finding_data_id,
+        scan_id,
+        scan_date
+    FROM asset_finding_scan
+    WHERE asset_id = $1
+        AND root_account_id = $2
+    ORDER BY finding_data_id,
+        scan_date DESC
+)
+SELECT fd.finding_data_id,
+    fd.finding_type,
+    fd.rule_id,
+    fd.title,
+    fd.severity,
+    asset_states.finding_state,
+    asset_states.state_changed_at,
+    latest.scan_id,
+    latest.scan_date,
+    afs.file_path,
+    afs.start_line,
+    afs.end_line,
+    afs.license_name,
+    afs.package_name,
+    afs.target,
+    afs.message
+FROM asset_states
+    JOIN finding_data fd ON fd.finding_data_id = asset_states.finding_data_id
+    JOIN latest ON latest.finding_data_id = asset_states.finding_data_id
+    JOIN asset_finding_scan afs ON afs.asset_id = $1
+    AND afs.scan_id = latest.scan_id
+    AND afs.finding_data_id = latest.finding_data_id
+ORDER BY CASE
+        WHEN fd.severity = 'Critical' THEN 4
+        WHEN fd.severity = 'High' THEN 3
+        WHEN fd.severity = 'Medium' THEN 2
+        WHEN fd.severity = 'Low' THEN 1
+        WHEN fd.severity = 'Unknown' THEN 0
+        ELSE -1
+    END DESC,
+    fd.finding_type,
+    fd.rule_id,
+    afs.file_path,
+    afs.start_line;
@@ -0,0 +1,41 @@
+-- name: GetAllAssetLiveness :many
+SELECT asset_id,
+  status,
+  last_seen_at,
+  status_changed_at
+FROM asset_liveness;
+
+-- name: SetAssetStatus :exec
+WITH history AS (
+  INSERT INTO asset_status_history (asset_id, status, reason, changed_at)
+  VALUES ($1, $2, $3, $4)
+)
+INSERT INTO asset_liveness (
+    asset_id,
+    status,
+    last_seen_at,
+    status_changed_at
+  )
+VALUES ($1, $2, $5, $4) ON CONFLICT (asset_id) DO
+UPDATE
+SET status = EXCLUDED.status,
+  status_changed_at = EXCLUDED.status_changed_at,
+  last_seen_at = COALESCE(EXCLUDED.last_seen_at, asset_liveness.last_seen_at);
+
+-- name: UpdateAssetLastSeen :exec
+UPDATE asset_liveness
+SET last_seen_at = $2
+WHERE asset_id = $1
+  AND (
+    last_seen_at IS NULL
+    OR last_seen_at < $2
+  );
+
+-- name: GetAssetStatusHistory :many
+SELECT status,
+  reason,
+  changed_at
+FROM asset_status_history
+WHERE asset_id = $1
+ORDER BY changed_at DESC
+LIMIT $2;
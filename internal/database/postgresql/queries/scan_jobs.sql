@@ -0,0 +1,167 @@
+-- name: CreateScanJob :one
+INSERT INTO scan_jobs (
+    root_account_id,
+    requested_by,
+    requested_by_type,
+    scanner_name,
+    flags,
+    target
+  )
+VALUES ($1, $2, $3, $4, $5, $6)
+RETURNING job_id,
+  root_account_id,
+  requested_by,
+  requested_by_type,
+  scanner_name,
+  flags,
+  target,
+  status,
+  error,
+  scan_id,
+  created_at,
+  started_at,
+  finished_at;
+
+-- name: AddScanJobAssets :exec
+INSERT INTO scan_job_assets (job_id, asset_id)
+SELECT @job_id::uuid,
+  unnest(@asset_ids::uuid []);
+
+-- name: GetScanJob :one
+SELECT job_id,
+  root_account_id,
+  requested_by,
+  requested_by_type,
+  scanner_name,
+  flags,
+  target,
+  status,
+  error,
+  scan_id,
+  created_at,
+  started_at,
+  finished_at
+FROM scan_jobs
+WHERE job_id = $1;
+
+-- name: GetScanJobForRootAccount :one
+SELECT job_id,
+  root_account_id,
+  requested_by,
+  requested_by_type,
+  scanner_name,
+  flags,
+  target,
+  status,
+  error,
+  scan_id,
+  created_at,
+  started_at,
+  finished_at
+FROM scan_jobs
+WHERE job_id = $1
+  AND root_account_id = $2;
+
+-- name: ListScanJobs :many
+SELECT j.job_id,
+  j.scanner_name,
+  j.status,
+  j.error,
+  j.scan_id,
+  j.created_at,
+  j.started_at,
+  j.finished_at,
+  COUNT(ja.asset_id)::int AS total_assets,
+  COUNT(ja.asset_id) FILTER (
+    WHERE ja.status = 'succeeded'
+  )::int AS succeeded_assets,
+  COUNT(ja.asset_id) FILTER (
+    WHERE ja.status = 'failed'
+  )::int AS failed_assets
+FROM scan_jobs j
+  LEFT JOIN scan_job_assets ja ON ja.job_id = j.job_id
+WHERE j.root_account_id = $1
+GROUP BY j.job_id
+ORDER BY j.created_at DESC;
+
+-- name: GetScanJobAssets :many
+SELECT ja.job_id,
+  ja.asset_id,
+  ja.status,
+  ja.error,
+  ja.vulnerability_count,
+  ja.started_at,
+  ja.finished_at,
+  s.hostname,
+  s.os,
+  a.ip_address,
+  a.agent_hostname,
+  a.agent_port,
+  a.agent_endpoint_override
+FROM scan_job_assets ja
+  JOIN assets a ON a.asset_id = ja.asset_id
+  JOIN system_information s ON s.id = a.sysinfo_id
+WHERE ja.job_id = $1
+ORDER BY s.hostname;
+
+-- name: GetScanTargetAssets :many
+SELECT a.asset_id
+FROM assets a
+WHERE a.root_account_id = @root_account_id
+  AND (
+    a.asset_id = ANY(@asset_ids::uuid [])
+    OR a.asset_id IN (
+      SELECT ea.asset_id
+      FROM environment_assets ea
+      WHERE ea.environment_id = ANY(@environment_ids::uuid [])
+    )
+    OR a.asset_id IN (
+      SELECT t.asset_id
+      FROM asset_tags t
+      WHERE t.tag = ANY(@tags::text [])
+    )
+    OR a.sysinfo_id IN (
+      SELECT s.id
+      FROM system_information s
+      WHERE s.hostname = ANY(@hostnames::text [])
+    )
+  )
+ORDER BY a.asset_id;
+
+-- name: GetUnfinishedScanJobs :many
+SELECT job_id
+FROM scan_jobs
+WHERE status IN ('queued', 'running')
+ORDER BY created_at;
+
+-- name: StartScanJob :exec
+UPDATE scan_jobs
+SET status = 'running',
+  scan_id = $2,
+  started_at = COALESCE(started_at, NOW())
+WHERE job_id = $1;
+
+-- name: FinishScanJob :exec
+UPDATE scan_jobs
+SET status = $2,
+  error = $3,
+  scan_id = $4,
+  finished_at = NOW()
+WHERE job_id = $1;
+
+-- name: StartScanJobAsset :exec
+UPDATE scan_job_assets
+SET status = 'running',
+  error = NULL,
+  started_at = NOW()
+WHERE job_id = $1
+  AND asset_id = $2;
+
+-- name: FinishScanJobAsset :exec
+UPDATE scan_job_assets
+SET status = $3,
+  error = $4,
+  vulnerability_count = $5,
+  finished_at = NOW()
+WHERE job_id = $1
+  AND asset_id = $2;
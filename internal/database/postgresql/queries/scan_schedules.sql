@@ -0,0 +1,173 @@
+-- name: CreateScanSchedule :one
+INSERT INTO scan_schedules (
+    root_account_id,
+    name,
+    cron_expression,
+    timezone,
+    scanner_name,
+    flags,
+    target_asset_ids,
+    target_environment_ids,
+    target_tags,
+    enabled,
+    created_by
+  )
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)
+RETURNING schedule_id,
+  root_account_id,
+  name,
+  cron_expression,
+  timezone,
+  scanner_name,
+  flags,
+  target_asset_ids,
+  target_environment_ids,
+  target_tags,
+  enabled,
+  created_by,
+  created_at,
+  updated_at,
+  next_run_at,
+  last_run_at,
+  last_job_id,
+  last_error;
+
+-- name: DeleteScanSchedule :execrows
+DELETE FROM scan_schedules
+WHERE schedule_id = $1
+  AND root_account_id = $2;
+
+-- name: GetEnabledScanSchedules :many
+SELECT schedule_id,
+  root_account_id,
+  name,
+  cron_expression,
+  timezone,
+  scanner_name,
+  flags,
+  target_asset_ids,
+  target_environment_ids,
+  target_tags,
+  enabled,
+  created_by,
+  created_at,
+  updated_at,
+  next_run_at,
+  last_run_at,
+  last_job_id,
+  last_error
+FROM scan_schedules
+WHERE enabled;
+
+-- name: GetScanSchedule :one
+SELECT schedule_id,
+  root_account_id,
+  name,
+  cron_expression,
+  timezone,
+  scanner_name,
+  flags,
+  target_asset_ids,
+  target_environment_ids,
+  target_tags,
+  enabled,
+  created_by,
+  created_at,
+  updated_at,
+  next_run_at,
+  last_run_at,
+  last_job_id,
+  last_error
+FROM scan_schedules
+WHERE schedule_id = $1;
+
+-- name: GetScanScheduleForRootAccount :one
+SELECT schedule_id,
+  root_account_id,
+  name,
+  cron_expression,
+  timezone,
+  scanner_name,
+  flags,
+  target_asset_ids,
+  target_environment_ids,
+  target_tags,
+  enabled,
+  created_by,
+  created_at,
+  updated_at,
+  next_run_at,
+  last_run_at,
+  last_job_id,
+  last_error
+FROM scan_schedules
+WHERE schedule_id = $1
+  AND root_account_id = $2;
+
+-- name: ListScanSchedules :many
+SELECT schedule_id,
+  root_account_id,
+  name,
+  cron_expression,
+  timezone,
+  scanner_name,
+  flags,
+  target_asset_ids,
+  target_environment_ids,
+  target_tags,
+  enabled,
+  created_by,
+  created_at,
+  updated_at,
+  next_run_at,
+  last_run_at,
+  last_job_id,
+  last_error
+FROM scan_schedules
+WHERE root_account_id = $1
+ORDER BY created_at;
+
+-- name: RecordScanScheduleRun :exec
+UPDATE scan_schedules
+SET last_run_at = NOW(),
+  last_job_id = $2,
+  last_error = $3
+WHERE schedule_id = $1;
+
+-- name: SetScanScheduleNextRun :exec
+UPDATE scan_schedules
+SET next_run_at = $2
+WHERE schedule_id = $1;
+
+-- name: UpdateScanSchedule :one
+UPDATE scan_schedules
+SET name = $3,
+  cron_expression = $4,
+  timezone = $5,
+  scanner_name = $6,
+  flags = $7,
+  target_asset_ids = $8,
+  target_environment_ids = $9,
+  target_tags = $10,
+  enabled = $11,
+  updated_at = NOW()
+WHERE schedule_id = $1
+  AND root_account_id = $2
+RETURNING schedule_id,
+  root_account_id,
+  name,
+  cron_expression,
+  timezone,
+  scanner_name,
+  flags,
+  target_asset_ids,
+  target_environment_ids,
+  target_tags,
+  enabled,
+  created_by,
+  created_at,
+  updated_at,
+  next_run_at,
+  last_run_at,
+  last_job_id,
+  last_error;
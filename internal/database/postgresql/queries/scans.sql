@@ -1,14 +1,58 @@
 -- name: CreateScanEntryRoot :one
-INSERT INTO scans (scanner_name, root_account_id)
-VALUES ($1, $2)
+INSERT INTO scans (
+        scanner_name,
+        root_account_id,
+        target,
+        asset_ids,
+        scan_path
+    )
+VALUES ($1, $2, $3, $4, $5)
 RETURNING scan_id;
 
 -- name: RemoveScanEntry :exec
 DELETE FROM scans
 WHERE scan_id = $1;
 
+-- name: RemoveScanResults :exec
+-- Removes what a scan recorded for its assets, so the scan itself can be
+-- removed.
+WITH removed_vulnerabilities AS (
+    DELETE FROM asset_vulnerability_scan
+    WHERE scan_id = $1
+),
+removed_packages AS (
+    DELETE FROM asset_vulnerability_packages
+    WHERE scan_id = $1
+)
+DELETE FROM asset_finding_scan
+WHERE scan_id = $1;
+
+-- name: RemoveScanAssetResults :exec
+-- Removes what a scan recorded for one asset, so that it can be recorded
+-- again.
+WITH removed_vulnerabilities AS (
+    DELETE FROM asset_vulnerability_scan
+    WHERE scan_id = @scan_id
+        AND asset_id = @asset_id
+),
+removed_packages AS (
+    DELETE FROM asset_vulnerability_packages
+    WHERE scan_id = @scan_id
+        AND asset_id = @asset_id
+)
+DELETE FROM asset_finding_scan
+WHERE scan_id = @scan_id
+    AND asset_id = @asset_id;
+
 -- name: CreateScanEntryIAMUser :one
-INSERT INTO scans (scanner_name, root_account_id, scanned_by_user)
+INSERT INTO scans (
+        scanner_name,
+        root_account_id,
+        scanned_by_user,
+        target,
+        asset_ids,
+        scan_path
+    )
 VALUES (
         $1,
         (
@@ -16,7 +60,10 @@ VALUES (
             FROM iam_accounts
             WHERE account_id = $2
         ),
-        $2
+        $2,
+        $3,
+        $4,
+        $5
     )
 RETURNING scan_id;
 
@@ -35,13 +82,63 @@ FROM unnest(@vuln_list::text []) AS id
     JOIN vulnerability_data vd ON vd.vulnerability_id = id
     JOIN scans s ON s.scan_id = $1;
 
+-- name: BatchInsertVulnerabilityPackages :exec
+INSERT INTO asset_vulnerability_packages (
+        root_account_id,
+        scan_id,
+        asset_id,
+        vulnerability_id,
+        package_name,
+        installed_version,
+        fixed_version,
+        package_path,
+        target,
+        package_type
+    )
+SELECT s.root_account_id,
+    @scan_id AS scan_id,
+    @asset_id AS asset_id,
+    vd.vulnerability_data_id AS vulnerability_id,
+    pkg->>'PkgName',
+    COALESCE(pkg->>'InstalledVersion', ''),
+    COALESCE(pkg->>'FixedVersion', ''),
+    COALESCE(pkg->>'PkgPath', ''),
+    COALESCE(pkg->>'Target', ''),
+    COALESCE(pkg->>'PkgType', '')
+FROM jsonb_array_elements(@packages::jsonb) AS pkg
+    JOIN vulnerability_data vd ON vd.vulnerability_id = pkg->>'VulnerabilityID'
+    JOIN scans s ON s.scan_id = @scan_id;
+
+-- name: GetScanPackages :many
+SELECT avp.asset_id,
+    si.hostname,
+    vd.vulnerability_id,
+    vd.vulnerability_severity,
+    avp.package_name,
+    avp.installed_version,
+    avp.fixed_version,
+    avp.package_path,
+    avp.target,
+    avp.package_type
+FROM asset_vulnerability_packages avp
+    JOIN vulnerability_data vd ON vd.vulnerability_data_id = avp.vulnerability_id
+    JOIN assets a ON a.asset_id = avp.asset_id
+    JOIN system_information si ON si.id = a.sysinfo_id
+WHERE avp.scan_id = $1
+    AND avp.root_account_id = $2
+ORDER BY si.hostname,
+    vd.vulnerability_id,
+    avp.package_name;
+
 -- name: RetrieveScans :many
 SELECT s.scan_id,
     ra.username AS root_account_username,
     s.root_account_id,
     s.scanner_name,
     s.scan_date,
-    s.notes
+    s.notes,
+    s.target,
+    s.asset_ids
 FROM scans s
     JOIN root_accounts ra ON s.root_account_id = ra.account_id
 WHERE root_account_id = $1
@@ -50,4 +147,69 @@ ORDER BY s.scan_date DESC;
 -- name: UpdateScanNotes :exec
 UPDATE scans
 SET notes = $1
-WHERE scan_id = $2;
\ No newline at end of file
+WHERE scan_id = $2;
+
+-- name: GetPreviousScansForAssets :many
+-- Only scans of the same path are compared, since a scan of another path
+-- covers other files on the asset.
+SELECT DISTINCT ON (covered.asset_id) covered.asset_id,
+    s.scan_id
+FROM (
+        SELECT avs.asset_id,
+            avs.scan_id
+        FROM asset_vulnerability_scan avs
+        WHERE avs.asset_id = ANY(@asset_ids::uuid [])
+        UNION
+        SELECT ja.asset_id,
+            j.scan_id
+        FROM scan_job_assets ja
+            JOIN scan_jobs j ON j.job_id = ja.job_id
+        WHERE ja.asset_id = ANY(@asset_ids::uuid [])
+            AND ja.status = 'succeeded'
+            AND j.scan_id IS NOT NULL
+    ) covered
+    JOIN scans s ON s.scan_id = covered.scan_id
+WHERE s.root_account_id = @root_account_id
+    AND s.scanner_name = @scanner_name
+    AND s.scan_path = @scan_path
+    AND s.scan_date < @scan_date
+ORDER BY covered.asset_id,
+    s.scan_date DESC;
+
+-- name: GetScanAssets :many
+SELECT a.asset_id,
+    si.hostname
+FROM assets a
+    JOIN system_information si ON si.id = a.sysinfo_id
+WHERE a.asset_id IN (
+        SELECT avs.asset_id
+        FROM asset_vulnerability_scan avs
+        WHERE avs.scan_id = $1
+        UNION
+        SELECT ja.asset_id
+        FROM scan_job_assets ja
+            JOIN scan_jobs j ON j.job_id = ja.job_id
+        WHERE j.scan_id = $1
+            AND ja.status = 'succeeded'
+    )
+ORDER BY si.hostname;
+
+-- name: GetScanFindings :many
+SELECT avs.scan_id,
+    avs.asset_id,
+    vd.vulnerability_id,
+    vd.vulnerability_severity
+FROM asset_vulnerability_scan avs
+    JOIN vulnerability_data vd ON vd.vulnerability_data_id = avs.vulnerability_id
+WHERE avs.root_account_id = @root_account_id
+    AND avs.scan_id = ANY(@scan_ids::uuid [])
+ORDER BY vd.vulnerability_id;
+
+-- name: GetScanForRootAccount :one
+SELECT scan_id,
+    scanner_name,
+    scan_path,
+    scan_date
+FROM scans
+WHERE scan_id = $1
+    AND root_account_id = $2;
@@ -144,6 +144,9 @@ ORDER BY
 SELECT 
   asset_id,
   ip_address,
-  root_account_id
+  root_account_id,
+  agent_hostname,
+  agent_port,
+  agent_endpoint_override
 FROM assets
 ORDER BY root_account_id, asset_id;
\ No newline at end of file
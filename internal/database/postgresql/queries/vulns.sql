@@ -9,7 +9,96 @@ FROM unnest(@vuln_list::text []) WITH ORDINALITY AS vuln(elem, ord)
     JOIN vulnerability_data vd ON vd.vulnerability_id = vuln.elem
 WHERE vd.last_modified >= mod.elem;
 
+-- name: BatchUpdateAssetVulnerabilityState :exec
+-- A vulnerability missing from the scan is only resolved if the scan it was
+-- last reported by used the same scanner on the same path, so one scanner
+-- does not resolve what only another one finds, nor a scan of one directory
+-- what was found in another.
+WITH scan AS (
+    SELECT root_account_id,
+        scanner_name,
+        scan_path
+    FROM scans
+    WHERE scan_id = $1
+),
+seen AS (
+    SELECT DISTINCT avs.asset_id,
+        avs.vulnerability_id AS vuln_data_id
+    FROM asset_vulnerability_scan avs
+    WHERE avs.scan_id = $1
+        AND avs.asset_id = ANY(@asset_ids::uuid [])
+),
+latest_state_history AS (
+    SELECT DISTINCT ON (asset_id, vuln_data_id) asset_id,
+        vuln_data_id,
+        vulnerability_state
+    FROM asset_vulnerability_state_history
+    WHERE root_account_id = (
+            SELECT root_account_id
+            FROM scan
+        )
+        AND asset_id = ANY(@asset_ids::uuid [])
+    ORDER BY asset_id,
+        vuln_data_id,
+        state_changed_at DESC
+),
+last_reported AS (
+    SELECT DISTINCT ON (avs.asset_id, avs.vulnerability_id) avs.asset_id,
+        avs.vulnerability_id AS vuln_data_id,
+        s.scanner_name,
+        s.scan_path
+    FROM asset_vulnerability_scan avs
+        JOIN scans s ON s.scan_id = avs.scan_id
+    WHERE avs.root_account_id = (
+            SELECT root_account_id
+            FROM scan
+        )
+        AND avs.asset_id = ANY(@asset_ids::uuid [])
+    ORDER BY avs.asset_id,
+        avs.vulnerability_id,
+        avs.scan_date DESC
+)
+INSERT INTO asset_vulnerability_state_history (
+        asset_id,
+        vuln_data_id,
+        vulnerability_state,
+        root_account_id
+    )
+SELECT COALESCE(seen.asset_id, lsh.asset_id),
+    COALESCE(seen.vuln_data_id, lsh.vuln_data_id),
+    CASE
+        WHEN lsh.vuln_data_id IS NULL THEN 'New'::vulnstate
+        WHEN seen.vuln_data_id IS NULL THEN 'Resolved'::vulnstate
+        WHEN lsh.vulnerability_state = 'New' THEN 'Active'::vulnstate
+        ELSE 'Resurfaced'::vulnstate
+    END,
+    (
+        SELECT root_account_id
+        FROM scan
+    )
+FROM seen
+    FULL OUTER JOIN latest_state_history lsh ON lsh.asset_id = seen.asset_id
+    AND lsh.vuln_data_id = seen.vuln_data_id
+    LEFT JOIN last_reported lr ON lr.asset_id = lsh.asset_id
+    AND lr.vuln_data_id = lsh.vuln_data_id
+WHERE lsh.vuln_data_id IS NULL
+    OR (
+        seen.vuln_data_id IS NULL
+        AND lsh.vulnerability_state != 'Resolved'
+        AND (lr.scanner_name, lr.scan_path) = (
+            SELECT scanner_name,
+                scan_path
+            FROM scan
+        )
+    )
+    OR (
+        seen.vuln_data_id IS NOT NULL
+        AND lsh.vulnerability_state IN ('New', 'Resolved')
+    );
+
 -- name: BatchUpdateVulnerabilityState :exec
+-- Account-level states are derived from the per-asset states of the
+-- vulnerabilities found on, or resolved from, the given assets.
 WITH root_account AS (
     SELECT COALESCE(
             (
@@ -20,10 +109,26 @@ WITH root_account AS (
             ), $1
         ) AS id
 ),
-vuln_list_data AS (
-    SELECT vd.vulnerability_data_id
-    FROM unnest(@vuln_list::text []) AS vuln_id
-        JOIN vulnerability_data vd ON vd.vulnerability_id = vuln_id
+asset_states AS (
+    SELECT DISTINCT ON (asset_id, vuln_data_id) asset_id,
+        vuln_data_id,
+        vulnerability_state
+    FROM asset_vulnerability_state_history
+    WHERE root_account_id = (
+            SELECT id
+            FROM root_account
+        )
+    ORDER BY asset_id,
+        vuln_data_id,
+        state_changed_at DESC
+),
+derived_state AS (
+    SELECT vuln_data_id,
+        bool_or(vulnerability_state != 'Resolved') AS open,
+        bool_or(vulnerability_state IN ('Active', 'Resurfaced')) AS seen_again
+    FROM asset_states
+    GROUP BY vuln_data_id
+    HAVING bool_or(asset_id = ANY(@asset_ids::uuid []))
 ),
 latest_state_history AS (
     SELECT DISTINCT ON (vuln_data_id) vuln_data_id,
@@ -36,64 +141,50 @@ latest_state_history AS (
     ORDER BY vuln_data_id,
         state_changed_at DESC
 ),
-insert_active_and_resurfaced AS (
-    INSERT INTO vulnerability_state_history (
-            vuln_data_id,
-            vulnerability_state,
-            root_account_id
-        )
-    SELECT vl.vulnerability_data_id,
+next_state AS (
+    SELECT ds.vuln_data_id,
+        lsh.vulnerability_state AS previous_state,
         CASE
-            WHEN lsh.vulnerability_state = 'New' THEN 'Active'::vulnstate
+            WHEN NOT ds.open THEN 'Resolved'::vulnstate
+            WHEN lsh.vulnerability_state IS NULL THEN 'New'::vulnstate
             WHEN lsh.vulnerability_state = 'Resolved' THEN 'Resurfaced'::vulnstate
-        END,
-        (
-            SELECT id
-            FROM root_account
-        )
-    FROM vuln_list_data vl
-        JOIN latest_state_history lsh ON lsh.vuln_data_id = vl.vulnerability_data_id
-    WHERE lsh.vulnerability_state = 'New'
-        OR lsh.vulnerability_state = 'Resolved'
-),
-insert_new_and_resolved AS (
-    INSERT INTO vulnerability_state_history (
-            vuln_data_id,
-            vulnerability_state,
-            root_account_id
-        )
-    SELECT COALESCE(
-            vl.vulnerability_data_id,
-            lsh.vuln_data_id
-        ) AS vulnerability_data_id,
-        CASE
-            WHEN lsh.vuln_data_id IS NULL THEN 'New'::vulnstate
-            WHEN vl.vulnerability_data_id IS NULL THEN 'Resolved'::vulnstate
-        END AS state_change,
-        (
-            SELECT id
-            FROM root_account
-        )
-    FROM vuln_list_data vl
-        FULL OUTER JOIN latest_state_history lsh ON lsh.vuln_data_id = vl.vulnerability_data_id
-    WHERE (
-            lsh.vulnerability_state IS NULL
-            OR (
-                vl.vulnerability_data_id IS NULL
-                AND lsh.vulnerability_state != 'Resolved'
-            )
-        )
+            WHEN lsh.vulnerability_state = 'New'
+            AND ds.seen_again THEN 'Active'::vulnstate
+            ELSE lsh.vulnerability_state
+        END AS vulnerability_state
+    FROM derived_state ds
+        LEFT JOIN latest_state_history lsh ON lsh.vuln_data_id = ds.vuln_data_id
 )
-SELECT 1;
+INSERT INTO vulnerability_state_history (
+        vuln_data_id,
+        vulnerability_state,
+        root_account_id
+    )
+SELECT vuln_data_id,
+    vulnerability_state,
+    (
+        SELECT id
+        FROM root_account
+    )
+FROM next_state
+WHERE previous_state IS DISTINCT FROM vulnerability_state;
 
 -- name: BatchUpdateVulnerabilityData :exec
+-- Scanners that do not report dates, such as Grype, send the zero time;
+-- those dates are left as they are rather than overwritten with it.
 UPDATE vulnerability_data
 SET vulnerability_name = vuln->>'Name',
     vulnerability_description = vuln->>'Description',
     vulnerability_severity = vuln->>'Severity',
     cvss_score = (vuln->>'CVSSScore')::float,
-    created_on = (vuln->>'CreatedOn')::timestamptz,
-    last_modified = (vuln->>'LastModified')::timestamptz,
+    created_on = COALESCE(
+        NULLIF(vuln->>'CreatedOn', '0001-01-01T00:00:00Z')::timestamptz,
+        vulnerability_data.created_on
+    ),
+    last_modified = COALESCE(
+        NULLIF(vuln->>'LastModified', '0001-01-01T00:00:00Z')::timestamptz,
+        vulnerability_data.last_modified
+    ),
     reference = CASE
         WHEN jsonb_typeof(vuln->'References') = 'array' THEN ARRAY(
             SELECT jsonb_array_elements_text(vuln->'References')
@@ -136,6 +227,53 @@ SELECT *
 FROM vulnerability_data
     JOIN latest_state_history lsh ON lsh.vuln_data_id = vulnerability_data.vulnerability_data_id;
 
+-- name: GetAssetVulnerabilityStates :many
+SELECT DISTINCT ON (si.hostname, avsh.asset_id) avsh.asset_id,
+    si.hostname,
+    avsh.vulnerability_state,
+    avsh.state_changed_at
+FROM asset_vulnerability_state_history avsh
+    JOIN vulnerability_data vd ON vd.vulnerability_data_id = avsh.vuln_data_id
+    JOIN assets a ON a.asset_id = avsh.asset_id
+    JOIN system_information si ON si.id = a.sysinfo_id
+WHERE vd.vulnerability_id = $1
+    AND avsh.root_account_id = $2
+ORDER BY si.hostname,
+    avsh.asset_id,
+    avsh.state_changed_at DESC;
+
+-- name: GetVulnerabilityPackages :many
+WITH latest AS (
+    SELECT DISTINCT ON (avs.asset_id) avs.asset_id,
+        avs.scan_id,
+        avs.vulnerability_id,
+        avs.scan_date
+    FROM asset_vulnerability_scan avs
+        JOIN vulnerability_data vd ON vd.vulnerability_data_id = avs.vulnerability_id
+    WHERE vd.vulnerability_id = $1
+        AND avs.root_account_id = $2
+    ORDER BY avs.asset_id,
+        avs.scan_date DESC
+)
+SELECT latest.asset_id,
+    si.hostname,
+    latest.scan_id,
+    latest.scan_date,
+    avp.package_name,
+    avp.installed_version,
+    avp.fixed_version,
+    avp.package_path,
+    avp.target,
+    avp.package_type
+FROM latest
+    JOIN asset_vulnerability_packages avp ON avp.asset_id = latest.asset_id
+    AND avp.scan_id = latest.scan_id
+    AND avp.vulnerability_id = latest.vulnerability_id
+    JOIN assets a ON a.asset_id = latest.asset_id
+    JOIN system_information si ON si.id = a.sysinfo_id
+ORDER BY si.hostname,
+    avp.package_name;
+
 -- name: GetVulnerabilitiesStateHistory :many
 SELECT *
 FROM vulnerability_state_history;
@@ -62,10 +62,85 @@ CREATE TABLE IF NOT EXISTS assets (
   sysinfo_id UUID NOT NULL,
   root_account_id UUID NOT NULL,
   registered_at TIMESTAMPTZ DEFAULT NOW(),
+  cert_fingerprint TEXT,
+  cert_serial TEXT,
+  cert_not_after TIMESTAMPTZ,
+  cert_revoked_at TIMESTAMPTZ,
+  agent_hostname VARCHAR(255),
+  agent_port INTEGER NOT NULL DEFAULT 50051,
+  agent_endpoint_override VARCHAR(255),
   FOREIGN KEY (sysinfo_id) REFERENCES system_information (id),
   FOREIGN KEY (root_account_id) REFERENCES root_accounts (account_id)
 );
 
+-- Columns added after assets was first created are added to existing
+-- databases here as well.
+ALTER TABLE assets
+ADD COLUMN IF NOT EXISTS cert_fingerprint TEXT,
+  ADD COLUMN IF NOT EXISTS cert_serial TEXT,
+  ADD COLUMN IF NOT EXISTS cert_not_after TIMESTAMPTZ,
+  ADD COLUMN IF NOT EXISTS cert_revoked_at TIMESTAMPTZ,
+  ADD COLUMN IF NOT EXISTS agent_hostname VARCHAR(255),
+  ADD COLUMN IF NOT EXISTS agent_port INTEGER NOT NULL DEFAULT 50051,
+  ADD COLUMN IF NOT EXISTS agent_endpoint_override VARCHAR(255);
+
+CREATE TABLE IF NOT EXISTS asset_liveness (
+  asset_id UUID PRIMARY KEY,
+  status VARCHAR(16) NOT NULL,
+  last_seen_at TIMESTAMPTZ,
+  status_changed_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+  FOREIGN KEY (asset_id) REFERENCES assets (asset_id)
+);
+
+CREATE TABLE IF NOT EXISTS asset_capabilities (
+  asset_id UUID PRIMARY KEY,
+  agent_version VARCHAR(64) NOT NULL,
+  os VARCHAR(32) NOT NULL,
+  arch VARCHAR(32) NOT NULL,
+  operations TEXT [] NOT NULL,
+  tools JSONB NOT NULL,
+  reported_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+  FOREIGN KEY (asset_id) REFERENCES assets (asset_id)
+);
+
+CREATE TABLE IF NOT EXISTS asset_files (
+  file_id UUID PRIMARY KEY,
+  asset_id UUID NOT NULL,
+  root_account_id UUID NOT NULL,
+  remote_path TEXT NOT NULL,
+  name TEXT NOT NULL,
+  size_bytes BIGINT NOT NULL,
+  sha256 CHAR(64) NOT NULL,
+  storage_path TEXT NOT NULL,
+  retrieved_by UUID NOT NULL,
+  retrieved_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+  FOREIGN KEY (asset_id) REFERENCES assets (asset_id),
+  FOREIGN KEY (root_account_id) REFERENCES root_accounts (account_id)
+);
+
+CREATE TABLE IF NOT EXISTS asset_tags (
+  asset_id UUID NOT NULL,
+  tag VARCHAR(64) NOT NULL,
+  PRIMARY KEY (asset_id, tag),
+  FOREIGN KEY (asset_id) REFERENCES assets (asset_id)
+);
+
+CREATE TABLE IF NOT EXISTS asset_status_history (
+  asset_id UUID NOT NULL,
+  status VARCHAR(16) NOT NULL,
+  reason TEXT,
+  changed_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+  PRIMARY KEY (asset_id, changed_at),
+  FOREIGN KEY (asset_id) REFERENCES assets (asset_id)
+);
+
+SELECT create_hypertable(
+    'asset_status_history',
+    by_range('changed_at'),
+    if_not_exists => TRUE,
+    migrate_data => TRUE
+  );
+
 CREATE TABLE IF NOT EXISTS actions (
   action_id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
   action_name TEXT NOT NULL,
@@ -105,6 +180,7 @@ CREATE TABLE IF NOT EXISTS permission_templates (
 INSERT INTO permission_templates (component_name, capabilities)
 VALUES ('Overview', ARRAY ['View']),
   ('Assets', ARRAY ['View', 'Create', 'Manage']),
+  ('AssetFiles', ARRAY ['View', 'Create']),
   ('Vulnerabilities', ARRAY ['View', 'Manage']),
   (
     'Environments',
@@ -211,10 +287,20 @@ CREATE TABLE IF NOT EXISTS scans (
   scanner_name VARCHAR(255) NOT NULL,
   scan_date TIMESTAMPTZ NOT NULL DEFAULT NOW(),
   notes TEXT,
+  target JSONB NOT NULL DEFAULT '{}',
+  asset_ids UUID [] NOT NULL DEFAULT '{}',
+  scan_path TEXT NOT NULL DEFAULT '',
   PRIMARY KEY (scan_id),
   FOREIGN KEY (root_account_id) REFERENCES root_accounts (account_id)
 );
 
+-- Columns added after scans was first created are added to existing
+-- databases here as well.
+ALTER TABLE scans
+ADD COLUMN IF NOT EXISTS target JSONB NOT NULL DEFAULT '{}',
+  ADD COLUMN IF NOT EXISTS asset_ids UUID [] NOT NULL DEFAULT '{}',
+  ADD COLUMN IF NOT EXISTS scan_path TEXT NOT NULL DEFAULT '';
+
 
 CREATE TABLE IF NOT EXISTS asset_vulnerability_scan (
   scan_result_id UUID DEFAULT uuid_generate_v4(),
@@ -238,6 +324,199 @@ SELECT create_hypertable(
     migrate_data => TRUE
   );
 
+CREATE TABLE IF NOT EXISTS asset_vulnerability_packages (
+  package_result_id UUID DEFAULT uuid_generate_v4(),
+  root_account_id UUID NOT NULL,
+  scan_id UUID NOT NULL,
+  asset_id UUID NOT NULL,
+  vulnerability_id UUID NOT NULL,
+  package_name TEXT NOT NULL,
+  installed_version TEXT NOT NULL DEFAULT '',
+  fixed_version TEXT NOT NULL DEFAULT '',
+  package_path TEXT NOT NULL DEFAULT '',
+  target TEXT NOT NULL DEFAULT '',
+  package_type TEXT NOT NULL DEFAULT '',
+  scan_date TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+  PRIMARY KEY (package_result_id, scan_date),
+  FOREIGN KEY (root_account_id) REFERENCES root_accounts (account_id),
+  FOREIGN KEY (scan_id) REFERENCES scans (scan_id),
+  FOREIGN KEY (asset_id) REFERENCES assets (asset_id),
+  FOREIGN KEY (vulnerability_id) REFERENCES vulnerability_data (vulnerability_data_id)
+);
+
+-- Convert to hypertable
+SELECT create_hypertable(
+    'asset_vulnerability_packages',
+    by_range('scan_date'),
+    if_not_exists => TRUE,
+    migrate_data => TRUE
+  );
+
+CREATE TABLE IF NOT EXISTS asset_vulnerability_state_history (
+  history_id UUID DEFAULT uuid_generate_v4(),
+  asset_id UUID NOT NULL,
+  vuln_data_id UUID NOT NULL,
+  vulnerability_state VULNSTATE NOT NULL,
+  state_changed_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+  root_account_id UUID NOT NULL,
+  PRIMARY KEY (history_id, state_changed_at),
+  FOREIGN KEY (asset_id) REFERENCES assets (asset_id),
+  FOREIGN KEY (vuln_data_id) REFERENCES vulnerability_data (vulnerability_data_id),
+  FOREIGN KEY (root_account_id) REFERENCES root_accounts (account_id)
+);
+
+-- Convert to hypertable
+SELECT create_hypertable(
+    'asset_vulnerability_state_history',
+    by_range('state_changed_at'),
+    if_not_exists => TRUE,
+    migrate_data => TRUE
+  );
+
+CREATE TABLE IF NOT EXISTS finding_data (
+  finding_data_id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+  finding_type VARCHAR(20) NOT NULL,
+  rule_id VARCHAR(255) NOT NULL,
+  title TEXT NOT NULL DEFAULT '',
+  description TEXT NOT NULL DEFAULT '',
+  severity VARCHAR(50) NOT NULL DEFAULT 'Unknown',
+  resolution TEXT NOT NULL DEFAULT '',
+  reference TEXT [] NOT NULL DEFAULT '{}',
+  UNIQUE (finding_type, rule_id)
+);
+
+CREATE TABLE IF NOT EXISTS asset_finding_scan (
+  finding_result_id UUID DEFAULT uuid_generate_v4(),
+  root_account_id UUID NOT NULL,
+  scan_id UUID NOT NULL,
+  asset_id UUID NOT NULL,
+  finding_data_id UUID NOT NULL,
+  file_path TEXT NOT NULL DEFAULT '',
+  start_line INTEGER NOT NULL DEFAULT 0,
+  end_line INTEGER NOT NULL DEFAULT 0,
+  license_name TEXT NOT NULL DEFAULT '',
+  package_name TEXT NOT NULL DEFAULT '',
+  target TEXT NOT NULL DEFAULT '',
+  message TEXT NOT NULL DEFAULT '',
+  location TEXT NOT NULL DEFAULT '',
+  scan_date TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+  PRIMARY KEY (finding_result_id, scan_date),
+  FOREIGN KEY (root_account_id) REFERENCES root_accounts (account_id),
+  FOREIGN KEY (scan_id) REFERENCES scans (scan_id),
+  FOREIGN KEY (asset_id) REFERENCES assets (asset_id),
+  FOREIGN KEY (finding_data_id) REFERENCES finding_data (finding_data_id)
+);
+
+-- Convert to hypertable
+SELECT create_hypertable(
+    'asset_finding_scan',
+    by_range('scan_date'),
+    if_not_exists => TRUE,
+    migrate_data => TRUE
+  );
+
+ALTER TABLE asset_finding_scan
+ADD COLUMN IF NOT EXISTS location TEXT NOT NULL DEFAULT '';
+
+CREATE TABLE IF NOT EXISTS asset_finding_state_history (
+  history_id UUID DEFAULT uuid_generate_v4(),
+  asset_id UUID NOT NULL,
+  finding_data_id UUID NOT NULL,
+  location TEXT NOT NULL DEFAULT '',
+  finding_state VULNSTATE NOT NULL,
+  state_changed_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+  root_account_id UUID NOT NULL,
+  PRIMARY KEY (history_id, state_changed_at),
+  FOREIGN KEY (asset_id) REFERENCES assets (asset_id),
+  FOREIGN KEY (finding_data_id) REFERENCES finding_data (finding_data_id),
+  FOREIGN KEY (root_account_id) REFERENCES root_accounts (account_id)
+);
+
+-- Convert to hypertable
+SELECT create_hypertable(
+    'asset_finding_state_history',
+    by_range('state_changed_at'),
+    if_not_exists => TRUE,
+    migrate_data => TRUE
+  );
+
+ALTER TABLE asset_finding_state_history
+ADD COLUMN IF NOT EXISTS location TEXT NOT NULL DEFAULT '';
+
+CREATE TABLE IF NOT EXISTS finding_state_history (
+  history_id UUID DEFAULT uuid_generate_v4(),
+  finding_data_id UUID NOT NULL,
+  finding_state VULNSTATE NOT NULL,
+  state_changed_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+  root_account_id UUID NOT NULL,
+  PRIMARY KEY (history_id, state_changed_at),
+  FOREIGN KEY (finding_data_id) REFERENCES finding_data (finding_data_id),
+  FOREIGN KEY (root_account_id) REFERENCES root_accounts (account_id)
+);
+
+-- Convert to hypertable
+SELECT create_hypertable(
+    'finding_state_history',
+    by_range('state_changed_at'),
+    if_not_exists => TRUE,
+    migrate_data => TRUE
+  );
+
+CREATE TABLE IF NOT EXISTS scan_jobs (
+  job_id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+  root_account_id UUID NOT NULL,
+  requested_by UUID NOT NULL,
+  requested_by_type VARCHAR(10) NOT NULL,
+  scanner_name VARCHAR(255) NOT NULL,
+  flags JSONB NOT NULL,
+  target JSONB NOT NULL DEFAULT '{}',
+  status VARCHAR(20) NOT NULL DEFAULT 'queued',
+  error TEXT,
+  scan_id UUID,
+  created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+  started_at TIMESTAMPTZ,
+  finished_at TIMESTAMPTZ,
+  FOREIGN KEY (root_account_id) REFERENCES root_accounts (account_id)
+);
+
+ALTER TABLE scan_jobs
+ADD COLUMN IF NOT EXISTS target JSONB NOT NULL DEFAULT '{}';
+
+CREATE TABLE IF NOT EXISTS scan_job_assets (
+  job_id UUID NOT NULL,
+  asset_id UUID NOT NULL,
+  status VARCHAR(20) NOT NULL DEFAULT 'queued',
+  error TEXT,
+  vulnerability_count INTEGER NOT NULL DEFAULT 0,
+  started_at TIMESTAMPTZ,
+  finished_at TIMESTAMPTZ,
+  PRIMARY KEY (job_id, asset_id),
+  FOREIGN KEY (job_id) REFERENCES scan_jobs (job_id) ON DELETE CASCADE,
+  FOREIGN KEY (asset_id) REFERENCES assets (asset_id)
+);
+
+CREATE TABLE IF NOT EXISTS scan_schedules (
+  schedule_id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+  root_account_id UUID NOT NULL,
+  name TEXT NOT NULL,
+  cron_expression VARCHAR(255) NOT NULL,
+  timezone VARCHAR(64) NOT NULL DEFAULT 'UTC',
+  scanner_name VARCHAR(255) NOT NULL,
+  flags JSONB NOT NULL,
+  target_asset_ids UUID [] NOT NULL DEFAULT '{}',
+  target_environment_ids UUID [] NOT NULL DEFAULT '{}',
+  target_tags TEXT [] NOT NULL DEFAULT '{}',
+  enabled BOOLEAN NOT NULL DEFAULT TRUE,
+  created_by UUID NOT NULL,
+  created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+  updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+  next_run_at TIMESTAMPTZ,
+  last_run_at TIMESTAMPTZ,
+  last_job_id UUID,
+  last_error TEXT,
+  FOREIGN KEY (root_account_id) REFERENCES root_accounts (account_id)
+);
+
 CREATE TABLE IF NOT EXISTS telemetry (
   telemetry_id UUID DEFAULT uuid_generate_v4(),
   telemetry_time TIMESTAMPTZ NOT NULL DEFAULT NOW(),
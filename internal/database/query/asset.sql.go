@@ -64,7 +64,12 @@ INSERT INTO assets (
     asset_id,
     ip_address,
     sysinfo_id,
-    root_account_id
+    root_account_id,
+    cert_fingerprint,
+    cert_serial,
+    cert_not_after,
+    agent_hostname,
+    agent_port
   )
 VALUES (
     $21,
@@ -73,7 +78,12 @@ VALUES (
       SELECT id
       FROM inserted_sysinfo
     ),
-    $23
+    $23,
+    $24,
+    $25,
+    $26,
+    $27,
+    $28
   )
 `
 
@@ -101,6 +111,11 @@ type AddAssetParams struct {
 	AssetID              pgtype.UUID
 	IpAddress            netip.Addr
 	RootAccountID        pgtype.UUID
+	CertFingerprint      pgtype.Text
+	CertSerial           pgtype.Text
+	CertNotAfter         pgtype.Timestamptz
+	AgentHostname        pgtype.Text
+	AgentPort            int32
 }
 
 func (q *Queries) AddAsset(ctx context.Context, arg AddAssetParams) error {
@@ -128,6 +143,11 @@ func (q *Queries) AddAsset(ctx context.Context, arg AddAssetParams) error {
 		arg.AssetID,
 		arg.IpAddress,
 		arg.RootAccountID,
+		arg.CertFingerprint,
+		arg.CertSerial,
+		arg.CertNotAfter,
+		arg.AgentHostname,
+		arg.AgentPort,
 	)
 	return err
 }
@@ -138,9 +158,12 @@ SELECT a.asset_id,
   s.os,
   s.platform_version,
   a.ip_address,
-  s.created_at
+  s.created_at,
+  l.status,
+  l.last_seen_at
 FROM assets a
   JOIN system_information s ON a.sysinfo_id = s.id
+  LEFT JOIN asset_liveness l ON l.asset_id = a.asset_id
 WHERE a.root_account_id = $1
 `
 
@@ -151,6 +174,8 @@ type GetAllAssetsRow struct {
 	PlatformVersion pgtype.Text
 	IpAddress       netip.Addr
 	CreatedAt       pgtype.Timestamptz
+	Status          pgtype.Text
+	LastSeenAt      pgtype.Timestamptz
 }
 
 func (q *Queries) GetAllAssets(ctx context.Context, rootAccountID pgtype.UUID) ([]GetAllAssetsRow, error) {
@@ -169,6 +194,8 @@ func (q *Queries) GetAllAssets(ctx context.Context, rootAccountID pgtype.UUID) (
 			&i.PlatformVersion,
 			&i.IpAddress,
 			&i.CreatedAt,
+			&i.Status,
+			&i.LastSeenAt,
 		); err != nil {
 			return nil, err
 		}
@@ -213,12 +240,105 @@ func (q *Queries) GetAllAssetsMin(ctx context.Context, rootAccountID pgtype.UUID
 	return items, nil
 }
 
+const getAssetCertificate = `-- name: GetAssetCertificate :one
+SELECT cert_fingerprint,
+  cert_serial,
+  cert_not_after,
+  cert_revoked_at
+FROM assets
+WHERE asset_id = $1
+`
+
+type GetAssetCertificateRow struct {
+	CertFingerprint pgtype.Text
+	CertSerial      pgtype.Text
+	CertNotAfter    pgtype.Timestamptz
+	CertRevokedAt   pgtype.Timestamptz
+}
+
+func (q *Queries) GetAssetCertificate(ctx context.Context, assetID pgtype.UUID) (GetAssetCertificateRow, error) {
+	row := q.db.QueryRow(ctx, getAssetCertificate, assetID)
+	var i GetAssetCertificateRow
+	err := row.Scan(
+		&i.CertFingerprint,
+		&i.CertSerial,
+		&i.CertNotAfter,
+		&i.CertRevokedAt,
+	)
+	return i, err
+}
+
+const getAssetEndpoint = `-- name: GetAssetEndpoint :one
+SELECT ip_address,
+  agent_hostname,
+  agent_port,
+  agent_endpoint_override
+FROM assets
+WHERE asset_id = $1
+`
+
+type GetAssetEndpointRow struct {
+	IpAddress             netip.Addr
+	AgentHostname         pgtype.Text
+	AgentPort             int32
+	AgentEndpointOverride pgtype.Text
+}
+
+func (q *Queries) GetAssetEndpoint(ctx context.Context, assetID pgtype.UUID) (GetAssetEndpointRow, error) {
+	row := q.db.QueryRow(ctx, getAssetEndpoint, assetID)
+	var i GetAssetEndpointRow
+	err := row.Scan(
+		&i.IpAddress,
+		&i.AgentHostname,
+		&i.AgentPort,
+		&i.AgentEndpointOverride,
+	)
+	return i, err
+}
+
+const getAssetEndpointForRootAccount = `-- name: GetAssetEndpointForRootAccount :one
+SELECT ip_address,
+  agent_hostname,
+  agent_port,
+  agent_endpoint_override
+FROM assets
+WHERE asset_id = $1
+  AND root_account_id = $2
+`
+
+type GetAssetEndpointForRootAccountParams struct {
+	AssetID       pgtype.UUID
+	RootAccountID pgtype.UUID
+}
+
+type GetAssetEndpointForRootAccountRow struct {
+	IpAddress             netip.Addr
+	AgentHostname         pgtype.Text
+	AgentPort             int32
+	AgentEndpointOverride pgtype.Text
+}
+
+func (q *Queries) GetAssetEndpointForRootAccount(ctx context.Context, arg GetAssetEndpointForRootAccountParams) (GetAssetEndpointForRootAccountRow, error) {
+	row := q.db.QueryRow(ctx, getAssetEndpointForRootAccount, arg.AssetID, arg.RootAccountID)
+	var i GetAssetEndpointForRootAccountRow
+	err := row.Scan(
+		&i.IpAddress,
+		&i.AgentHostname,
+		&i.AgentPort,
+		&i.AgentEndpointOverride,
+	)
+	return i, err
+}
+
 const getAssetInfoById = `-- name: GetAssetInfoById :one
 SELECT a.asset_id,
   a.ip_address,
   a.sysinfo_id,
   a.root_account_id,
   a.registered_at,
+  a.agent_hostname,
+  a.agent_port,
+  a.agent_endpoint_override,
   s.id AS system_info_id,
   s.hostname,
   s.uptime,
@@ -240,40 +360,50 @@ SELECT a.asset_id,
   s.cpu_cache_size,
   s.memory,
   s.disk,
-  s.created_at AS system_info_created_at
+  s.created_at AS system_info_created_at,
+  l.status,
+  l.last_seen_at,
+  l.status_changed_at
 FROM assets a
   JOIN system_information s ON a.sysinfo_id = s.id
+  LEFT JOIN asset_liveness l ON l.asset_id = a.asset_id
 WHERE a.asset_id = $1
 `
 
 type GetAssetInfoByIdRow struct {
-	AssetID              pgtype.UUID
-	IpAddress            netip.Addr
-	SysinfoID            pgtype.UUID
-	RootAccountID        pgtype.UUID
-	RegisteredAt         pgtype.Timestamptz
-	SystemInfoID         pgtype.UUID
-	Hostname             pgtype.Text
-	Uptime               pgtype.Int8
-	BootTime             pgtype.Int8
-	Procs                pgtype.Int8
-	Os                   pgtype.Text
-	Platform             pgtype.Text
-	PlatformFamily       pgtype.Text
-	PlatformVersion      pgtype.Text
-	KernelVersion        pgtype.Text
-	KernelArch           pgtype.Text
-	VirtualizationSystem pgtype.Text
-	VirtualizationRole   pgtype.Text
-	HostID               pgtype.Text
-	CpuVendorID          pgtype.Text
-	CpuCores             pgtype.Int4
-	CpuModelName         pgtype.Text
-	CpuMhz               pgtype.Float8
-	CpuCacheSize         pgtype.Int4
-	Memory               pgtype.Int8
-	Disk                 pgtype.Int8
-	SystemInfoCreatedAt  pgtype.Timestamptz
+	AssetID               pgtype.UUID
+	IpAddress             netip.Addr
+	SysinfoID             pgtype.UUID
+	RootAccountID         pgtype.UUID
+	RegisteredAt          pgtype.Timestamptz
+	AgentHostname         pgtype.Text
+	AgentPort             int32
+	AgentEndpointOverride pgtype.Text
+	SystemInfoID          pgtype.UUID
+	Hostname              pgtype.Text
+	Uptime                pgtype.Int8
+	BootTime              pgtype.Int8
+	Procs                 pgtype.Int8
+	Os                    pgtype.Text
+	Platform              pgtype.Text
+	PlatformFamily        pgtype.Text
+	PlatformVersion       pgtype.Text
+	KernelVersion         pgtype.Text
+	KernelArch            pgtype.Text
+	VirtualizationSystem  pgtype.Text
+	VirtualizationRole    pgtype.Text
+	HostID                pgtype.Text
+	CpuVendorID           pgtype.Text
+	CpuCores              pgtype.Int4
+	CpuModelName          pgtype.Text
+	CpuMhz                pgtype.Float8
+	CpuCacheSize          pgtype.Int4
+	Memory                pgtype.Int8
+	Disk                  pgtype.Int8
+	SystemInfoCreatedAt   pgtype.Timestamptz
+	Status                pgtype.Text
+	LastSeenAt            pgtype.Timestamptz
+	StatusChangedAt       pgtype.Timestamptz
 }
 
 func (q *Queries) GetAssetInfoById(ctx context.Context, assetID pgtype.UUID) (GetAssetInfoByIdRow, error) {
@@ -285,6 +415,9 @@ func (q *Queries) GetAssetInfoById(ctx context.Context, assetID pgtype.UUID) (Ge
 		&i.SysinfoID,
 		&i.RootAccountID,
 		&i.RegisteredAt,
+		&i.AgentHostname,
+		&i.AgentPort,
+		&i.AgentEndpointOverride,
 		&i.SystemInfoID,
 		&i.Hostname,
 		&i.Uptime,
@@ -307,12 +440,15 @@ func (q *Queries) GetAssetInfoById(ctx context.Context, assetID pgtype.UUID) (Ge
 		&i.Memory,
 		&i.Disk,
 		&i.SystemInfoCreatedAt,
+		&i.Status,
+		&i.LastSeenAt,
+		&i.StatusChangedAt,
 	)
 	return i, err
 }
 
 const getAssets = `-- name: GetAssets :many
-SELECT asset_id, ip_address, sysinfo_id, root_account_id, registered_at
+SELECT asset_id, ip_address, sysinfo_id, root_account_id, registered_at, cert_fingerprint, cert_serial, cert_not_after, cert_revoked_at, agent_hostname, agent_port, agent_endpoint_override
 FROM assets
 WHERE root_account_id = $1
 `
@@ -332,6 +468,13 @@ func (q *Queries) GetAssets(ctx context.Context, rootAccountID pgtype.UUID) ([]A
 			&i.SysinfoID,
 			&i.RootAccountID,
 			&i.RegisteredAt,
+			&i.CertFingerprint,
+			&i.CertSerial,
+			&i.CertNotAfter,
+			&i.CertRevokedAt,
+			&i.AgentHostname,
+			&i.AgentPort,
+			&i.AgentEndpointOverride,
 		); err != nil {
 			return nil, err
 		}
@@ -343,60 +486,110 @@ func (q *Queries) GetAssets(ctx context.Context, rootAccountID pgtype.UUID) ([]A
 	return items, nil
 }
 
-const getAssetsByHostnames = `-- name: GetAssetsByHostnames :many
-SELECT a.asset_id,
-  a.ip_address,
-  s.os,
-  a.root_account_id,
-  s.hostname
-FROM assets a
-  JOIN system_information s ON s.id = a.sysinfo_id
-WHERE s.hostname = ANY($1::text [])
+const getIPByAssetID = `-- name: GetIPByAssetID :one
+SELECT ip_address
+FROM assets
+WHERE asset_id = $1
+`
+
+func (q *Queries) GetIPByAssetID(ctx context.Context, assetID pgtype.UUID) (netip.Addr, error) {
+	row := q.db.QueryRow(ctx, getIPByAssetID, assetID)
+	var ip_address netip.Addr
+	err := row.Scan(&ip_address)
+	return ip_address, err
+}
+
+const reenrollAsset = `-- name: ReenrollAsset :execrows
+UPDATE assets
+SET ip_address = $1,
+  cert_fingerprint = $2,
+  cert_serial = $3,
+  cert_not_after = $4,
+  cert_revoked_at = NULL,
+  agent_hostname = $5,
+  agent_port = $6
+WHERE asset_id = $7
+  AND root_account_id = $8
 `
 
-type GetAssetsByHostnamesRow struct {
+type ReenrollAssetParams struct {
+	IpAddress       netip.Addr
+	CertFingerprint pgtype.Text
+	CertSerial      pgtype.Text
+	CertNotAfter    pgtype.Timestamptz
+	AgentHostname   pgtype.Text
+	AgentPort       int32
+	AssetID         pgtype.UUID
+	RootAccountID   pgtype.UUID
+}
+
+// Stores the certificate issued to an asset that enrolls again, which also
+// lifts a revocation. Assets of other root accounts are left alone.
+func (q *Queries) ReenrollAsset(ctx context.Context, arg ReenrollAssetParams) (int64, error) {
+	result, err := q.db.Exec(ctx, reenrollAsset,
+		arg.IpAddress,
+		arg.CertFingerprint,
+		arg.CertSerial,
+		arg.CertNotAfter,
+		arg.AgentHostname,
+		arg.AgentPort,
+		arg.AssetID,
+		arg.RootAccountID,
+	)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
+const revokeAssetCertificate = `-- name: RevokeAssetCertificate :execrows
+UPDATE assets
+SET cert_revoked_at = NOW()
+WHERE asset_id = $1
+  AND root_account_id = $2
+  AND cert_revoked_at IS NULL
+`
+
+type RevokeAssetCertificateParams struct {
 	AssetID       pgtype.UUID
-	IpAddress     netip.Addr
-	Os            pgtype.Text
 	RootAccountID pgtype.UUID
-	Hostname      pgtype.Text
 }
 
-func (q *Queries) GetAssetsByHostnames(ctx context.Context, hostnames []string) ([]GetAssetsByHostnamesRow, error) {
-	rows, err := q.db.Query(ctx, getAssetsByHostnames, hostnames)
+func (q *Queries) RevokeAssetCertificate(ctx context.Context, arg RevokeAssetCertificateParams) (int64, error) {
+	result, err := q.db.Exec(ctx, revokeAssetCertificate, arg.AssetID, arg.RootAccountID)
 	if err != nil {
-		return nil, err
+		return 0, err
 	}
-	defer rows.Close()
-	var items []GetAssetsByHostnamesRow
-	for rows.Next() {
-		var i GetAssetsByHostnamesRow
-		if err := rows.Scan(
-			&i.AssetID,
-			&i.IpAddress,
-			&i.Os,
-			&i.RootAccountID,
-			&i.Hostname,
-		); err != nil {
-			return nil, err
-		}
-		items = append(items, i)
-	}
-	if err := rows.Err(); err != nil {
-		return nil, err
-	}
-	return items, nil
+	return result.RowsAffected(), nil
 }
 
-const getIPByAssetID = `-- name: GetIPByAssetID :one
-SELECT ip_address
-FROM assets
+const updateAssetEndpoint = `-- name: UpdateAssetEndpoint :execrows
+UPDATE assets
+SET agent_hostname = $3,
+  agent_port = $4,
+  agent_endpoint_override = $5
 WHERE asset_id = $1
+  AND root_account_id = $2
 `
 
-func (q *Queries) GetIPByAssetID(ctx context.Context, assetID pgtype.UUID) (netip.Addr, error) {
-	row := q.db.QueryRow(ctx, getIPByAssetID, assetID)
-	var ip_address netip.Addr
-	err := row.Scan(&ip_address)
-	return ip_address, err
+type UpdateAssetEndpointParams struct {
+	AssetID               pgtype.UUID
+	RootAccountID         pgtype.UUID
+	AgentHostname         pgtype.Text
+	AgentPort             int32
+	AgentEndpointOverride pgtype.Text
+}
+
+func (q *Queries) UpdateAssetEndpoint(ctx context.Context, arg UpdateAssetEndpointParams) (int64, error) {
+	result, err := q.db.Exec(ctx, updateAssetEndpoint,
+		arg.AssetID,
+		arg.RootAccountID,
+		arg.AgentHostname,
+		arg.AgentPort,
+		arg.AgentEndpointOverride,
+	)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
 }
@@ -68,11 +68,102 @@ type Action struct {
 }
 
 type Asset struct {
+	AssetID               pgtype.UUID
+	IpAddress             netip.Addr
+	SysinfoID             pgtype.UUID
+	RootAccountID         pgtype.UUID
+	RegisteredAt          pgtype.Timestamptz
+	CertFingerprint       pgtype.Text
+	CertSerial            pgtype.Text
+	CertNotAfter          pgtype.Timestamptz
+	CertRevokedAt         pgtype.Timestamptz
+	AgentHostname         pgtype.Text
+	AgentPort             int32
+	AgentEndpointOverride pgtype.Text
+}
+
+type AssetCapability struct {
+	AssetID      pgtype.UUID
+	AgentVersion string
+	Os           string
+	Arch         string
+	Operations   []string
+	Tools        []byte
+	ReportedAt   pgtype.Timestamptz
+}
+
+type AssetFile struct {
+	FileID        pgtype.UUID
 	AssetID       pgtype.UUID
-	IpAddress     netip.Addr
-	SysinfoID     pgtype.UUID
 	RootAccountID pgtype.UUID
-	RegisteredAt  pgtype.Timestamptz
+	RemotePath    string
+	Name          string
+	SizeBytes     int64
+	Sha256        string
+	StoragePath   string
+	RetrievedBy   pgtype.UUID
+	RetrievedAt   pgtype.Timestamptz
+}
+
+type AssetFindingScan struct {
+	FindingResultID pgtype.UUID
+	RootAccountID   pgtype.UUID
+	ScanID          pgtype.UUID
+	AssetID         pgtype.UUID
+	FindingDataID   pgtype.UUID
+	FilePath        string
+	StartLine       int32
+	EndLine         int32
+	LicenseName     string
+	PackageName     string
+	Target          string
+	Message         string
+	Location        string
+	ScanDate        pgtype.Timestamptz
+}
+
+type AssetFindingStateHistory struct {
+	HistoryID      pgtype.UUID
+	AssetID        pgtype.UUID
+	FindingDataID  pgtype.UUID
+	Location       string
+	FindingState   Vulnstate
+	StateChangedAt pgtype.Timestamptz
+	RootAccountID  pgtype.UUID
+}
+
+type AssetLiveness struct {
+	AssetID         pgtype.UUID
+	Status          string
+	LastSeenAt      pgtype.Timestamptz
+	StatusChangedAt pgtype.Timestamptz
+}
+
+type AssetStatusHistory struct {
+	AssetID   pgtype.UUID
+	Status    string
+	Reason    pgtype.Text
+	ChangedAt pgtype.Timestamptz
+}
+
+type AssetTag struct {
+	AssetID pgtype.UUID
+	Tag     string
+}
+
+type AssetVulnerabilityPackage struct {
+	PackageResultID  pgtype.UUID
+	RootAccountID    pgtype.UUID
+	ScanID           pgtype.UUID
+	AssetID          pgtype.UUID
+	VulnerabilityID  pgtype.UUID
+	PackageName      string
+	InstalledVersion string
+	FixedVersion     string
+	PackagePath      string
+	Target           string
+	PackageType      string
+	ScanDate         pgtype.Timestamptz
 }
 
 type AssetVulnerabilityScan struct {
@@ -84,6 +175,15 @@ type AssetVulnerabilityScan struct {
 	ScanDate        pgtype.Timestamptz
 }
 
+type AssetVulnerabilityStateHistory struct {
+	HistoryID          pgtype.UUID
+	AssetID            pgtype.UUID
+	VulnDataID         pgtype.UUID
+	VulnerabilityState Vulnstate
+	StateChangedAt     pgtype.Timestamptz
+	RootAccountID      pgtype.UUID
+}
+
 type Environment struct {
 	EnvironmentID   pgtype.UUID
 	EnvironmentName string
@@ -97,6 +197,25 @@ type EnvironmentAsset struct {
 	AssetID       pgtype.UUID
 }
 
+type FindingDatum struct {
+	FindingDataID pgtype.UUID
+	FindingType   string
+	RuleID        string
+	Title         string
+	Description   string
+	Severity      string
+	Resolution    string
+	Reference     []string
+}
+
+type FindingStateHistory struct {
+	HistoryID      pgtype.UUID
+	FindingDataID  pgtype.UUID
+	FindingState   Vulnstate
+	StateChangedAt pgtype.Timestamptz
+	RootAccountID  pgtype.UUID
+}
+
 type IamAccount struct {
 	AccountID       pgtype.UUID
 	RootAccountID   pgtype.UUID
@@ -171,6 +290,56 @@ type Scan struct {
 	ScannerName   string
 	ScanDate      pgtype.Timestamptz
 	Notes         pgtype.Text
+	Target        []byte
+	AssetIds      []pgtype.UUID
+	ScanPath      string
+}
+
+type ScanJob struct {
+	JobID           pgtype.UUID
+	RootAccountID   pgtype.UUID
+	RequestedBy     pgtype.UUID
+	RequestedByType string
+	ScannerName     string
+	Flags           []byte
+	Target          []byte
+	Status          string
+	Error           pgtype.Text
+	ScanID          pgtype.UUID
+	CreatedAt       pgtype.Timestamptz
+	StartedAt       pgtype.Timestamptz
+	FinishedAt      pgtype.Timestamptz
+}
+
+type ScanJobAsset struct {
+	JobID              pgtype.UUID
+	AssetID            pgtype.UUID
+	Status             string
+	Error              pgtype.Text
+	VulnerabilityCount int32
+	StartedAt          pgtype.Timestamptz
+	FinishedAt         pgtype.Timestamptz
+}
+
+type ScanSchedule struct {
+	ScheduleID           pgtype.UUID
+	RootAccountID        pgtype.UUID
+	Name                 string
+	CronExpression       string
+	Timezone             string
+	ScannerName          string
+	Flags                []byte
+	TargetAssetIds       []pgtype.UUID
+	TargetEnvironmentIds []pgtype.UUID
+	TargetTags           []string
+	Enabled              bool
+	CreatedBy            pgtype.UUID
+	CreatedAt            pgtype.Timestamptz
+	UpdatedAt            pgtype.Timestamptz
+	NextRunAt            pgtype.Timestamptz
+	LastRunAt            pgtype.Timestamptz
+	LastJobID            pgtype.UUID
+	LastError            pgtype.Text
 }
 
 type SystemInformation struct {
@@ -11,6 +11,45 @@ import (
 	"github.com/jackc/pgx/v5/pgtype"
 )
 
+const batchInsertVulnerabilityPackages = `-- name: BatchInsertVulnerabilityPackages :exec
+INSERT INTO asset_vulnerability_packages (
+        root_account_id,
+        scan_id,
+        asset_id,
+        vulnerability_id,
+        package_name,
+        installed_version,
+        fixed_version,
+        package_path,
+        target,
+        package_type
+    )
+SELECT s.root_account_id,
+    $1 AS scan_id,
+    $2 AS asset_id,
+    vd.vulnerability_data_id AS vulnerability_id,
+    pkg->>'PkgName',
+    COALESCE(pkg->>'InstalledVersion', ''),
+    COALESCE(pkg->>'FixedVersion', ''),
+    COALESCE(pkg->>'PkgPath', ''),
+    COALESCE(pkg->>'Target', ''),
+    COALESCE(pkg->>'PkgType', '')
+FROM jsonb_array_elements($3::jsonb) AS pkg
+    JOIN vulnerability_data vd ON vd.vulnerability_id = pkg->>'VulnerabilityID'
+    JOIN scans s ON s.scan_id = $1
+`
+
+type BatchInsertVulnerabilityPackagesParams struct {
+	ScanID   pgtype.UUID
+	AssetID  pgtype.UUID
+	Packages []byte
+}
+
+func (q *Queries) BatchInsertVulnerabilityPackages(ctx context.Context, arg BatchInsertVulnerabilityPackagesParams) error {
+	_, err := q.db.Exec(ctx, batchInsertVulnerabilityPackages, arg.ScanID, arg.AssetID, arg.Packages)
+	return err
+}
+
 const batchUpdateAVS = `-- name: BatchUpdateAVS :exec
 INSERT INTO asset_vulnerability_scan (
         root_account_id,
@@ -39,7 +78,14 @@ func (q *Queries) BatchUpdateAVS(ctx context.Context, arg BatchUpdateAVSParams)
 }
 
 const createScanEntryIAMUser = `-- name: CreateScanEntryIAMUser :one
-INSERT INTO scans (scanner_name, root_account_id, scanned_by_user)
+INSERT INTO scans (
+        scanner_name,
+        root_account_id,
+        scanned_by_user,
+        target,
+        asset_ids,
+        scan_path
+    )
 VALUES (
         $1,
         (
@@ -47,7 +93,10 @@ VALUES (
             FROM iam_accounts
             WHERE account_id = $2
         ),
-        $2
+        $2,
+        $3,
+        $4,
+        $5
     )
 RETURNING scan_id
 `
@@ -55,33 +104,350 @@ RETURNING scan_id
 type CreateScanEntryIAMUserParams struct {
 	ScannerName   string
 	ScannedByUser pgtype.UUID
+	Target        []byte
+	AssetIds      []pgtype.UUID
+	ScanPath      string
 }
 
 func (q *Queries) CreateScanEntryIAMUser(ctx context.Context, arg CreateScanEntryIAMUserParams) (pgtype.UUID, error) {
-	row := q.db.QueryRow(ctx, createScanEntryIAMUser, arg.ScannerName, arg.ScannedByUser)
+	row := q.db.QueryRow(ctx, createScanEntryIAMUser,
+		arg.ScannerName,
+		arg.ScannedByUser,
+		arg.Target,
+		arg.AssetIds,
+		arg.ScanPath,
+	)
 	var scan_id pgtype.UUID
 	err := row.Scan(&scan_id)
 	return scan_id, err
 }
 
 const createScanEntryRoot = `-- name: CreateScanEntryRoot :one
-INSERT INTO scans (scanner_name, root_account_id)
-VALUES ($1, $2)
+INSERT INTO scans (
+        scanner_name,
+        root_account_id,
+        target,
+        asset_ids,
+        scan_path
+    )
+VALUES ($1, $2, $3, $4, $5)
 RETURNING scan_id
 `
 
 type CreateScanEntryRootParams struct {
 	ScannerName   string
 	RootAccountID pgtype.UUID
+	Target        []byte
+	AssetIds      []pgtype.UUID
+	ScanPath      string
 }
 
 func (q *Queries) CreateScanEntryRoot(ctx context.Context, arg CreateScanEntryRootParams) (pgtype.UUID, error) {
-	row := q.db.QueryRow(ctx, createScanEntryRoot, arg.ScannerName, arg.RootAccountID)
+	row := q.db.QueryRow(ctx, createScanEntryRoot,
+		arg.ScannerName,
+		arg.RootAccountID,
+		arg.Target,
+		arg.AssetIds,
+		arg.ScanPath,
+	)
 	var scan_id pgtype.UUID
 	err := row.Scan(&scan_id)
 	return scan_id, err
 }
 
+const getPreviousScansForAssets = `-- name: GetPreviousScansForAssets :many
+SELECT DISTINCT ON (covered.asset_id) covered.asset_id,
+    s.scan_id
+FROM (
+        SELECT avs.asset_id,
+            avs.scan_id
+        FROM asset_vulnerability_scan avs
+        WHERE avs.asset_id = ANY($1::uuid [])
+        UNION
+        SELECT ja.asset_id,
+            j.scan_id
+        FROM scan_job_assets ja
+            JOIN scan_jobs j ON j.job_id = ja.job_id
+        WHERE ja.asset_id = ANY($1::uuid [])
+            AND ja.status = 'succeeded'
+            AND j.scan_id IS NOT NULL
+    ) covered
+    JOIN scans s ON s.scan_id = covered.scan_id
+WHERE s.root_account_id = $2
+    AND s.scanner_name = $3
+    AND s.scan_path = $4
+    AND s.scan_date < $5
+ORDER BY covered.asset_id,
+    s.scan_date DESC
+`
+
+type GetPreviousScansForAssetsParams struct {
+	AssetIds      []pgtype.UUID
+	RootAccountID pgtype.UUID
+	ScannerName   string
+	ScanPath      string
+	ScanDate      pgtype.Timestamptz
+}
+
+type GetPreviousScansForAssetsRow struct {
+	AssetID pgtype.UUID
+	ScanID  pgtype.UUID
+}
+
+// Only scans of the same path are compared, since a scan of another path
+// covers other files on the asset.
+func (q *Queries) GetPreviousScansForAssets(ctx context.Context, arg GetPreviousScansForAssetsParams) ([]GetPreviousScansForAssetsRow, error) {
+	rows, err := q.db.Query(ctx, getPreviousScansForAssets,
+		arg.AssetIds,
+		arg.RootAccountID,
+		arg.ScannerName,
+		arg.ScanPath,
+		arg.ScanDate,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GetPreviousScansForAssetsRow
+	for rows.Next() {
+		var i GetPreviousScansForAssetsRow
+		if err := rows.Scan(&i.AssetID, &i.ScanID); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getScanAssets = `-- name: GetScanAssets :many
+SELECT a.asset_id,
+    si.hostname
+FROM assets a
+    JOIN system_information si ON si.id = a.sysinfo_id
+WHERE a.asset_id IN (
+        SELECT avs.asset_id
+        FROM asset_vulnerability_scan avs
+        WHERE avs.scan_id = $1
+        UNION
+        SELECT ja.asset_id
+        FROM scan_job_assets ja
+            JOIN scan_jobs j ON j.job_id = ja.job_id
+        WHERE j.scan_id = $1
+            AND ja.status = 'succeeded'
+    )
+ORDER BY si.hostname
+`
+
+type GetScanAssetsRow struct {
+	AssetID  pgtype.UUID
+	Hostname pgtype.Text
+}
+
+func (q *Queries) GetScanAssets(ctx context.Context, scanID pgtype.UUID) ([]GetScanAssetsRow, error) {
+	rows, err := q.db.Query(ctx, getScanAssets, scanID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GetScanAssetsRow
+	for rows.Next() {
+		var i GetScanAssetsRow
+		if err := rows.Scan(&i.AssetID, &i.Hostname); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getScanFindings = `-- name: GetScanFindings :many
+SELECT avs.scan_id,
+    avs.asset_id,
+    vd.vulnerability_id,
+    vd.vulnerability_severity
+FROM asset_vulnerability_scan avs
+    JOIN vulnerability_data vd ON vd.vulnerability_data_id = avs.vulnerability_id
+WHERE avs.root_account_id = $1
+    AND avs.scan_id = ANY($2::uuid [])
+ORDER BY vd.vulnerability_id
+`
+
+type GetScanFindingsParams struct {
+	RootAccountID pgtype.UUID
+	ScanIds       []pgtype.UUID
+}
+
+type GetScanFindingsRow struct {
+	ScanID                pgtype.UUID
+	AssetID               pgtype.UUID
+	VulnerabilityID       string
+	VulnerabilitySeverity pgtype.Text
+}
+
+func (q *Queries) GetScanFindings(ctx context.Context, arg GetScanFindingsParams) ([]GetScanFindingsRow, error) {
+	rows, err := q.db.Query(ctx, getScanFindings, arg.RootAccountID, arg.ScanIds)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GetScanFindingsRow
+	for rows.Next() {
+		var i GetScanFindingsRow
+		if err := rows.Scan(
+			&i.ScanID,
+			&i.AssetID,
+			&i.VulnerabilityID,
+			&i.VulnerabilitySeverity,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getScanForRootAccount = `-- name: GetScanForRootAccount :one
+SELECT scan_id,
+    scanner_name,
+    scan_path,
+    scan_date
+FROM scans
+WHERE scan_id = $1
+    AND root_account_id = $2
+`
+
+type GetScanForRootAccountParams struct {
+	ScanID        pgtype.UUID
+	RootAccountID pgtype.UUID
+}
+
+type GetScanForRootAccountRow struct {
+	ScanID      pgtype.UUID
+	ScannerName string
+	ScanPath    string
+	ScanDate    pgtype.Timestamptz
+}
+
+func (q *Queries) GetScanForRootAccount(ctx context.Context, arg GetScanForRootAccountParams) (GetScanForRootAccountRow, error) {
+	row := q.db.QueryRow(ctx, getScanForRootAccount, arg.ScanID, arg.RootAccountID)
+	var i GetScanForRootAccountRow
+	err := row.Scan(
+		&i.ScanID,
+		&i.ScannerName,
+		&i.ScanPath,
+		&i.ScanDate,
+	)
+	return i, err
+}
+
+const getScanPackages = `-- name: GetScanPackages :many
+SELECT avp.asset_id,
+    si.hostname,
+    vd.vulnerability_id,
+    vd.vulnerability_severity,
+    avp.package_name,
+    avp.installed_version,
+    avp.fixed_version,
+    avp.package_path,
+    avp.target,
+    avp.package_type
+FROM asset_vulnerability_packages avp
+    JOIN vulnerability_data vd ON vd.vulnerability_data_id = avp.vulnerability_id
+    JOIN assets a ON a.asset_id = avp.asset_id
+    JOIN system_information si ON si.id = a.sysinfo_id
+WHERE avp.scan_id = $1
+    AND avp.root_account_id = $2
+ORDER BY si.hostname,
+    vd.vulnerability_id,
+    avp.package_name
+`
+
+type GetScanPackagesParams struct {
+	ScanID        pgtype.UUID
+	RootAccountID pgtype.UUID
+}
+
+type GetScanPackagesRow struct {
+	AssetID               pgtype.UUID
+	Hostname              pgtype.Text
+	VulnerabilityID       string
+	VulnerabilitySeverity pgtype.Text
+	PackageName           string
+	InstalledVersion      string
+	FixedVersion          string
+	PackagePath           string
+	Target                string
+	PackageType           string
+}
+
+func (q *Queries) GetScanPackages(ctx context.Context, arg GetScanPackagesParams) ([]GetScanPackagesRow, error) {
+	rows, err := q.db.Query(ctx, getScanPackages, arg.ScanID, arg.RootAccountID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GetScanPackagesRow
+	for rows.Next() {
+		var i GetScanPackagesRow
+		if err := rows.Scan(
+			&i.AssetID,
+			&i.Hostname,
+			&i.VulnerabilityID,
+			&i.VulnerabilitySeverity,
+			&i.PackageName,
+			&i.InstalledVersion,
+			&i.FixedVersion,
+			&i.PackagePath,
+			&i.Target,
+			&i.PackageType,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const removeScanAssetResults = `-- name: RemoveScanAssetResults :exec
+WITH removed_vulnerabilities AS (
+    DELETE FROM asset_vulnerability_scan
+    WHERE scan_id = $1
+        AND asset_id = $2
+),
+removed_packages AS (
+    DELETE FROM asset_vulnerability_packages
+    WHERE scan_id = $1
+        AND asset_id = $2
+)
+DELETE FROM asset_finding_scan
+WHERE scan_id = $1
+    AND asset_id = $2
+`
+
+type RemoveScanAssetResultsParams struct {
+	ScanID  pgtype.UUID
+	AssetID pgtype.UUID
+}
+
+// Removes what a scan recorded for one asset, so that it can be recorded
+// again.
+func (q *Queries) RemoveScanAssetResults(ctx context.Context, arg RemoveScanAssetResultsParams) error {
+	_, err := q.db.Exec(ctx, removeScanAssetResults, arg.ScanID, arg.AssetID)
+	return err
+}
+
 const removeScanEntry = `-- name: RemoveScanEntry :exec
 DELETE FROM scans
 WHERE scan_id = $1
@@ -92,13 +458,35 @@ func (q *Queries) RemoveScanEntry(ctx context.Context, scanID pgtype.UUID) error
 	return err
 }
 
+const removeScanResults = `-- name: RemoveScanResults :exec
+WITH removed_vulnerabilities AS (
+    DELETE FROM asset_vulnerability_scan
+    WHERE scan_id = $1
+),
+removed_packages AS (
+    DELETE FROM asset_vulnerability_packages
+    WHERE scan_id = $1
+)
+DELETE FROM asset_finding_scan
+WHERE scan_id = $1
+`
+
+// Removes what a scan recorded for its assets, so the scan itself can be
+// removed.
+func (q *Queries) RemoveScanResults(ctx context.Context, scanID pgtype.UUID) error {
+	_, err := q.db.Exec(ctx, removeScanResults, scanID)
+	return err
+}
+
 const retrieveScans = `-- name: RetrieveScans :many
 SELECT s.scan_id,
     ra.username AS root_account_username,
     s.root_account_id,
     s.scanner_name,
     s.scan_date,
-    s.notes
+    s.notes,
+    s.target,
+    s.asset_ids
 FROM scans s
     JOIN root_accounts ra ON s.root_account_id = ra.account_id
 WHERE root_account_id = $1
@@ -112,6 +500,8 @@ type RetrieveScansRow struct {
 	ScannerName         string
 	ScanDate            pgtype.Timestamptz
 	Notes               pgtype.Text
+	Target              []byte
+	AssetIds            []pgtype.UUID
 }
 
 func (q *Queries) RetrieveScans(ctx context.Context, rootAccountID pgtype.UUID) ([]RetrieveScansRow, error) {
@@ -130,6 +520,8 @@ func (q *Queries) RetrieveScans(ctx context.Context, rootAccountID pgtype.UUID)
 			&i.ScannerName,
 			&i.ScanDate,
 			&i.Notes,
+			&i.Target,
+			&i.AssetIds,
 		); err != nil {
 			return nil, err
 		}
@@ -16,15 +16,21 @@ const getAllAssetIPs = `-- name: GetAllAssetIPs :many
 SELECT 
   asset_id,
   ip_address,
-  root_account_id
+  root_account_id,
+  agent_hostname,
+  agent_port,
+  agent_endpoint_override
 FROM assets
 ORDER BY root_account_id, asset_id
 `
 
 type GetAllAssetIPsRow struct {
-	AssetID       pgtype.UUID
-	IpAddress     netip.Addr
-	RootAccountID pgtype.UUID
+	AssetID               pgtype.UUID
+	IpAddress             netip.Addr
+	RootAccountID         pgtype.UUID
+	AgentHostname         pgtype.Text
+	AgentPort             int32
+	AgentEndpointOverride pgtype.Text
 }
 
 func (q *Queries) GetAllAssetIPs(ctx context.Context) ([]GetAllAssetIPsRow, error) {
@@ -36,7 +42,14 @@ func (q *Queries) GetAllAssetIPs(ctx context.Context) ([]GetAllAssetIPsRow, erro
 	var items []GetAllAssetIPsRow
 	for rows.Next() {
 		var i GetAllAssetIPsRow
-		if err := rows.Scan(&i.AssetID, &i.IpAddress, &i.RootAccountID); err != nil {
+		if err := rows.Scan(
+			&i.AssetID,
+			&i.IpAddress,
+			&i.RootAccountID,
+			&i.AgentHostname,
+			&i.AgentPort,
+			&i.AgentEndpointOverride,
+		); err != nil {
 			return nil, err
 		}
 		items = append(items, i)
@@ -0,0 +1,31 @@
+package query
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// InTx runs fn with queries bound to a new transaction, which is committed if
+// fn returns nil and rolled back otherwise.
+func (q *Queries) InTx(ctx context.Context, fn func(*Queries) error) error {
+	db, ok := q.db.(interface {
+		Begin(context.Context) (pgx.Tx, error)
+	})
+	if !ok {
+		return fmt.Errorf("database connection does not support transactions")
+	}
+
+	tx, err := db.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %v", err)
+	}
+	defer tx.Rollback(ctx)
+
+	if err := fn(q.WithTx(tx)); err != nil {
+		return err
+	}
+
+	return tx.Commit(ctx)
+}
@@ -0,0 +1,136 @@
+package endpoint
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/netip"
+	"strconv"
+	"strings"
+
+	"github.com/jackc/pgx/v5/pgtype"
+
+	"github.com/SyntinelNyx/syntinel-server/internal/database/query"
+)
+
+const DefaultPort = 50051
+
+// Endpoint is where an asset's agent accepts connections. Override is an
+// admin-supplied host[:port] used as is; otherwise the agent is reached at
+// Hostname, or the IP recorded at enrollment, on Port.
+type Endpoint struct {
+	IP       netip.Addr
+	Hostname string
+	Port     int32
+	Override string
+}
+
+func New(ip netip.Addr, hostname pgtype.Text, port int32, override pgtype.Text) Endpoint {
+	return Endpoint{IP: ip, Hostname: hostname.String, Port: port, Override: override.String}
+}
+
+// Target returns the host:port to dial.
+func (e Endpoint) Target() (string, error) {
+	port := e.Port
+	if port == 0 {
+		port = DefaultPort
+	}
+
+	switch {
+	case e.Override != "":
+		if _, _, err := net.SplitHostPort(e.Override); err == nil {
+			return e.Override, nil
+		}
+		return net.JoinHostPort(strings.Trim(e.Override, "[]"), strconv.Itoa(int(port))), nil
+	case e.Hostname != "":
+		return net.JoinHostPort(e.Hostname, strconv.Itoa(int(port))), nil
+	case e.IP.IsValid():
+		return net.JoinHostPort(e.IP.String(), strconv.Itoa(int(port))), nil
+	default:
+		return "", fmt.Errorf("asset has no agent address")
+	}
+}
+
+// Validate checks an endpoint reported by an agent or entered by an admin.
+func Validate(hostname string, port int32, override string) error {
+	if port < 1 || port > 65535 {
+		return fmt.Errorf("port %d is out of range", port)
+	}
+	if hostname != "" && !validHost(hostname) {
+		return fmt.Errorf("invalid hostname %q", hostname)
+	}
+	if override != "" {
+		host, portStr, err := net.SplitHostPort(override)
+		if err != nil {
+			host, portStr = strings.Trim(override, "[]"), ""
+		}
+		if !validHost(host) {
+			return fmt.Errorf("invalid override host %q", host)
+		}
+		if portStr != "" {
+			if p, err := strconv.Atoi(portStr); err != nil || p < 1 || p > 65535 {
+				return fmt.Errorf("invalid override port %q", portStr)
+			}
+		}
+	}
+
+	return nil
+}
+
+var lookupNetIP = net.DefaultResolver.LookupNetIP
+
+// CheckReported accepts a hostname an agent reports at enrollment only if it
+// is, or resolves to, the address the agent enrolled from, so an agent cannot
+// point the server's connections at another host. Admins can still set any
+// hostname or override with the endpoint update.
+func CheckReported(ctx context.Context, hostname string, source netip.Addr) error {
+	source = source.Unmap()
+
+	if addr, err := netip.ParseAddr(hostname); err == nil {
+		if addr.Unmap() != source {
+			return fmt.Errorf("address %s is not the enrollment address %s", hostname, source)
+		}
+		return nil
+	}
+
+	addrs, err := lookupNetIP(ctx, "ip", hostname)
+	if err != nil {
+		return fmt.Errorf("failed to resolve %q: %v", hostname, err)
+	}
+	for _, addr := range addrs {
+		if addr.Unmap() == source {
+			return nil
+		}
+	}
+	return fmt.Errorf("hostname %q does not resolve to the enrollment address %s", hostname, source)
+}
+
+func validHost(host string) bool {
+	if _, err := netip.ParseAddr(host); err == nil {
+		return true
+	}
+	if host == "" || len(host) > 253 {
+		return false
+	}
+	for _, label := range strings.Split(host, ".") {
+		if label == "" || len(label) > 63 || strings.HasPrefix(label, "-") || strings.HasSuffix(label, "-") {
+			return false
+		}
+		for _, c := range label {
+			if !(c == '-' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9') {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// Resolve looks up the asset and returns the host:port to dial its agent at.
+func Resolve(ctx context.Context, queries *query.Queries, assetID pgtype.UUID) (string, error) {
+	row, err := queries.GetAssetEndpoint(ctx, assetID)
+	if err != nil {
+		return "", fmt.Errorf("failed to get asset endpoint: %w", err)
+	}
+
+	return New(row.IpAddress, row.AgentHostname, row.AgentPort, row.AgentEndpointOverride).Target()
+}
@@ -0,0 +1,67 @@
+package endpoint
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/netip"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTarget(t *testing.T) {
+	tests := []struct {
+		name     string
+		endpoint Endpoint
+		want     string
+	}{
+		{"ipv4 default port", Endpoint{IP: netip.MustParseAddr("10.0.0.5")}, "10.0.0.5:50051"},
+		{"ipv6", Endpoint{IP: netip.MustParseAddr("fd00::5"), Port: 50051}, "[fd00::5]:50051"},
+		{"hostname over ip", Endpoint{IP: netip.MustParseAddr("10.0.0.5"), Hostname: "agent.internal", Port: 6000}, "agent.internal:6000"},
+		{"override with port", Endpoint{Hostname: "agent.internal", Override: "nat.example.com:443"}, "nat.example.com:443"},
+		{"override without port", Endpoint{Override: "nat.example.com", Port: 6000}, "nat.example.com:6000"},
+		{"ipv6 override without port", Endpoint{Override: "[fd00::9]"}, "[fd00::9]:50051"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.endpoint.Target()
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+
+	_, err := Endpoint{}.Target()
+	assert.Error(t, err)
+}
+
+func TestValidate(t *testing.T) {
+	assert.NoError(t, Validate("agent.internal", 50051, ""))
+	assert.NoError(t, Validate("", 50051, "nat.example.com:443"))
+	assert.NoError(t, Validate("", 50051, "[fd00::9]:443"))
+
+	assert.Error(t, Validate("", 0, ""))
+	assert.Error(t, Validate("", 70000, ""))
+	assert.Error(t, Validate("bad host", 50051, ""))
+	assert.Error(t, Validate("", 50051, "nat.example.com:99999"))
+	assert.Error(t, Validate("", 50051, "-bad-.example.com"))
+}
+
+func TestCheckReported(t *testing.T) {
+	lookupNetIP = func(ctx context.Context, network, host string) ([]netip.Addr, error) {
+		if host == "agent.internal" {
+			return []netip.Addr{netip.MustParseAddr("10.0.0.9"), netip.MustParseAddr("10.0.0.5")}, nil
+		}
+		return nil, errors.New("no such host")
+	}
+	t.Cleanup(func() { lookupNetIP = net.DefaultResolver.LookupNetIP })
+
+	source := netip.MustParseAddr("10.0.0.5")
+	assert.NoError(t, CheckReported(context.Background(), "10.0.0.5", source))
+	assert.NoError(t, CheckReported(context.Background(), "agent.internal", source))
+	assert.NoError(t, CheckReported(context.Background(), "10.0.0.5", netip.MustParseAddr("::ffff:10.0.0.5")))
+	assert.Error(t, CheckReported(context.Background(), "169.254.169.254", source))
+	assert.Error(t, CheckReported(context.Background(), "agent.internal", netip.MustParseAddr("10.0.0.6")))
+	assert.Error(t, CheckReported(context.Background(), "unknown.internal", source))
+}
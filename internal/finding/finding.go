@@ -0,0 +1,51 @@
+package finding
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// TypeVulnerability stands for the vulnerabilities a scan looks for, which
+// are stored as vulnerabilities rather than findings.
+const (
+	TypeVulnerability = "vuln"
+	TypeSecret        = "secret"
+	TypeMisconfig     = "misconfig"
+	TypeLicense       = "license"
+)
+
+// Finding is a non-vulnerability result of a scan at one location on an
+// asset. Findings with the same Type and RuleID share their data, and each
+// file and line, or package and file for licenses, they are found at goes
+// through the lifecycle states separately. For licenses the RuleID is the
+// license name.
+type Finding struct {
+	Type        string   `json:"Type"`
+	RuleID      string   `json:"RuleID"`
+	Title       string   `json:"Title"`
+	Description string   `json:"Description"`
+	Severity    string   `json:"Severity"`
+	Resolution  string   `json:"Resolution,omitempty"`
+	References  []string `json:"References"`
+
+	FilePath    string `json:"FilePath,omitempty"`
+	StartLine   int    `json:"StartLine,omitempty"`
+	EndLine     int    `json:"EndLine,omitempty"`
+	LicenseName string `json:"LicenseName,omitempty"`
+	Package     string `json:"PkgName,omitempty"`
+	Target      string `json:"Target,omitempty"`
+	Message     string `json:"Message,omitempty"`
+}
+
+func GetFindingsJSON(findings []Finding) ([]byte, error) {
+	if findings == nil {
+		findings = []Finding{}
+	}
+
+	findingsJSON, err := json.Marshal(findings)
+	if err != nil {
+		return nil, fmt.Errorf("error marshalling findings to JSON: %w", err)
+	}
+
+	return findingsJSON, nil
+}
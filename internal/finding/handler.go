@@ -0,0 +1,26 @@
+package finding
+
+import (
+	"net/http"
+
+	"github.com/jackc/pgx/v5/pgtype"
+
+	"github.com/SyntinelNyx/syntinel-server/internal/auth"
+	"github.com/SyntinelNyx/syntinel-server/internal/database/query"
+)
+
+type Handler struct {
+	queries *query.Queries
+}
+
+func NewHandler(queries *query.Queries) *Handler {
+	return &Handler{queries: queries}
+}
+
+func (h *Handler) rootAccountID(r *http.Request) (pgtype.UUID, error) {
+	account := auth.GetClaims(r.Context())
+	if account.AccountType == "root" {
+		return account.AccountID, nil
+	}
+	return h.queries.GetRootAccountIDForIAMUser(r.Context(), account.AccountID)
+}
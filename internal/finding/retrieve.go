@@ -0,0 +1,214 @@
+package finding
+
+import (
+	"fmt"
+	"net/http"
+	"slices"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+
+	"github.com/SyntinelNyx/syntinel-server/internal/database/query"
+	"github.com/SyntinelNyx/syntinel-server/internal/response"
+)
+
+type assetsAffected struct {
+	AssetUUID string `json:"assetUUID"`
+	Hostname  string `json:"hostname"`
+}
+
+type findingResponse struct {
+	FindingUUID    string           `json:"id"`
+	Type           string           `json:"type"`
+	RuleID         string           `json:"ruleId"`
+	Title          string           `json:"title"`
+	Status         string           `json:"status"`
+	Severity       string           `json:"severity"`
+	AssetsAffected []assetsAffected `json:"assetsAffected"`
+	LastSeen       string           `json:"lastSeen"`
+}
+
+// Location is where a finding was found on an asset.
+type Location struct {
+	AssetID     string `json:"assetId,omitempty"`
+	Hostname    string `json:"hostname,omitempty"`
+	Type        string `json:"type,omitempty"`
+	RuleID      string `json:"ruleId,omitempty"`
+	Title       string `json:"title,omitempty"`
+	Severity    string `json:"severity,omitempty"`
+	File        string `json:"file"`
+	StartLine   int32  `json:"startLine,omitempty"`
+	EndLine     int32  `json:"endLine,omitempty"`
+	LicenseName string `json:"licenseName,omitempty"`
+	Package     string `json:"package,omitempty"`
+	Target      string `json:"target,omitempty"`
+	Message     string `json:"message,omitempty"`
+}
+
+// AssetFinding is a finding on one asset with its lifecycle state there.
+type AssetFinding struct {
+	FindingUUID string     `json:"id"`
+	Type        string     `json:"type"`
+	RuleID      string     `json:"ruleId"`
+	Title       string     `json:"title"`
+	Severity    string     `json:"severity"`
+	State       string     `json:"state"`
+	ChangedAt   string     `json:"changedAt"`
+	ScanID      string     `json:"scanId"`
+	LastSeen    string     `json:"lastSeen"`
+	Locations   []Location `json:"locations"`
+}
+
+// Retrieve lists the findings of the account with their account-level
+// state. The type query parameter limits them to one finding type.
+func (h *Handler) Retrieve(w http.ResponseWriter, r *http.Request) {
+	findingType := r.URL.Query().Get("type")
+	if findingType != "" && !slices.Contains([]string{TypeSecret, TypeMisconfig, TypeLicense}, findingType) {
+		response.RespondWithError(w, r, http.StatusBadRequest, "Invalid finding type", fmt.Errorf("unknown finding type %q", findingType))
+		return
+	}
+
+	rootId, err := h.rootAccountID(r)
+	if err != nil {
+		response.RespondWithError(w, r, http.StatusInternalServerError, "Failed to get associated root account", err)
+		return
+	}
+
+	rows, err := h.queries.GetFindings(r.Context(), query.GetFindingsParams{
+		RootAccountID: rootId,
+		FindingType:   findingType,
+	})
+	if err != nil {
+		response.RespondWithError(w, r, http.StatusInternalServerError, "Failed to retrieve findings", err)
+		return
+	}
+
+	findings := []findingResponse{}
+	for _, row := range rows {
+		assetList := []assetsAffected{}
+		for idx, assetUUID := range row.AssetUuids {
+			assetList = append(assetList, assetsAffected{
+				AssetUUID: response.UuidToString(assetUUID),
+				Hostname:  row.AssetsAffected[idx],
+			})
+		}
+
+		findings = append(findings, findingResponse{
+			FindingUUID:    response.UuidToString(row.FindingDataID),
+			Type:           row.FindingType,
+			RuleID:         row.RuleID,
+			Title:          row.Title,
+			Status:         string(row.FindingState),
+			Severity:       row.Severity,
+			AssetsAffected: assetList,
+			LastSeen:       row.LastSeen.Time.Format(time.RFC3339),
+		})
+	}
+
+	response.RespondWithJSON(w, http.StatusOK, findings)
+}
+
+// RetrieveScan lists every location a finding was found at by a scan.
+func (h *Handler) RetrieveScan(w http.ResponseWriter, r *http.Request) {
+	var scanUUID pgtype.UUID
+	if err := scanUUID.Scan(chi.URLParam(r, "scanID")); err != nil {
+		response.RespondWithError(w, r, http.StatusBadRequest, "Invalid scan_id format", err)
+		return
+	}
+
+	rootId, err := h.rootAccountID(r)
+	if err != nil {
+		response.RespondWithError(w, r, http.StatusInternalServerError, "Failed to get associated root account", err)
+		return
+	}
+
+	rows, err := h.queries.GetFindingsByScan(r.Context(), query.GetFindingsByScanParams{
+		ScanID:        scanUUID,
+		RootAccountID: rootId,
+	})
+	if err != nil {
+		response.RespondWithError(w, r, http.StatusInternalServerError, "Failed to retrieve scan findings", err)
+		return
+	}
+
+	locations := []Location{}
+	for _, row := range rows {
+		locations = append(locations, Location{
+			AssetID:     response.UuidToString(row.AssetID),
+			Hostname:    row.Hostname.String,
+			Type:        row.FindingType,
+			RuleID:      row.RuleID,
+			Title:       row.Title,
+			Severity:    row.Severity,
+			File:        row.FilePath,
+			StartLine:   row.StartLine,
+			EndLine:     row.EndLine,
+			LicenseName: row.LicenseName,
+			Package:     row.PackageName,
+			Target:      row.Target,
+			Message:     row.Message,
+		})
+	}
+
+	response.RespondWithJSON(w, http.StatusOK, locations)
+}
+
+// RetrieveAsset lists the findings on an asset with their state there and
+// the locations the latest scan reporting each one found it at.
+func (h *Handler) RetrieveAsset(w http.ResponseWriter, r *http.Request) {
+	var assetUUID pgtype.UUID
+	if err := assetUUID.Scan(chi.URLParam(r, "assetID")); err != nil {
+		response.RespondWithError(w, r, http.StatusBadRequest, "Invalid asset_id format", err)
+		return
+	}
+
+	rootId, err := h.rootAccountID(r)
+	if err != nil {
+		response.RespondWithError(w, r, http.StatusInternalServerError, "Failed to get associated root account", err)
+		return
+	}
+
+	rows, err := h.queries.GetFindingsByAsset(r.Context(), query.GetFindingsByAssetParams{
+		AssetID:       assetUUID,
+		RootAccountID: rootId,
+	})
+	if err != nil {
+		response.RespondWithError(w, r, http.StatusInternalServerError, "Failed to retrieve asset findings", err)
+		return
+	}
+
+	findings := []AssetFinding{}
+	index := make(map[[16]byte]int)
+	for _, row := range rows {
+		i, exists := index[row.FindingDataID.Bytes]
+		if !exists {
+			i = len(findings)
+			index[row.FindingDataID.Bytes] = i
+			findings = append(findings, AssetFinding{
+				FindingUUID: response.UuidToString(row.FindingDataID),
+				Type:        row.FindingType,
+				RuleID:      row.RuleID,
+				Title:       row.Title,
+				Severity:    row.Severity,
+				State:       string(row.FindingState),
+				ChangedAt:   row.StateChangedAt.Time.Format(time.RFC3339),
+				ScanID:      response.UuidToString(row.ScanID),
+				LastSeen:    row.ScanDate.Time.Format(time.RFC3339),
+				Locations:   []Location{},
+			})
+		}
+
+		findings[i].Locations = append(findings[i].Locations, Location{
+			File:        row.FilePath,
+			StartLine:   row.StartLine,
+			EndLine:     row.EndLine,
+			LicenseName: row.LicenseName,
+			Package:     row.PackageName,
+			Target:      row.Target,
+			Message:     row.Message,
+		})
+	}
+
+	response.RespondWithJSON(w, http.StatusOK, findings)
+}
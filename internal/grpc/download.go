@@ -0,0 +1,171 @@
+package grpc
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/SyntinelNyx/syntinel-server/internal/logger"
+	"github.com/SyntinelNyx/syntinel-server/internal/proto/controlpb"
+)
+
+var ErrFileTooLarge = errors.New("file exceeds the size limit")
+
+// DownloadedFile describes a file received from an agent.
+type DownloadedFile struct {
+	Name   string
+	Size   int64
+	SHA256 string
+}
+
+// downloadStream is implemented by the direct Download RPC client and by
+// tunnel sessions so both share the same receive logic.
+type downloadStream interface {
+	Recv() (*controlpb.FileChunk, error)
+}
+
+// receive writes the file streamed by the agent to w. The size announced in
+// the first chunk and the bytes actually received are both held to maxSize,
+// and the digest the agent announced is checked once the stream ends.
+func receive(stream downloadStream, w io.Writer, maxSize int64) (DownloadedFile, error) {
+	first, err := stream.Recv()
+	if err == io.EOF {
+		return DownloadedFile{}, fmt.Errorf("agent closed the download without sending the file")
+	}
+	if err != nil {
+		return DownloadedFile{}, fmt.Errorf("failed to start download: %v", err)
+	}
+	if first.TotalSize < 0 || first.TotalSize > maxSize {
+		return DownloadedFile{}, fmt.Errorf("%w: %d bytes, limit is %d", ErrFileTooLarge, first.TotalSize, maxSize)
+	}
+
+	file := DownloadedFile{Name: first.Name, Size: first.TotalSize, SHA256: first.Sha256}
+	hash := sha256.New()
+	out := io.MultiWriter(w, hash)
+
+	var received int64
+	for chunk := first; ; {
+		if chunk.Offset != received {
+			return DownloadedFile{}, fmt.Errorf("agent sent chunk at offset %d, expected %d", chunk.Offset, received)
+		}
+		if received+int64(len(chunk.Data)) > file.Size {
+			return DownloadedFile{}, fmt.Errorf("%w: agent sent more than the announced %d bytes", ErrFileTooLarge, file.Size)
+		}
+		if _, err := out.Write(chunk.Data); err != nil {
+			return DownloadedFile{}, fmt.Errorf("failed to write downloaded file: %v", err)
+		}
+		received += int64(len(chunk.Data))
+
+		chunk, err = stream.Recv()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return DownloadedFile{}, fmt.Errorf("download interrupted at offset %d of %d: %v", received, file.Size, err)
+		}
+	}
+
+	if received != file.Size {
+		return DownloadedFile{}, fmt.Errorf("agent sent %d of %d bytes", received, file.Size)
+	}
+	if digest := hex.EncodeToString(hash.Sum(nil)); digest != file.SHA256 {
+		return DownloadedFile{}, fmt.Errorf("%w: agent announced %s, received %s", ErrDigestMismatch, file.SHA256, digest)
+	}
+
+	return file, nil
+}
+
+func (m *Manager) Download(ctx context.Context, assetID string, target string, path string, maxSize int64, w io.Writer) (DownloadedFile, error) {
+	c, err := m.acquire(ctx, assetID, target)
+	if err != nil {
+		return DownloadedFile{}, err
+	}
+	defer m.release(c)
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	logger.Info("Downloading %s from %s", path, target)
+	stream, err := c.client.Download(ctx, &controlpb.FileTransfer{Path: path, MaxSize: maxSize})
+	if err != nil {
+		m.sendFailures.Add(1)
+		return DownloadedFile{}, fmt.Errorf("failed to create download stream with agent: %v", err)
+	}
+
+	file, err := receive(stream, w, maxSize)
+	if err != nil {
+		m.sendFailures.Add(1)
+		return DownloadedFile{}, err
+	}
+
+	return file, nil
+}
+
+// Download fetches path from the agent over the tunnel. The request is sent
+// as a file_transfer command and the agent answers with chunks.
+func (s *TunnelSession) Download(ctx context.Context, path string, maxSize int64, w io.Writer) (DownloadedFile, error) {
+	requestID, p, release := s.open()
+	defer release()
+
+	cmd := &controlpb.ControlMessage{
+		Operation: &controlpb.ControlMessage_FileTransfer{FileTransfer: &controlpb.FileTransfer{Path: path, MaxSize: maxSize}},
+	}
+
+	s.sendMu.Lock()
+	logger.Info("Downloading %s from asset %s over tunnel", path, s.assetID)
+	err := s.stream.Send(&controlpb.TunnelDownlink{RequestId: requestID, Command: cmd})
+	if err == nil {
+		err = s.stream.Send(&controlpb.TunnelDownlink{RequestId: requestID, CloseSend: true})
+	}
+	s.sendMu.Unlock()
+	if err != nil {
+		return DownloadedFile{}, fmt.Errorf("failed to send download request over tunnel: %v", err)
+	}
+
+	file, err := receive(&tunnelDownloadStream{ctx: ctx, session: s, pending: p}, w, maxSize)
+	if err != nil {
+		s.cancel(requestID)
+		if ctx.Err() != nil {
+			return DownloadedFile{}, ctx.Err()
+		}
+		return DownloadedFile{}, err
+	}
+
+	return file, nil
+}
+
+type tunnelDownloadStream struct {
+	ctx     context.Context
+	session *TunnelSession
+	pending *pendingRequest
+	done    bool
+}
+
+func (t *tunnelDownloadStream) Recv() (*controlpb.FileChunk, error) {
+	if t.done {
+		return nil, io.EOF
+	}
+
+	for {
+		select {
+		case <-t.ctx.Done():
+			return nil, t.ctx.Err()
+		case <-t.session.done:
+			return nil, fmt.Errorf("tunnel closed: %v", t.session.err)
+		case resp := <-t.pending.responses:
+			if resp.Error != "" {
+				return nil, fmt.Errorf("agent reported error: %s", resp.Error)
+			}
+			if resp.Chunk != nil {
+				t.done = resp.Done
+				return resp.Chunk, nil
+			}
+			if resp.Done {
+				return nil, io.EOF
+			}
+		}
+	}
+}
@@ -0,0 +1,57 @@
+package grpc
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/SyntinelNyx/syntinel-server/internal/proto/controlpb"
+)
+
+type chunkStream []*controlpb.FileChunk
+
+func (s *chunkStream) Recv() (*controlpb.FileChunk, error) {
+	if len(*s) == 0 {
+		return nil, io.EOF
+	}
+	chunk := (*s)[0]
+	*s = (*s)[1:]
+	return chunk, nil
+}
+
+func chunksOf(data []byte, announcedDigest string, size int) *chunkStream {
+	stream := chunkStream{{Name: "syslog", TotalSize: int64(len(data)), Sha256: announcedDigest}}
+	for offset := 0; offset < len(data); offset += size {
+		end := min(offset+size, len(data))
+		stream = append(stream, &controlpb.FileChunk{Offset: int64(offset), Data: data[offset:end]})
+	}
+	return &stream
+}
+
+func TestReceive(t *testing.T) {
+	data := bytes.Repeat([]byte("log line\n"), 1000)
+	digest := sha256.Sum256(data)
+
+	var out bytes.Buffer
+	file, err := receive(chunksOf(data, hex.EncodeToString(digest[:]), 4096), &out, 1<<20)
+	require.NoError(t, err)
+	assert.Equal(t, "syslog", file.Name)
+	assert.Equal(t, int64(len(data)), file.Size)
+	assert.Equal(t, data, out.Bytes())
+
+	_, err = receive(chunksOf(data, hex.EncodeToString(digest[:]), 4096), io.Discard, 1024)
+	assert.ErrorIs(t, err, ErrFileTooLarge)
+
+	_, err = receive(chunksOf(data, "0000", 4096), io.Discard, 1<<20)
+	assert.ErrorIs(t, err, ErrDigestMismatch)
+
+	stream := chunksOf(data, hex.EncodeToString(digest[:]), 4096)
+	(*stream)[2].Offset = 0
+	_, err = receive(stream, io.Discard, 1<<20)
+	assert.ErrorContains(t, err, "expected 4096")
+}
@@ -6,11 +6,15 @@ import (
 	"crypto/x509"
 	"fmt"
 	"io"
+	"net"
 	"os"
 	"path/filepath"
 
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgtype"
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/credentials/insecure"
 
 	"github.com/SyntinelNyx/syntinel-server/internal/logger"
 	"github.com/SyntinelNyx/syntinel-server/internal/proto/controlpb"
@@ -18,6 +22,10 @@ import (
 
 var creds credentials.TransportCredentials
 
+var serverCreds credentials.TransportCredentials
+
+var manager *Manager
+
 func LoadCreds() {
 	serverCertPath := filepath.Join(os.Getenv("DATA_PATH"), "server_cert.pem")
 	serverKeyPath := filepath.Join(os.Getenv("DATA_PATH"), "server_key.pem")
@@ -36,60 +44,116 @@ func LoadCreds() {
 	caPool := x509.NewCertPool()
 	caPool.AppendCertsFromPEM(ca)
 
-	creds = credentials.NewTLS(&tls.Config{
+	clientConfig := &tls.Config{
 		Certificates: []tls.Certificate{cert},
 		RootCAs:      caPool,
+	}
+	creds = credentials.NewTLS(clientConfig)
+
+	if manager != nil {
+		manager.Close()
+	}
+	serverCreds = credentials.NewTLS(&tls.Config{
+		Certificates: []tls.Certificate{cert},
+		ClientCAs:    caPool,
+		ClientAuth:   tls.RequireAndVerifyClientCert,
 	})
+
+	manager = NewManager(ManagerConfigFromViper(), grpc.WithTransportCredentials(creds))
+	manager.agentCreds = pinnedCreds(clientConfig)
 }
 
-func Send(target string, commands []*controlpb.ControlMessage) ([]*controlpb.ControlResponse, error) {
-	conn, err := grpc.NewClient(
-		target,
-		grpc.WithTransportCredentials(creds),
-		grpc.WithDefaultCallOptions(
-			grpc.MaxCallRecvMsgSize(1024*1024*1024),
-			grpc.MaxCallSendMsgSize(1024*1024*1024),
-		),
+// UseDialer replaces the agent connection manager with one that reaches
+// agents through dial over plaintext, so tests can serve agents in-process.
+// The returned function restores the previous manager.
+func UseDialer(dial func(ctx context.Context, address string) (net.Conn, error)) func() {
+	previous := manager
+
+	config := DefaultManagerConfig()
+	config.HealthInterval = 0
+	manager = NewManager(config,
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+		grpc.WithContextDialer(dial),
 	)
-	if err != nil {
-		return nil, fmt.Errorf("failed to connect to agent: %v", err)
+
+	return func() {
+		manager.Close()
+		manager = previous
 	}
-	defer conn.Close()
+}
 
-	client := controlpb.NewAgentServiceClient(conn)
-	ctx := context.Background()
+func assetKey(assetID pgtype.UUID) string {
+	if !assetID.Valid {
+		return ""
+	}
+	return uuid.UUID(assetID.Bytes).String()
+}
 
-	stream, err := client.Control(ctx)
-	if err != nil {
-		return nil, fmt.Errorf("failed to create stream with agent: %v", err)
+func Send(ctx context.Context, assetID pgtype.UUID, target string, commands []*controlpb.ControlMessage) ([]*controlpb.ControlResponse, error) {
+	if manager == nil {
+		return nil, fmt.Errorf("agent connection manager not initialized")
+	}
+
+	return manager.Send(ctx, assetKey(assetID), target, commands)
+}
+
+func Upload(ctx context.Context, assetID pgtype.UUID, target string, file UploadFile) error {
+	if manager == nil {
+		return fmt.Errorf("agent connection manager not initialized")
+	}
+
+	return manager.Upload(ctx, assetKey(assetID), target, file)
+}
+
+func Download(ctx context.Context, assetID pgtype.UUID, target string, path string, maxSize int64, w io.Writer) (DownloadedFile, error) {
+	if manager == nil {
+		return DownloadedFile{}, fmt.Errorf("agent connection manager not initialized")
+	}
+
+	return manager.Download(ctx, assetKey(assetID), target, path, maxSize, w)
+}
+
+func Probe(ctx context.Context, assetID pgtype.UUID, target string) error {
+	if manager == nil {
+		return fmt.Errorf("agent connection manager not initialized")
+	}
+
+	return manager.Probe(ctx, assetKey(assetID), target)
+}
+
+func Handshake(ctx context.Context, assetID pgtype.UUID, target string) (*controlpb.Capabilities, error) {
+	if manager == nil {
+		return nil, fmt.Errorf("agent connection manager not initialized")
+	}
+
+	return manager.Handshake(ctx, assetKey(assetID), target)
+}
+
+func Stats() (Metrics, []ConnStats) {
+	if manager == nil {
+		return Metrics{}, []ConnStats{}
+	}
+
+	return manager.Metrics(), manager.Stats()
+}
+
+func Close() {
+	if manager != nil {
+		manager.Close()
+	}
+}
+
+// Operation names the operation in cmd without including its parameters,
+// which may contain credentials.
+func Operation(cmd *controlpb.ControlMessage) string {
+	if cmd.Command != "" {
+		return cmd.Command
 	}
 
-	go func() {
-		for _, cmd := range commands {
-			logger.Info("Sending command to %s: %s", target, cmd.Command)
-			if err := stream.Send(cmd); err != nil {
-				logger.Error("Failed to send command to agent: %v", err)
-				return
-			}
-		}
-		if err := stream.CloseSend(); err != nil {
-			logger.Error("Failed to close send stream: %v", err)
-			return
-		}
-	}()
-
-	responses := []*controlpb.ControlResponse{}
-	for {
-		res, err := stream.Recv()
-		if err == io.EOF {
-			break
-		}
-		if err != nil {
-			return nil, fmt.Errorf("failed to get response from agent: %v", err)
-		}
-		responses = append(responses, res)
-		logger.Info("Agent Response: %+v", res)
+	message := cmd.ProtoReflect()
+	if field := message.WhichOneof(message.Descriptor().Oneofs().ByName("operation")); field != nil {
+		return string(field.Name())
 	}
 
-	return responses, nil
+	return "unknown"
 }
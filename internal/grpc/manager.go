@@ -0,0 +1,448 @@
+package grpc
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/spf13/viper"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/backoff"
+	"google.golang.org/grpc/connectivity"
+	"google.golang.org/grpc/credentials"
+
+	"github.com/SyntinelNyx/syntinel-server/internal/logger"
+	"github.com/SyntinelNyx/syntinel-server/internal/proto/controlpb"
+)
+
+type ManagerConfig struct {
+	IdleTimeout       time.Duration
+	HealthInterval    time.Duration
+	UnhealthyAfter    time.Duration
+	BackoffBaseDelay  time.Duration
+	BackoffMaxDelay   time.Duration
+	MinConnectTimeout time.Duration
+}
+
+type Metrics struct {
+	Active       int    `json:"active"`
+	Dials        uint64 `json:"dials"`
+	Reuses       uint64 `json:"reuses"`
+	DialFailures uint64 `json:"dialFailures"`
+	SendFailures uint64 `json:"sendFailures"`
+	IdleEvicted  uint64 `json:"idleEvicted"`
+	Unhealthy    uint64 `json:"unhealthyEvicted"`
+}
+
+type ConnStats struct {
+	AssetID     string `json:"assetId,omitempty"`
+	Target      string `json:"target"`
+	State       string `json:"state"`
+	InFlight    int    `json:"inFlight"`
+	ConnectedAt string `json:"connectedAt"`
+	LastUsed    string `json:"lastUsed"`
+}
+
+type agentConn struct {
+	assetID      string
+	target       string
+	conn         *grpc.ClientConn
+	client       controlpb.AgentServiceClient
+	createdAt    time.Time
+	lastUsed     time.Time
+	inFlight     int
+	failingSince time.Time
+}
+
+// Manager keeps one long-lived gRPC channel per agent target so that callers
+// do not pay for a new connection and TLS handshake on every command.
+type Manager struct {
+	mu       sync.Mutex
+	conns    map[string]*agentConn
+	config   ManagerConfig
+	dialOpts []grpc.DialOption
+
+	// agentCreds returns credentials pinned to an asset's enrolled
+	// certificate, or nil for assets that predate per-agent certificates.
+	agentCreds func(ctx context.Context, assetID string) (credentials.TransportCredentials, error)
+
+	dials        atomic.Uint64
+	reuses       atomic.Uint64
+	dialFailures atomic.Uint64
+	sendFailures atomic.Uint64
+	idleEvicted  atomic.Uint64
+	unhealthy    atomic.Uint64
+
+	stop      chan struct{}
+	closeOnce sync.Once
+}
+
+func DefaultManagerConfig() ManagerConfig {
+	return ManagerConfig{
+		IdleTimeout:       10 * time.Minute,
+		HealthInterval:    30 * time.Second,
+		UnhealthyAfter:    2 * time.Minute,
+		BackoffBaseDelay:  1 * time.Second,
+		BackoffMaxDelay:   30 * time.Second,
+		MinConnectTimeout: 10 * time.Second,
+	}
+}
+
+func ManagerConfigFromViper() ManagerConfig {
+	config := DefaultManagerConfig()
+
+	durations := map[string]*time.Duration{
+		"agent.connection.idle_timeout":        &config.IdleTimeout,
+		"agent.connection.health_interval":     &config.HealthInterval,
+		"agent.connection.unhealthy_after":     &config.UnhealthyAfter,
+		"agent.connection.backoff_base_delay":  &config.BackoffBaseDelay,
+		"agent.connection.backoff_max_delay":   &config.BackoffMaxDelay,
+		"agent.connection.min_connect_timeout": &config.MinConnectTimeout,
+	}
+	for key, value := range durations {
+		if viper.IsSet(key) {
+			*value = viper.GetDuration(key)
+		}
+	}
+
+	return config
+}
+
+func NewManager(config ManagerConfig, opts ...grpc.DialOption) *Manager {
+	m := &Manager{
+		conns:  make(map[string]*agentConn),
+		config: config,
+		dialOpts: append([]grpc.DialOption{
+			grpc.WithDefaultCallOptions(
+				grpc.MaxCallRecvMsgSize(1024*1024*1024),
+				grpc.MaxCallSendMsgSize(1024*1024*1024),
+			),
+			grpc.WithConnectParams(grpc.ConnectParams{
+				Backoff: backoff.Config{
+					BaseDelay:  config.BackoffBaseDelay,
+					Multiplier: backoff.DefaultConfig.Multiplier,
+					Jitter:     backoff.DefaultConfig.Jitter,
+					MaxDelay:   config.BackoffMaxDelay,
+				},
+				MinConnectTimeout: config.MinConnectTimeout,
+			}),
+		}, opts...),
+		stop: make(chan struct{}),
+	}
+
+	if config.HealthInterval > 0 {
+		go m.run()
+	}
+
+	return m
+}
+
+// connKey scopes connections to the asset they were dialed for, since pinned
+// credentials differ per asset even when two assets share an address.
+func connKey(assetID string, target string) string {
+	if assetID == "" {
+		return target
+	}
+	return assetID + "@" + target
+}
+
+// acquire returns the pooled channel for the asset and target, dialing one
+// if there is none. Credentials are resolved before taking the lock, so a
+// slow identity lookup only holds up the caller that needs it.
+func (m *Manager) acquire(ctx context.Context, assetID string, target string) (*agentConn, error) {
+	key := connKey(assetID, target)
+	if c := m.reuse(key); c != nil {
+		return c, nil
+	}
+
+	opts := m.dialOpts
+	if assetID != "" && m.agentCreds != nil {
+		creds, err := m.agentCreds(ctx, assetID)
+		if err != nil {
+			m.dialFailures.Add(1)
+			return nil, err
+		}
+		if creds != nil {
+			opts = append(opts[:len(opts):len(opts)], grpc.WithTransportCredentials(creds), grpc.WithAuthority(assetID))
+		}
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	// Another caller may have dialed the agent while the credentials were
+	// being resolved.
+	if c := m.reuseLocked(key); c != nil {
+		return c, nil
+	}
+
+	conn, err := grpc.NewClient(target, opts...)
+	if err != nil {
+		m.dialFailures.Add(1)
+		return nil, fmt.Errorf("failed to connect to agent: %v", err)
+	}
+	m.dials.Add(1)
+
+	now := time.Now()
+	c := &agentConn{
+		assetID:   assetID,
+		target:    target,
+		conn:      conn,
+		client:    controlpb.NewAgentServiceClient(conn),
+		createdAt: now,
+		lastUsed:  now,
+		inFlight:  1,
+	}
+	m.conns[key] = c
+
+	return c, nil
+}
+
+func (m *Manager) reuse(key string) *agentConn {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	return m.reuseLocked(key)
+}
+
+func (m *Manager) reuseLocked(key string) *agentConn {
+	c, ok := m.conns[key]
+	if !ok {
+		return nil
+	}
+	if c.conn.GetState() == connectivity.Shutdown {
+		delete(m.conns, key)
+		return nil
+	}
+
+	c.inFlight++
+	c.lastUsed = time.Now()
+	m.reuses.Add(1)
+	return c
+}
+
+func (m *Manager) release(c *agentConn) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	c.inFlight--
+	c.lastUsed = time.Now()
+}
+
+// Send runs commands on one stream. Cancelling ctx cancels the stream, which
+// the agent observes as its own stream context being cancelled.
+func (m *Manager) Send(ctx context.Context, assetID string, target string, commands []*controlpb.ControlMessage) ([]*controlpb.ControlResponse, error) {
+	c, err := m.acquire(ctx, assetID, target)
+	if err != nil {
+		return nil, err
+	}
+	defer m.release(c)
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	stream, err := c.client.Control(ctx)
+	if err != nil {
+		m.sendFailures.Add(1)
+		return nil, fmt.Errorf("failed to create stream with agent: %v", err)
+	}
+
+	go func() {
+		for _, cmd := range commands {
+			logger.Info("Sending command to %s: %s", target, Operation(cmd))
+			if err := stream.Send(cmd); err != nil {
+				logger.Error("Failed to send command to agent: %v", err)
+				return
+			}
+		}
+		if err := stream.CloseSend(); err != nil {
+			logger.Error("Failed to close send stream: %v", err)
+			return
+		}
+	}()
+
+	responses := []*controlpb.ControlResponse{}
+	for {
+		res, err := stream.Recv()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			m.sendFailures.Add(1)
+			return nil, fmt.Errorf("failed to get response from agent: %v", err)
+		}
+		responses = append(responses, res)
+		logger.Info("Agent Response: %+v", res)
+	}
+
+	return responses, nil
+}
+
+// Probe waits until the channel to target is ready or ctx expires. It reuses
+// the pooled channel, so a successful probe also warms it for later commands.
+func (m *Manager) Probe(ctx context.Context, assetID string, target string) error {
+	c, err := m.acquire(ctx, assetID, target)
+	if err != nil {
+		return err
+	}
+	defer m.release(c)
+
+	for {
+		state := c.conn.GetState()
+		switch state {
+		case connectivity.Ready:
+			return nil
+		case connectivity.Idle:
+			c.conn.Connect()
+		case connectivity.Shutdown:
+			return fmt.Errorf("connection to agent was closed")
+		}
+
+		if !c.conn.WaitForStateChange(ctx, state) {
+			return fmt.Errorf("agent not reachable: connection %s", strings.ToLower(state.String()))
+		}
+	}
+}
+
+// Handshake asks the agent to report its capabilities.
+func (m *Manager) Handshake(ctx context.Context, assetID string, target string) (*controlpb.Capabilities, error) {
+	c, err := m.acquire(ctx, assetID, target)
+	if err != nil {
+		return nil, err
+	}
+	defer m.release(c)
+
+	capabilities, err := c.client.Handshake(ctx, &controlpb.HandshakeRequest{})
+	if err != nil {
+		return nil, err
+	}
+
+	return capabilities, nil
+}
+
+func (m *Manager) run() {
+	ticker := time.NewTicker(m.config.HealthInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-m.stop:
+			return
+		case <-ticker.C:
+			m.sweep(time.Now())
+		}
+	}
+}
+
+// sweep closes channels that have been idle for longer than IdleTimeout and
+// channels that have been failing to reconnect for longer than UnhealthyAfter,
+// so the next command to that agent starts from a fresh dial.
+func (m *Manager) sweep(now time.Time) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for key, c := range m.conns {
+		target := c.target
+		state := c.conn.GetState()
+
+		switch state {
+		case connectivity.TransientFailure:
+			if c.failingSince.IsZero() {
+				c.failingSince = now
+			}
+		case connectivity.Idle:
+			c.failingSince = time.Time{}
+			if c.inFlight == 0 && now.Sub(c.lastUsed) < m.config.IdleTimeout {
+				c.conn.Connect()
+			}
+		default:
+			c.failingSince = time.Time{}
+		}
+
+		if c.inFlight > 0 {
+			continue
+		}
+
+		switch {
+		case state == connectivity.Shutdown:
+			delete(m.conns, key)
+		case m.config.IdleTimeout > 0 && now.Sub(c.lastUsed) >= m.config.IdleTimeout:
+			logger.Info("Closing idle agent connection to %s", target)
+			c.conn.Close()
+			delete(m.conns, key)
+			m.idleEvicted.Add(1)
+		case !c.failingSince.IsZero() && now.Sub(c.failingSince) >= m.config.UnhealthyAfter:
+			logger.Warn("Closing unhealthy agent connection to %s after %s in %s", target, now.Sub(c.failingSince).Round(time.Second), state)
+			c.conn.Close()
+			delete(m.conns, key)
+			m.unhealthy.Add(1)
+		}
+	}
+}
+
+// Disconnect closes every connection dialed for assetID, for example after
+// its certificate has been revoked.
+func (m *Manager) Disconnect(assetID string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for key, c := range m.conns {
+		if c.assetID == assetID {
+			c.conn.Close()
+			delete(m.conns, key)
+		}
+	}
+}
+
+func (m *Manager) Metrics() Metrics {
+	m.mu.Lock()
+	active := len(m.conns)
+	m.mu.Unlock()
+
+	return Metrics{
+		Active:       active,
+		Dials:        m.dials.Load(),
+		Reuses:       m.reuses.Load(),
+		DialFailures: m.dialFailures.Load(),
+		SendFailures: m.sendFailures.Load(),
+		IdleEvicted:  m.idleEvicted.Load(),
+		Unhealthy:    m.unhealthy.Load(),
+	}
+}
+
+func (m *Manager) Stats() []ConnStats {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	stats := []ConnStats{}
+	for _, c := range m.conns {
+		stats = append(stats, ConnStats{
+			AssetID:     c.assetID,
+			Target:      c.target,
+			State:       c.conn.GetState().String(),
+			InFlight:    c.inFlight,
+			ConnectedAt: c.createdAt.Format(time.RFC3339),
+			LastUsed:    c.lastUsed.Format(time.RFC3339),
+		})
+	}
+
+	return stats
+}
+
+func (m *Manager) Close() {
+	m.closeOnce.Do(func() {
+		close(m.stop)
+
+		m.mu.Lock()
+		defer m.mu.Unlock()
+
+		for target, c := range m.conns {
+			c.conn.Close()
+			delete(m.conns, target)
+		}
+	})
+}
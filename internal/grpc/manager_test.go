@@ -0,0 +1,189 @@
+package grpc
+
+import (
+	"context"
+	"io"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/test/bufconn"
+
+	"github.com/SyntinelNyx/syntinel-server/internal/proto/controlpb"
+)
+
+type echoAgent struct {
+	controlpb.UnimplementedAgentServiceServer
+}
+
+func (echoAgent) Control(stream controlpb.AgentService_ControlServer) error {
+	for {
+		msg, err := stream.Recv()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if err := stream.Send(&controlpb.ControlResponse{Result: msg.Payload, Status: "success"}); err != nil {
+			return err
+		}
+	}
+}
+
+func newTestManager(t *testing.T, config ManagerConfig) (*Manager, *int) {
+	return newTestManagerFor(t, config, echoAgent{})
+}
+
+func newTestManagerFor(t *testing.T, config ManagerConfig, agent controlpb.AgentServiceServer) (*Manager, *int) {
+	listener := bufconn.Listen(1024 * 1024)
+	server := grpc.NewServer()
+	controlpb.RegisterAgentServiceServer(server, agent)
+	go server.Serve(listener)
+	t.Cleanup(server.Stop)
+
+	handshakes := 0
+	m := NewManager(config,
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) {
+			handshakes++
+			return listener.DialContext(ctx)
+		}),
+	)
+	t.Cleanup(m.Close)
+
+	return m, &handshakes
+}
+
+func TestManagerReusesConnection(t *testing.T) {
+	config := DefaultManagerConfig()
+	config.HealthInterval = 0
+	m, handshakes := newTestManager(t, config)
+
+	for i := 0; i < 5; i++ {
+		responses, err := m.Send(context.Background(), "", "passthrough:///agent", []*controlpb.ControlMessage{{Command: "exec", Payload: "hostname"}})
+		require.NoError(t, err)
+		require.Len(t, responses, 1)
+		assert.Equal(t, "hostname", responses[0].Result)
+	}
+
+	metrics := m.Metrics()
+	assert.Equal(t, 1, metrics.Active)
+	assert.Equal(t, uint64(1), metrics.Dials)
+	assert.Equal(t, uint64(4), metrics.Reuses)
+	assert.Equal(t, 1, *handshakes)
+}
+
+func TestManagerEvictsIdleConnection(t *testing.T) {
+	config := DefaultManagerConfig()
+	config.HealthInterval = 0
+	config.IdleTimeout = time.Minute
+	m, _ := newTestManager(t, config)
+
+	_, err := m.Send(context.Background(), "", "passthrough:///agent", []*controlpb.ControlMessage{{Command: "exec", Payload: "uptime"}})
+	require.NoError(t, err)
+
+	m.sweep(time.Now())
+	assert.Equal(t, 1, m.Metrics().Active)
+
+	m.sweep(time.Now().Add(2 * time.Minute))
+	metrics := m.Metrics()
+	assert.Equal(t, 0, metrics.Active)
+	assert.Equal(t, uint64(1), metrics.IdleEvicted)
+
+	_, err = m.Send(context.Background(), "", "passthrough:///agent", []*controlpb.ControlMessage{{Command: "exec", Payload: "uptime"}})
+	require.NoError(t, err)
+	assert.Equal(t, uint64(2), m.Metrics().Dials)
+}
+
+func TestManagerProbe(t *testing.T) {
+	config := DefaultManagerConfig()
+	config.HealthInterval = 0
+	m, _ := newTestManager(t, config)
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	require.NoError(t, m.Probe(ctx, "", "passthrough:///agent"))
+
+	unreachable := NewManager(config,
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) {
+			return nil, io.ErrClosedPipe
+		}),
+	)
+	t.Cleanup(unreachable.Close)
+
+	ctx, cancel = context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	assert.Error(t, unreachable.Probe(ctx, "", "passthrough:///agent"))
+}
+
+type hangingAgent struct {
+	controlpb.UnimplementedAgentServiceServer
+	cancelled chan struct{}
+}
+
+func (a hangingAgent) Control(stream controlpb.AgentService_ControlServer) error {
+	<-stream.Context().Done()
+	close(a.cancelled)
+	return stream.Context().Err()
+}
+
+func TestManagerSendHonoursDeadline(t *testing.T) {
+	config := DefaultManagerConfig()
+	config.HealthInterval = 0
+	agent := hangingAgent{cancelled: make(chan struct{})}
+	m, _ := newTestManagerFor(t, config, agent)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+
+	start := time.Now()
+	_, err := m.Send(ctx, "", "passthrough:///agent", []*controlpb.ControlMessage{{Command: "exec", Payload: "sleep 60"}})
+	require.Error(t, err)
+	assert.ErrorIs(t, ctx.Err(), context.DeadlineExceeded)
+	assert.Less(t, time.Since(start), 5*time.Second)
+
+	select {
+	case <-agent.cancelled:
+	case <-time.After(5 * time.Second):
+		t.Fatal("agent stream was not cancelled")
+	}
+}
+
+func TestManagerResolvesCredentialsOutsideLock(t *testing.T) {
+	config := DefaultManagerConfig()
+	config.HealthInterval = 0
+	m, _ := newTestManager(t, config)
+
+	lookupStarted := make(chan struct{})
+	m.agentCreds = func(ctx context.Context, assetID string) (credentials.TransportCredentials, error) {
+		if assetID == "slow" {
+			close(lookupStarted)
+			<-ctx.Done()
+			return nil, ctx.Err()
+		}
+		return nil, nil
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	slow := make(chan error, 1)
+	go func() {
+		_, err := m.Send(ctx, "slow", "passthrough:///agent", []*controlpb.ControlMessage{{Command: "exec", Payload: "id"}})
+		slow <- err
+	}()
+	<-lookupStarted
+
+	// Other agents and the health sweep are not held up by the lookup.
+	_, err := m.Send(context.Background(), "fast", "passthrough:///agent", []*controlpb.ControlMessage{{Command: "exec", Payload: "id"}})
+	require.NoError(t, err)
+	m.sweep(time.Now())
+
+	cancel()
+	assert.ErrorIs(t, <-slow, context.Canceled)
+}
@@ -0,0 +1,158 @@
+package grpc
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgtype"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/peer"
+
+	"github.com/SyntinelNyx/syntinel-server/internal/logger"
+	"github.com/SyntinelNyx/syntinel-server/internal/pki"
+)
+
+var ErrCertificateRevoked = errors.New("agent certificate has been revoked")
+
+// handshakeLookupTimeout bounds the identity lookup made during a TLS
+// handshake, which has no caller context to follow.
+const handshakeLookupTimeout = 10 * time.Second
+
+// Identity is the certificate an asset was issued at enrollment.
+type Identity struct {
+	Fingerprint string
+	Revoked     bool
+}
+
+// IdentityLookup returns the pinned identity of an asset. ok is false for
+// assets enrolled before per-agent certificates were issued, which are only
+// contacted when pki.LegacyAgents allows it.
+type IdentityLookup func(ctx context.Context, assetID pgtype.UUID) (identity Identity, ok bool, err error)
+
+var lookupIdentity IdentityLookup
+
+// SetIdentityLookup enables certificate pinning for direct connections and
+// tunnels. It must be called before agents are contacted.
+func SetIdentityLookup(lookup IdentityLookup) {
+	lookupIdentity = lookup
+}
+
+func identityFor(ctx context.Context, assetID string) (Identity, bool, error) {
+	var id pgtype.UUID
+	if err := id.Scan(assetID); err != nil {
+		return Identity{}, false, fmt.Errorf("invalid asset id %q: %v", assetID, err)
+	}
+
+	return lookupIdentity(ctx, id)
+}
+
+func checkPinned(identity Identity, certs []*x509.Certificate) error {
+	if identity.Revoked {
+		return ErrCertificateRevoked
+	}
+	if len(certs) == 0 {
+		return fmt.Errorf("agent did not present a certificate")
+	}
+	if pki.Fingerprint(certs[0].Raw) != identity.Fingerprint {
+		return fmt.Errorf("agent certificate does not match the one issued at enrollment")
+	}
+
+	return nil
+}
+
+// pinnedCreds returns credentials that dial the agent with its asset ID as
+// the TLS server name and only accept the certificate recorded for it. The
+// identity is looked up again on every handshake so that revocation also
+// applies to reconnects of an existing channel.
+func pinnedCreds(base *tls.Config) func(ctx context.Context, assetID string) (credentials.TransportCredentials, error) {
+	return func(ctx context.Context, assetID string) (credentials.TransportCredentials, error) {
+		if lookupIdentity == nil {
+			return nil, fmt.Errorf("agent certificate pinning is not configured")
+		}
+
+		identity, ok, err := identityFor(ctx, assetID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to look up agent certificate: %v", err)
+		}
+		if !ok {
+			if !pki.LegacyAgents() {
+				return nil, fmt.Errorf("asset %s has no enrolled certificate", assetID)
+			}
+			logger.Warn("Asset %s has no enrolled certificate, connecting without pinning because legacy agents are allowed", assetID)
+			return nil, nil
+		}
+		if identity.Revoked {
+			return nil, ErrCertificateRevoked
+		}
+
+		config := base.Clone()
+		config.ServerName = assetID
+		config.VerifyConnection = func(cs tls.ConnectionState) error {
+			ctx, cancel := context.WithTimeout(context.Background(), handshakeLookupTimeout)
+			defer cancel()
+
+			identity, ok, err := identityFor(ctx, assetID)
+			if err != nil {
+				return fmt.Errorf("failed to look up agent certificate: %v", err)
+			}
+			if !ok {
+				return fmt.Errorf("asset %s has no enrolled certificate", assetID)
+			}
+			return checkPinned(identity, cs.PeerCertificates)
+		}
+
+		return credentials.NewTLS(config), nil
+	}
+}
+
+// verifyTunnelPeer checks that an agent opened its tunnel with a client
+// certificate issued by the CA for the asset it claims to be, and that the
+// certificate is the one pinned for the asset.
+func verifyTunnelPeer(ctx context.Context, assetID pgtype.UUID) error {
+	var state tls.ConnectionState
+	if p, found := peer.FromContext(ctx); found {
+		if info, isTLS := p.AuthInfo.(credentials.TLSInfo); isTLS {
+			state = info.State
+		}
+	}
+	if len(state.VerifiedChains) == 0 || len(state.PeerCertificates) == 0 {
+		return fmt.Errorf("agent did not present a verified certificate")
+	}
+	if err := state.PeerCertificates[0].VerifyHostname(assetKey(assetID)); err != nil {
+		return fmt.Errorf("agent certificate was not issued for asset %s", assetKey(assetID))
+	}
+
+	if lookupIdentity == nil {
+		return fmt.Errorf("agent certificate pinning is not configured")
+	}
+
+	identity, ok, err := lookupIdentity(ctx, assetID)
+	if err != nil {
+		return fmt.Errorf("failed to look up agent certificate: %v", err)
+	}
+	if !ok {
+		if !pki.LegacyAgents() {
+			return fmt.Errorf("asset %s has no enrolled certificate", assetKey(assetID))
+		}
+		logger.Warn("Asset %s has no enrolled certificate, accepting its tunnel without pinning because legacy agents are allowed", assetKey(assetID))
+		return nil
+	}
+
+	return checkPinned(identity, state.PeerCertificates)
+}
+
+// Disconnect drops every open connection and tunnel for an asset.
+func Disconnect(assetID pgtype.UUID) {
+	if !assetID.Valid {
+		return
+	}
+
+	if manager != nil {
+		manager.Disconnect(assetKey(assetID))
+	}
+	Tunnels.disconnect(assetID)
+}
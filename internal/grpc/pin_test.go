@@ -0,0 +1,186 @@
+package grpc
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/test/bufconn"
+
+	"github.com/SyntinelNyx/syntinel-server/internal/pki"
+	"github.com/SyntinelNyx/syntinel-server/internal/proto/controlpb"
+)
+
+// issueAgentCert creates a throwaway CA and signs an agent certificate for
+// testAssetID with it.
+func issueAgentCert(t *testing.T) (*x509.CertPool, tls.Certificate, string) {
+	ca, roots := newTestCA(t)
+	cert, fingerprint := signAgentCert(t, ca, testAssetID)
+
+	return roots, cert, fingerprint
+}
+
+func newTestCA(t *testing.T) (*pki.CA, *x509.CertPool) {
+	caKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	caTemplate := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "test-ca"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+		KeyUsage:              x509.KeyUsageCertSign,
+	}
+	caDER, err := x509.CreateCertificate(rand.Reader, caTemplate, caTemplate, &caKey.PublicKey, caKey)
+	require.NoError(t, err)
+	caKeyDER, err := x509.MarshalPKCS8PrivateKey(caKey)
+	require.NoError(t, err)
+
+	ca, err := pki.NewCA(
+		pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: caDER}),
+		pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: caKeyDER}),
+	)
+	require.NoError(t, err)
+
+	roots := x509.NewCertPool()
+	roots.AddCert(ca.Cert)
+
+	return ca, roots
+}
+
+func signAgentCert(t *testing.T, ca *pki.CA, assetID string) (tls.Certificate, string) {
+	agentKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	csrDER, err := x509.CreateCertificateRequest(rand.Reader, &x509.CertificateRequest{}, agentKey)
+	require.NoError(t, err)
+
+	issued, err := ca.Sign(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE REQUEST", Bytes: csrDER}), assetID, time.Hour)
+	require.NoError(t, err)
+
+	agentKeyDER, err := x509.MarshalECPrivateKey(agentKey)
+	require.NoError(t, err)
+	cert, err := tls.X509KeyPair(issued.CertPEM, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: agentKeyDER}))
+	require.NoError(t, err)
+
+	return cert, issued.Fingerprint
+}
+
+func TestPinnedConnection(t *testing.T) {
+	roots, agentCert, fingerprint := issueAgentCert(t)
+
+	listener := bufconn.Listen(1024 * 1024)
+	server := grpc.NewServer(grpc.Creds(credentials.NewTLS(&tls.Config{Certificates: []tls.Certificate{agentCert}})))
+	controlpb.RegisterAgentServiceServer(server, echoAgent{})
+	go server.Serve(listener)
+	t.Cleanup(server.Stop)
+
+	identity := Identity{Fingerprint: fingerprint}
+	SetIdentityLookup(func(ctx context.Context, assetID pgtype.UUID) (Identity, bool, error) {
+		return identity, true, nil
+	})
+	t.Cleanup(func() { SetIdentityLookup(nil) })
+
+	config := DefaultManagerConfig()
+	config.HealthInterval = 0
+	m := NewManager(config,
+		grpc.WithTransportCredentials(credentials.NewTLS(&tls.Config{RootCAs: roots})),
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) {
+			return listener.DialContext(ctx)
+		}),
+	)
+	m.agentCreds = pinnedCreds(&tls.Config{RootCAs: roots})
+	t.Cleanup(m.Close)
+
+	commands := []*controlpb.ControlMessage{{Command: "exec", Payload: "id"}}
+
+	responses, err := m.Send(context.Background(), testAssetID, "passthrough:///agent", commands)
+	require.NoError(t, err)
+	assert.Equal(t, "id", responses[0].Result)
+
+	m.Disconnect(testAssetID)
+	identity.Fingerprint = "0000"
+	_, err = m.Send(context.Background(), testAssetID, "passthrough:///agent", commands)
+	assert.Error(t, err)
+
+	m.Disconnect(testAssetID)
+	identity = Identity{Fingerprint: fingerprint, Revoked: true}
+	_, err = m.Send(context.Background(), testAssetID, "passthrough:///agent", commands)
+	assert.ErrorIs(t, err, ErrCertificateRevoked)
+}
+
+func TestUnpinnedAssetRequiresLegacyMode(t *testing.T) {
+	SetIdentityLookup(func(ctx context.Context, assetID pgtype.UUID) (Identity, bool, error) {
+		return Identity{}, false, nil
+	})
+	t.Cleanup(func() { SetIdentityLookup(nil) })
+
+	creds, err := pinnedCreds(&tls.Config{})(context.Background(), testAssetID)
+	assert.Error(t, err)
+	assert.Nil(t, creds)
+
+	viper.Set("agent.legacy_agents", true)
+	t.Cleanup(func() { viper.Set("agent.legacy_agents", false) })
+
+	creds, err = pinnedCreds(&tls.Config{})(context.Background(), testAssetID)
+	assert.NoError(t, err)
+	assert.Nil(t, creds)
+}
+
+func TestReenrolledAgentConnectsAfterRevocation(t *testing.T) {
+	ca, roots := newTestCA(t)
+	agentCert, fingerprint := signAgentCert(t, ca, testAssetID)
+
+	listener := bufconn.Listen(1024 * 1024)
+	server := grpc.NewServer(grpc.Creds(credentials.NewTLS(&tls.Config{
+		GetCertificate: func(*tls.ClientHelloInfo) (*tls.Certificate, error) { return &agentCert, nil },
+	})))
+	controlpb.RegisterAgentServiceServer(server, echoAgent{})
+	go server.Serve(listener)
+	t.Cleanup(server.Stop)
+
+	identity := Identity{Fingerprint: fingerprint, Revoked: true}
+	SetIdentityLookup(func(ctx context.Context, assetID pgtype.UUID) (Identity, bool, error) {
+		return identity, true, nil
+	})
+	t.Cleanup(func() { SetIdentityLookup(nil) })
+
+	config := DefaultManagerConfig()
+	config.HealthInterval = 0
+	m := NewManager(config,
+		grpc.WithTransportCredentials(credentials.NewTLS(&tls.Config{RootCAs: roots})),
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) {
+			return listener.DialContext(ctx)
+		}),
+	)
+	m.agentCreds = pinnedCreds(&tls.Config{RootCAs: roots})
+	t.Cleanup(m.Close)
+
+	commands := []*controlpb.ControlMessage{{Command: "exec", Payload: "id"}}
+
+	_, err := m.Send(context.Background(), testAssetID, "passthrough:///agent", commands)
+	assert.ErrorIs(t, err, ErrCertificateRevoked)
+
+	// Enrolling again issues a new certificate and clears the revocation.
+	agentCert, fingerprint = signAgentCert(t, ca, testAssetID)
+	identity = Identity{Fingerprint: fingerprint}
+
+	responses, err := m.Send(context.Background(), testAssetID, "passthrough:///agent", commands)
+	require.NoError(t, err)
+	assert.Equal(t, "id", responses[0].Result)
+}
@@ -0,0 +1,413 @@
+package grpc
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgtype"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/SyntinelNyx/syntinel-server/internal/logger"
+	"github.com/SyntinelNyx/syntinel-server/internal/proto/controlpb"
+)
+
+// AssetVerifier is called with the asset ID from a TunnelHello before the
+// session is accepted.
+type AssetVerifier func(ctx context.Context, assetID pgtype.UUID) error
+
+type TunnelRegistry struct {
+	mu       sync.RWMutex
+	sessions map[string]*TunnelSession
+	onOpen   []func(assetID pgtype.UUID)
+}
+
+type TunnelSession struct {
+	assetID      string
+	agentVersion string
+	capabilities *controlpb.Capabilities
+	connectedAt  time.Time
+	stream       controlpb.TunnelService_TunnelServer
+
+	sendMu sync.Mutex
+
+	mu      sync.Mutex
+	pending map[string]*pendingRequest
+
+	done chan struct{}
+	err  error
+}
+
+type pendingRequest struct {
+	responses chan *controlpb.TunnelResponse
+	abandoned chan struct{}
+}
+
+type TunnelServer struct {
+	controlpb.UnimplementedTunnelServiceServer
+	registry *TunnelRegistry
+	verify   AssetVerifier
+}
+
+var Tunnels = NewTunnelRegistry()
+
+func NewTunnelRegistry() *TunnelRegistry {
+	return &TunnelRegistry{sessions: make(map[string]*TunnelSession)}
+}
+
+func (r *TunnelRegistry) Get(assetID pgtype.UUID) *TunnelSession {
+	if !assetID.Valid {
+		return nil
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	return r.sessions[uuid.UUID(assetID.Bytes).String()]
+}
+
+// register adds s to the registry unless the asset already has a live
+// session, so a second client cannot take over the commands of an asset.
+func (r *TunnelRegistry) register(s *TunnelSession) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if previous := r.sessions[s.assetID]; previous != nil && !previous.closed() {
+		return fmt.Errorf("asset %s already has an open tunnel", s.assetID)
+	}
+	r.sessions[s.assetID] = s
+
+	return nil
+}
+
+// OnOpen registers fn to be called whenever an agent opens a tunnel. fn runs
+// on the tunnel's goroutine and should not block.
+func (r *TunnelRegistry) OnOpen(fn func(assetID pgtype.UUID)) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.onOpen = append(r.onOpen, fn)
+}
+
+func (r *TunnelRegistry) opened(assetID pgtype.UUID) {
+	r.mu.RLock()
+	hooks := r.onOpen
+	r.mu.RUnlock()
+
+	for _, fn := range hooks {
+		fn(assetID)
+	}
+}
+
+func (r *TunnelRegistry) unregister(s *TunnelSession) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.sessions[s.assetID] == s {
+		delete(r.sessions, s.assetID)
+	}
+}
+
+func (r *TunnelRegistry) disconnect(assetID pgtype.UUID) {
+	if session := r.Get(assetID); session != nil {
+		session.close(fmt.Errorf("asset disconnected by server"))
+	}
+}
+
+func (r *TunnelRegistry) Count() int {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	return len(r.sessions)
+}
+
+func NewTunnelServer(registry *TunnelRegistry, verify AssetVerifier) *TunnelServer {
+	return &TunnelServer{registry: registry, verify: verify}
+}
+
+func (t *TunnelServer) Tunnel(stream controlpb.TunnelService_TunnelServer) error {
+	first, err := stream.Recv()
+	if err != nil {
+		return err
+	}
+
+	hello := first.GetHello()
+	if hello == nil {
+		return status.Error(codes.InvalidArgument, "first tunnel message must be a hello")
+	}
+
+	var assetID pgtype.UUID
+	if err := assetID.Scan(hello.AssetId); err != nil {
+		return status.Errorf(codes.InvalidArgument, "invalid asset id: %v", err)
+	}
+
+	if t.verify != nil {
+		if err := t.verify(stream.Context(), assetID); err != nil {
+			return status.Errorf(codes.PermissionDenied, "asset not recognised: %v", err)
+		}
+	}
+
+	if err := verifyTunnelPeer(stream.Context(), assetID); err != nil {
+		return status.Errorf(codes.PermissionDenied, "agent certificate rejected: %v", err)
+	}
+
+	session := &TunnelSession{
+		assetID:      uuid.UUID(assetID.Bytes).String(),
+		agentVersion: hello.AgentVersion,
+		capabilities: hello.Capabilities,
+		connectedAt:  time.Now(),
+		stream:       stream,
+		pending:      make(map[string]*pendingRequest),
+		done:         make(chan struct{}),
+	}
+
+	if err := t.registry.register(session); err != nil {
+		return status.Error(codes.AlreadyExists, err.Error())
+	}
+	defer t.registry.unregister(session)
+	logger.Info("Agent tunnel opened for asset %s", session.assetID)
+	t.registry.opened(assetID)
+
+	go func() {
+		for {
+			msg, err := stream.Recv()
+			if err != nil {
+				session.close(fmt.Errorf("tunnel closed: %v", err))
+				return
+			}
+
+			if resp := msg.GetResponse(); resp != nil {
+				session.dispatch(resp)
+			}
+		}
+	}()
+
+	<-session.done
+	logger.Info("Agent tunnel closed for asset %s: %v", session.assetID, session.err)
+
+	return nil
+}
+
+func (s *TunnelSession) dispatch(resp *controlpb.TunnelResponse) {
+	s.mu.Lock()
+	p, ok := s.pending[resp.RequestId]
+	s.mu.Unlock()
+
+	if !ok {
+		logger.Warn("Dropping tunnel response for unknown request %s", resp.RequestId)
+		return
+	}
+
+	select {
+	case p.responses <- resp:
+	case <-p.abandoned:
+	case <-s.done:
+	}
+}
+
+func (s *TunnelSession) closed() bool {
+	select {
+	case <-s.done:
+		return true
+	default:
+		return false
+	}
+}
+
+func (s *TunnelSession) close(err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	select {
+	case <-s.done:
+	default:
+		s.err = err
+		close(s.done)
+	}
+}
+
+// open registers a new request on the session. The returned function must
+// be called once the caller stops reading responses.
+func (s *TunnelSession) open() (string, *pendingRequest, func()) {
+	requestID := uuid.NewString()
+	p := &pendingRequest{
+		responses: make(chan *controlpb.TunnelResponse, 16),
+		abandoned: make(chan struct{}),
+	}
+
+	s.mu.Lock()
+	s.pending[requestID] = p
+	s.mu.Unlock()
+
+	return requestID, p, func() {
+		s.mu.Lock()
+		delete(s.pending, requestID)
+		s.mu.Unlock()
+		close(p.abandoned)
+	}
+}
+
+// Capabilities returns what the agent reported when it opened the tunnel,
+// or nil for agents that predate the handshake.
+func (s *TunnelSession) Capabilities() *controlpb.Capabilities {
+	return s.capabilities
+}
+
+// Send forwards commands over the tunnel and collects the agent's responses.
+// If ctx ends first the agent is told to cancel the request.
+func (s *TunnelSession) Send(ctx context.Context, commands []*controlpb.ControlMessage) ([]*controlpb.ControlResponse, error) {
+	requestID, p, release := s.open()
+	defer release()
+
+	downlinks := make([]*controlpb.TunnelDownlink, 0, len(commands)+1)
+	for _, cmd := range commands {
+		downlinks = append(downlinks, &controlpb.TunnelDownlink{RequestId: requestID, Command: cmd})
+	}
+	downlinks = append(downlinks, &controlpb.TunnelDownlink{RequestId: requestID, CloseSend: true})
+
+	s.sendMu.Lock()
+	for _, downlink := range downlinks {
+		if downlink.Command != nil {
+			logger.Info("Sending command to asset %s over tunnel: %s", s.assetID, Operation(downlink.Command))
+		}
+		if err := s.stream.Send(downlink); err != nil {
+			s.sendMu.Unlock()
+			return nil, fmt.Errorf("failed to send command over tunnel: %v", err)
+		}
+	}
+	s.sendMu.Unlock()
+
+	responses := []*controlpb.ControlResponse{}
+	for {
+		select {
+		case <-ctx.Done():
+			s.cancel(requestID)
+			return nil, ctx.Err()
+		case <-s.done:
+			return nil, fmt.Errorf("failed to get response from agent: %v", s.err)
+		case resp := <-p.responses:
+			if resp.Error != "" {
+				return nil, fmt.Errorf("agent reported error: %s", resp.Error)
+			}
+			if resp.Response != nil {
+				responses = append(responses, resp.Response)
+				logger.Info("Agent Response: %+v", resp.Response)
+			}
+			if resp.Done {
+				return responses, nil
+			}
+		}
+	}
+}
+
+// Upload transfers file to the agent over the tunnel using the same
+// offset and digest handshake as the direct Upload RPC.
+func (s *TunnelSession) Upload(ctx context.Context, file UploadFile) error {
+	requestID, p, release := s.open()
+	defer release()
+
+	err := transfer(ctx, &tunnelUploadStream{ctx: ctx, session: s, requestID: requestID, pending: p}, file)
+	if ctx.Err() != nil {
+		s.cancel(requestID)
+		return ctx.Err()
+	}
+	return err
+}
+
+// cancel tells the agent to stop working on requestID. Errors are ignored
+// because the caller has already given up on the request.
+func (s *TunnelSession) cancel(requestID string) {
+	s.sendMu.Lock()
+	defer s.sendMu.Unlock()
+
+	if err := s.stream.Send(&controlpb.TunnelDownlink{RequestId: requestID, Cancel: true}); err != nil {
+		logger.Warn("Failed to cancel tunnel request %s for asset %s: %v", requestID, s.assetID, err)
+	}
+}
+
+type tunnelUploadStream struct {
+	ctx       context.Context
+	session   *TunnelSession
+	requestID string
+	pending   *pendingRequest
+}
+
+func (t *tunnelUploadStream) Send(chunk *controlpb.FileChunk) error {
+	if err := t.ctx.Err(); err != nil {
+		return err
+	}
+
+	t.session.sendMu.Lock()
+	defer t.session.sendMu.Unlock()
+
+	return t.session.stream.Send(&controlpb.TunnelDownlink{RequestId: t.requestID, Chunk: chunk})
+}
+
+func (t *tunnelUploadStream) Recv() (*controlpb.UploadAck, error) {
+	for {
+		select {
+		case <-t.ctx.Done():
+			return nil, t.ctx.Err()
+		case <-t.session.done:
+			return nil, fmt.Errorf("tunnel closed: %v", t.session.err)
+		case resp := <-t.pending.responses:
+			if resp.Error != "" {
+				return nil, fmt.Errorf("agent reported error: %s", resp.Error)
+			}
+			if resp.Ack != nil {
+				return resp.Ack, nil
+			}
+			if resp.Done {
+				return nil, io.EOF
+			}
+		}
+	}
+}
+
+// CloseSend is a no-op because the agent knows the transfer is finished once
+// it has received total_size bytes.
+func (t *tunnelUploadStream) CloseSend() error {
+	return nil
+}
+
+func (s *TunnelSession) ConnectedAt() time.Time {
+	return s.connectedAt
+}
+
+func (s *TunnelSession) AgentVersion() string {
+	return s.agentVersion
+}
+
+// ServeTunnel starts the TunnelService on address using the credentials
+// loaded by LoadCreds. The returned server should be stopped on shutdown.
+func ServeTunnel(address string, verify AssetVerifier) (*grpc.Server, error) {
+	if serverCreds == nil {
+		return nil, fmt.Errorf("gRPC credentials not loaded")
+	}
+
+	listener, err := net.Listen("tcp", address)
+	if err != nil {
+		return nil, fmt.Errorf("failed to listen on %s: %v", address, err)
+	}
+
+	server := grpc.NewServer(
+		grpc.Creds(serverCreds),
+		grpc.MaxRecvMsgSize(1024*1024*1024),
+		grpc.MaxSendMsgSize(1024*1024*1024),
+	)
+	controlpb.RegisterTunnelServiceServer(server, NewTunnelServer(Tunnels, verify))
+
+	go func() {
+		if err := server.Serve(listener); err != nil {
+			logger.Error("Agent tunnel server stopped: %v", err)
+		}
+	}()
+
+	return server, nil
+}
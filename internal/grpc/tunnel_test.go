@@ -0,0 +1,186 @@
+package grpc
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+
+	"github.com/SyntinelNyx/syntinel-server/internal/proto/controlpb"
+)
+
+const testAssetID = "4f1d2a3b-5c6d-4e7f-8a9b-0c1d2e3f4a5b"
+
+// startTunnelServer serves the tunnel over TLS with client certificates
+// required, as ServeTunnel does. The returned client presents a certificate
+// issued for clientAssetID.
+func startTunnelServer(t *testing.T, registry *TunnelRegistry, verify AssetVerifier, clientAssetID string) controlpb.TunnelServiceClient {
+	ca, roots := newTestCA(t)
+	serverCert, _ := signAgentCert(t, ca, "syntinel-server")
+	clientCert, fingerprint := signAgentCert(t, ca, clientAssetID)
+
+	SetIdentityLookup(func(ctx context.Context, assetID pgtype.UUID) (Identity, bool, error) {
+		return Identity{Fingerprint: fingerprint}, true, nil
+	})
+	t.Cleanup(func() { SetIdentityLookup(nil) })
+
+	listener := bufconn.Listen(1024 * 1024)
+	server := grpc.NewServer(grpc.Creds(credentials.NewTLS(&tls.Config{
+		Certificates: []tls.Certificate{serverCert},
+		ClientCAs:    roots,
+		ClientAuth:   tls.RequireAndVerifyClientCert,
+	})))
+	controlpb.RegisterTunnelServiceServer(server, NewTunnelServer(registry, verify))
+	go server.Serve(listener)
+	t.Cleanup(server.Stop)
+
+	conn, err := grpc.NewClient("passthrough:///tunnel",
+		grpc.WithTransportCredentials(credentials.NewTLS(&tls.Config{
+			Certificates: []tls.Certificate{clientCert},
+			RootCAs:      roots,
+			ServerName:   "syntinel-server",
+		})),
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) {
+			return listener.DialContext(ctx)
+		}),
+	)
+	require.NoError(t, err)
+	t.Cleanup(func() { conn.Close() })
+
+	return controlpb.NewTunnelServiceClient(conn)
+}
+
+// runFakeAgent opens a tunnel and answers every command by echoing its payload.
+func runFakeAgent(t *testing.T, ctx context.Context, client controlpb.TunnelServiceClient) {
+	stream, err := client.Tunnel(ctx)
+	require.NoError(t, err)
+	require.NoError(t, stream.Send(&controlpb.TunnelUplink{
+		Message: &controlpb.TunnelUplink_Hello{Hello: &controlpb.TunnelHello{AssetId: testAssetID}},
+	}))
+
+	go func() {
+		for {
+			downlink, err := stream.Recv()
+			if err != nil {
+				return
+			}
+
+			resp := &controlpb.TunnelResponse{RequestId: downlink.RequestId}
+			if downlink.CloseSend {
+				resp.Done = true
+			} else {
+				resp.Response = &controlpb.ControlResponse{Result: downlink.Command.Payload, Status: "success"}
+			}
+
+			if err := stream.Send(&controlpb.TunnelUplink{Message: &controlpb.TunnelUplink_Response{Response: resp}}); err != nil {
+				return
+			}
+		}
+	}()
+}
+
+func waitForSession(t *testing.T, registry *TunnelRegistry, assetID pgtype.UUID) *TunnelSession {
+	var session *TunnelSession
+	require.Eventually(t, func() bool {
+		session = registry.Get(assetID)
+		return session != nil
+	}, time.Second, 10*time.Millisecond)
+
+	return session
+}
+
+func TestTunnelRoundTrip(t *testing.T) {
+	registry := NewTunnelRegistry()
+	opened := make(chan pgtype.UUID, 1)
+	registry.OnOpen(func(assetID pgtype.UUID) { opened <- assetID })
+	client := startTunnelServer(t, registry, nil, testAssetID)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	runFakeAgent(t, ctx, client)
+
+	var assetID pgtype.UUID
+	require.NoError(t, assetID.Scan(testAssetID))
+	session := waitForSession(t, registry, assetID)
+	assert.Equal(t, assetID, <-opened)
+
+	responses, err := session.Send(context.Background(), []*controlpb.ControlMessage{
+		{Command: "exec", Payload: "whoami"},
+		{Command: "exec", Payload: "uname -a"},
+	})
+	require.NoError(t, err)
+	require.Len(t, responses, 2)
+	assert.Equal(t, "whoami", responses[0].Result)
+	assert.Equal(t, "uname -a", responses[1].Result)
+
+	cancel()
+	require.Eventually(t, func() bool {
+		return registry.Get(assetID) == nil
+	}, time.Second, 10*time.Millisecond)
+}
+
+func TestTunnelRejectsUnknownAsset(t *testing.T) {
+	registry := NewTunnelRegistry()
+	client := startTunnelServer(t, registry, func(ctx context.Context, assetID pgtype.UUID) error {
+		return errors.New("no such asset")
+	}, testAssetID)
+
+	stream, err := client.Tunnel(context.Background())
+	require.NoError(t, err)
+	require.NoError(t, stream.Send(&controlpb.TunnelUplink{
+		Message: &controlpb.TunnelUplink_Hello{Hello: &controlpb.TunnelHello{AssetId: testAssetID}},
+	}))
+
+	_, err = stream.Recv()
+	assert.Error(t, err)
+	assert.Equal(t, 0, registry.Count())
+}
+
+func TestTunnelRejectsCertificateForAnotherAsset(t *testing.T) {
+	registry := NewTunnelRegistry()
+	client := startTunnelServer(t, registry, nil, "9a8b7c6d-5e4f-4a3b-8c2d-1e0f9a8b7c6d")
+
+	stream, err := client.Tunnel(context.Background())
+	require.NoError(t, err)
+	require.NoError(t, stream.Send(&controlpb.TunnelUplink{
+		Message: &controlpb.TunnelUplink_Hello{Hello: &controlpb.TunnelHello{AssetId: testAssetID}},
+	}))
+
+	_, err = stream.Recv()
+	assert.Equal(t, codes.PermissionDenied, status.Code(err))
+	assert.Equal(t, 0, registry.Count())
+}
+
+func TestTunnelRejectsSecondSession(t *testing.T) {
+	registry := NewTunnelRegistry()
+	client := startTunnelServer(t, registry, nil, testAssetID)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	runFakeAgent(t, ctx, client)
+
+	var assetID pgtype.UUID
+	require.NoError(t, assetID.Scan(testAssetID))
+	first := waitForSession(t, registry, assetID)
+
+	stream, err := client.Tunnel(context.Background())
+	require.NoError(t, err)
+	require.NoError(t, stream.Send(&controlpb.TunnelUplink{
+		Message: &controlpb.TunnelUplink_Hello{Hello: &controlpb.TunnelHello{AssetId: testAssetID}},
+	}))
+
+	_, err = stream.Recv()
+	assert.Equal(t, codes.AlreadyExists, status.Code(err))
+	assert.Same(t, first, registry.Get(assetID))
+}
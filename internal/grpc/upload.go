@@ -0,0 +1,152 @@
+package grpc
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/SyntinelNyx/syntinel-server/internal/proto/controlpb"
+)
+
+const ChunkSize = 1024 * 1024
+
+var ErrDigestMismatch = errors.New("file digest on agent does not match")
+
+type UploadFile struct {
+	ID     string
+	Name   string
+	Size   int64
+	SHA256 string
+	Reader io.ReaderAt
+}
+
+// uploadStream is implemented by the direct Upload RPC client and by tunnel
+// sessions so both share the same transfer logic.
+type uploadStream interface {
+	Send(*controlpb.FileChunk) error
+	Recv() (*controlpb.UploadAck, error)
+	CloseSend() error
+}
+
+// transfer asks the agent for its current offset of file.ID, streams the
+// remaining chunks and waits for the agent to confirm the digest. It can be
+// called again after a failure to resume from the last acknowledged offset.
+func transfer(ctx context.Context, stream uploadStream, file UploadFile) error {
+	err := stream.Send(&controlpb.FileChunk{
+		FileId:    file.ID,
+		Name:      file.Name,
+		TotalSize: file.Size,
+		Sha256:    file.SHA256,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to start upload: %v", err)
+	}
+
+	ack, err := stream.Recv()
+	if err != nil {
+		return fmt.Errorf("failed to get upload offset from agent: %v", err)
+	}
+	if err := checkAck(ack, file); err != nil || ack.Complete {
+		return err
+	}
+
+	offset := ack.Offset
+	if offset < 0 || offset > file.Size {
+		return fmt.Errorf("agent acknowledged invalid offset %d for %d byte file", offset, file.Size)
+	}
+
+	// Stops the sender below if transfer returns before it has finished.
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	go func() {
+		buffer := make([]byte, ChunkSize)
+		for offset < file.Size {
+			if ctx.Err() != nil {
+				return
+			}
+
+			n, err := file.Reader.ReadAt(buffer, offset)
+			if err != nil && err != io.EOF {
+				stream.CloseSend()
+				return
+			}
+			if n == 0 {
+				break
+			}
+
+			err = stream.Send(&controlpb.FileChunk{
+				FileId:    file.ID,
+				Offset:    offset,
+				TotalSize: file.Size,
+				Data:      buffer[:n],
+			})
+			if err != nil {
+				return
+			}
+			offset += int64(n)
+		}
+		stream.CloseSend()
+	}()
+
+	acked := ack.Offset
+	for {
+		ack, err := stream.Recv()
+		if err != nil {
+			return fmt.Errorf("upload interrupted at offset %d of %d: %v", acked, file.Size, err)
+		}
+		if err := checkAck(ack, file); err != nil {
+			return err
+		}
+		if ack.Complete {
+			return nil
+		}
+		acked = ack.Offset
+	}
+}
+
+func checkAck(ack *controlpb.UploadAck, file UploadFile) error {
+	if ack.Error != "" {
+		return fmt.Errorf("agent rejected upload: %s", ack.Error)
+	}
+	if ack.FileId != "" && ack.FileId != file.ID {
+		return fmt.Errorf("agent acknowledged unexpected file %s", ack.FileId)
+	}
+	if !ack.Complete {
+		return nil
+	}
+
+	if ack.Offset != file.Size {
+		return fmt.Errorf("agent completed upload with %d of %d bytes", ack.Offset, file.Size)
+	}
+	if ack.Sha256 != file.SHA256 {
+		return fmt.Errorf("%w: expected %s, agent has %s", ErrDigestMismatch, file.SHA256, ack.Sha256)
+	}
+
+	return nil
+}
+
+func (m *Manager) Upload(ctx context.Context, assetID string, target string, file UploadFile) error {
+	c, err := m.acquire(ctx, assetID, target)
+	if err != nil {
+		return err
+	}
+	defer m.release(c)
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	stream, err := c.client.Upload(ctx)
+	if err != nil {
+		m.sendFailures.Add(1)
+		return fmt.Errorf("failed to create upload stream with agent: %v", err)
+	}
+
+	if err := transfer(ctx, stream, file); err != nil {
+		m.sendFailures.Add(1)
+		return err
+	}
+
+	return nil
+}
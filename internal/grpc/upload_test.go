@@ -0,0 +1,168 @@
+package grpc
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"io"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/SyntinelNyx/syntinel-server/internal/proto/controlpb"
+)
+
+// fileAgent keeps partial uploads in memory. It drops the stream after
+// failAfter data chunks once, and corrupts the stored file when corrupt is set.
+type fileAgent struct {
+	controlpb.UnimplementedAgentServiceServer
+
+	mu        sync.Mutex
+	files     map[string][]byte
+	failAfter int
+	corrupt   bool
+	received  int
+}
+
+func (a *fileAgent) Upload(stream controlpb.AgentService_UploadServer) error {
+	open, err := stream.Recv()
+	if err != nil {
+		return err
+	}
+
+	a.mu.Lock()
+	have := int64(len(a.files[open.FileId]))
+	a.mu.Unlock()
+
+	if err := stream.Send(&controlpb.UploadAck{FileId: open.FileId, Offset: have}); err != nil {
+		return err
+	}
+
+	for {
+		chunk, err := stream.Recv()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		a.mu.Lock()
+		data := append(a.files[chunk.FileId], chunk.Data...)
+		a.files[chunk.FileId] = data
+		a.received++
+		fail := a.failAfter > 0 && a.received == a.failAfter
+		a.mu.Unlock()
+
+		if fail {
+			return errors.New("connection reset")
+		}
+
+		ack := &controlpb.UploadAck{FileId: chunk.FileId, Offset: int64(len(data))}
+		if ack.Offset == open.TotalSize {
+			if a.corrupt {
+				data = append([]byte{0}, data[1:]...)
+			}
+			sum := sha256.Sum256(data)
+			ack.Complete = true
+			ack.Sha256 = hex.EncodeToString(sum[:])
+		}
+		if err := stream.Send(ack); err != nil {
+			return err
+		}
+	}
+}
+
+func testUploadFile(content []byte) UploadFile {
+	sum := sha256.Sum256(content)
+	digest := hex.EncodeToString(sum[:])
+
+	return UploadFile{
+		ID:     digest,
+		Name:   "script.sh",
+		Size:   int64(len(content)),
+		SHA256: digest,
+		Reader: bytes.NewReader(content),
+	}
+}
+
+func TestUploadResumesFromAcknowledgedOffset(t *testing.T) {
+	config := DefaultManagerConfig()
+	config.HealthInterval = 0
+
+	agent := &fileAgent{files: map[string][]byte{}, failAfter: 2}
+	m, _ := newTestManagerFor(t, config, agent)
+
+	content := bytes.Repeat([]byte("syntinel"), ChunkSize/2)
+	file := testUploadFile(content)
+
+	err := m.Upload(context.Background(), "", "passthrough:///agent", file)
+	require.Error(t, err)
+	assert.Len(t, agent.files[file.ID], 2*ChunkSize)
+
+	require.NoError(t, m.Upload(context.Background(), "", "passthrough:///agent", file))
+	assert.Equal(t, content, agent.files[file.ID])
+	assert.Equal(t, 4, agent.received)
+}
+
+func TestUploadDigestMismatch(t *testing.T) {
+	config := DefaultManagerConfig()
+	config.HealthInterval = 0
+
+	agent := &fileAgent{files: map[string][]byte{}, corrupt: true}
+	m, _ := newTestManagerFor(t, config, agent)
+
+	err := m.Upload(context.Background(), "", "passthrough:///agent", testUploadFile([]byte("#!/bin/sh\necho hello\n")))
+	assert.ErrorIs(t, err, ErrDigestMismatch)
+}
+
+// stalledStream acknowledges the start of an upload, then fails while every
+// data chunk it is sent waits for release.
+type stalledStream struct {
+	release chan struct{}
+	recvs   int
+}
+
+func (s *stalledStream) Send(chunk *controlpb.FileChunk) error {
+	if chunk.Data != nil {
+		<-s.release
+	}
+	return nil
+}
+
+func (s *stalledStream) Recv() (*controlpb.UploadAck, error) {
+	s.recvs++
+	if s.recvs == 1 {
+		return &controlpb.UploadAck{}, nil
+	}
+	return nil, errors.New("connection reset")
+}
+
+func (s *stalledStream) CloseSend() error {
+	return nil
+}
+
+func TestTransferStopsSendingAfterReturning(t *testing.T) {
+	stream := &stalledStream{release: make(chan struct{})}
+	file := testUploadFile(bytes.Repeat([]byte("syntinel"), ChunkSize))
+
+	require.Error(t, transfer(context.Background(), stream, file))
+
+	// At most the chunk that was already in flight may still be sent.
+	sent := 0
+	for {
+		select {
+		case stream.release <- struct{}{}:
+			sent++
+			require.LessOrEqual(t, sent, 1, "chunks were still sent after the transfer failed")
+			continue
+		case <-time.After(100 * time.Millisecond):
+		}
+		return
+	}
+}
@@ -0,0 +1,344 @@
+package liveness
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/spf13/viper"
+
+	"github.com/SyntinelNyx/syntinel-server/internal/capability"
+	"github.com/SyntinelNyx/syntinel-server/internal/database/query"
+	"github.com/SyntinelNyx/syntinel-server/internal/endpoint"
+	"github.com/SyntinelNyx/syntinel-server/internal/grpc"
+	"github.com/SyntinelNyx/syntinel-server/internal/logger"
+)
+
+const (
+	StatusOnline  = "online"
+	StatusOffline = "offline"
+)
+
+var ErrAgentOffline = errors.New("agent is offline")
+
+type Config struct {
+	ProbeInterval  time.Duration
+	ProbeTimeout   time.Duration
+	OfflineAfter   time.Duration
+	MaxConcurrency int
+}
+
+type assetState struct {
+	status    string
+	lastSeen  time.Time
+	changedAt time.Time
+	flushed   time.Time
+}
+
+// Monitor tracks whether each asset's agent is reachable. Agents are probed
+// on an interval, and any successful command or open tunnel counts as a
+// heartbeat in between probes.
+type Monitor struct {
+	queries *query.Queries
+	config  Config
+	probe   func(ctx context.Context, assetID pgtype.UUID, target string) error
+	resolve func(ctx context.Context, assetID pgtype.UUID) (string, error)
+
+	mu     sync.Mutex
+	assets map[pgtype.UUID]*assetState
+
+	stop chan struct{}
+}
+
+var monitor *Monitor
+
+func DefaultConfig() Config {
+	return Config{
+		ProbeInterval:  30 * time.Second,
+		ProbeTimeout:   5 * time.Second,
+		OfflineAfter:   90 * time.Second,
+		MaxConcurrency: 16,
+	}
+}
+
+func ConfigFromViper() Config {
+	config := DefaultConfig()
+
+	durations := map[string]*time.Duration{
+		"agent.liveness.probe_interval": &config.ProbeInterval,
+		"agent.liveness.probe_timeout":  &config.ProbeTimeout,
+		"agent.liveness.offline_after":  &config.OfflineAfter,
+	}
+	for key, value := range durations {
+		if viper.IsSet(key) {
+			*value = viper.GetDuration(key)
+		}
+	}
+	if viper.IsSet("agent.liveness.max_concurrency") {
+		config.MaxConcurrency = viper.GetInt("agent.liveness.max_concurrency")
+	}
+
+	return config
+}
+
+func NewMonitor(queries *query.Queries, config Config) *Monitor {
+	return &Monitor{
+		queries: queries,
+		config:  config,
+		probe:   grpc.Probe,
+		resolve: func(ctx context.Context, assetID pgtype.UUID) (string, error) {
+			return endpoint.Resolve(ctx, queries, assetID)
+		},
+		assets: make(map[pgtype.UUID]*assetState),
+		stop:   make(chan struct{}),
+	}
+}
+
+// Start loads the last known status of every asset and begins probing.
+func Start(queries *query.Queries) error {
+	m := NewMonitor(queries, ConfigFromViper())
+	if err := m.load(context.Background()); err != nil {
+		return err
+	}
+
+	monitor = m
+	go m.run()
+
+	return nil
+}
+
+func Stop() {
+	if monitor != nil {
+		close(monitor.stop)
+	}
+}
+
+// Check returns ErrAgentOffline if the asset has no open tunnel and its
+// agent, which was last known to be offline, still does not answer a probe.
+// Assets that have not been probed yet are assumed reachable.
+func Check(assetID pgtype.UUID) error {
+	if monitor == nil {
+		return nil
+	}
+	return monitor.Check(assetID)
+}
+
+// Seen records a successful exchange with the asset's agent.
+func Seen(assetID pgtype.UUID) {
+	if monitor != nil {
+		monitor.observe(assetID, nil, time.Now())
+	}
+}
+
+func (m *Monitor) Check(assetID pgtype.UUID) error {
+	if grpc.Tunnels.Get(assetID) != nil {
+		m.observe(assetID, nil, time.Now())
+		return nil
+	}
+
+	if m.status(assetID) != StatusOffline {
+		return nil
+	}
+
+	// The agent may have come back since the last sweep, so ask it again
+	// rather than trusting the stored status.
+	ctx, cancel := context.WithTimeout(context.Background(), m.config.ProbeTimeout)
+	defer cancel()
+
+	target, err := m.resolve(ctx, assetID)
+	if err == nil {
+		err = m.probe(ctx, assetID, target)
+	}
+	m.observe(assetID, err, time.Now())
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	state := m.assets[assetID]
+	if state.status != StatusOffline {
+		return nil
+	}
+
+	if state.lastSeen.IsZero() {
+		return fmt.Errorf("%w: asset %s has never responded", ErrAgentOffline, uuid.UUID(assetID.Bytes))
+	}
+	return fmt.Errorf("%w: asset %s last seen %s", ErrAgentOffline, uuid.UUID(assetID.Bytes), state.lastSeen.Format(time.RFC3339))
+}
+
+func (m *Monitor) status(assetID pgtype.UUID) string {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if state, ok := m.assets[assetID]; ok {
+		return state.status
+	}
+	return ""
+}
+
+func (m *Monitor) load(ctx context.Context) error {
+	rows, err := m.queries.GetAllAssetLiveness(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to load asset liveness: %v", err)
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for _, row := range rows {
+		m.assets[row.AssetID] = &assetState{
+			status:    row.Status,
+			lastSeen:  row.LastSeenAt.Time,
+			changedAt: row.StatusChangedAt.Time,
+			flushed:   row.LastSeenAt.Time,
+		}
+	}
+
+	return nil
+}
+
+func (m *Monitor) run() {
+	ticker := time.NewTicker(m.config.ProbeInterval)
+	defer ticker.Stop()
+
+	m.sweep()
+	for {
+		select {
+		case <-m.stop:
+			return
+		case <-ticker.C:
+			m.sweep()
+		}
+	}
+}
+
+// sweep probes every asset and persists last_seen_at for assets that have
+// been seen since the previous sweep.
+func (m *Monitor) sweep() {
+	ctx := context.Background()
+
+	assets, err := m.queries.GetAllAssetIPs(ctx)
+	if err != nil {
+		logger.Error("Failed to get assets for liveness probe: %v", err)
+		return
+	}
+
+	sem := make(chan struct{}, max(m.config.MaxConcurrency, 1))
+	var wg sync.WaitGroup
+	for _, asset := range assets {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func() {
+			defer wg.Done()
+			defer func() { <-sem }()
+
+			m.observe(asset.AssetID, m.probeAsset(ctx, asset), time.Now())
+		}()
+	}
+	wg.Wait()
+
+	m.flush(ctx)
+}
+
+func (m *Monitor) probeAsset(ctx context.Context, asset query.GetAllAssetIPsRow) error {
+	if grpc.Tunnels.Get(asset.AssetID) != nil {
+		return nil
+	}
+
+	target, err := endpoint.New(asset.IpAddress, asset.AgentHostname, asset.AgentPort, asset.AgentEndpointOverride).Target()
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, m.config.ProbeTimeout)
+	defer cancel()
+
+	return m.probe(ctx, asset.AssetID, target)
+}
+
+// observe applies the result of a probe or command. A failure only marks the
+// asset offline once it has not been seen for OfflineAfter, so a single lost
+// probe does not flap the status.
+func (m *Monitor) observe(assetID pgtype.UUID, probeErr error, now time.Time) {
+	m.mu.Lock()
+	state, ok := m.assets[assetID]
+	if !ok {
+		state = &assetState{}
+		m.assets[assetID] = state
+	}
+
+	var next, reason string
+	if probeErr == nil {
+		state.lastSeen = now
+		if state.status != StatusOnline {
+			next, reason = StatusOnline, "agent responded"
+		}
+	} else if state.status != StatusOffline && (state.lastSeen.IsZero() || now.Sub(state.lastSeen) >= m.config.OfflineAfter) {
+		next, reason = StatusOffline, probeErr.Error()
+	}
+
+	if next == "" {
+		m.mu.Unlock()
+		return
+	}
+
+	state.status = next
+	state.changedAt = now
+	lastSeen := state.lastSeen
+	if next == StatusOnline {
+		state.flushed = now
+	}
+	m.mu.Unlock()
+
+	logger.Info("Asset %s is now %s: %s", uuid.UUID(assetID.Bytes), next, reason)
+
+	// The agent may have been upgraded or reinstalled while it was away.
+	if next == StatusOnline {
+		go refreshCapabilities(assetID)
+	}
+
+	err := m.queries.SetAssetStatus(context.Background(), query.SetAssetStatusParams{
+		AssetID:    assetID,
+		Status:     next,
+		Reason:     pgtype.Text{String: reason, Valid: true},
+		ChangedAt:  pgtype.Timestamptz{Time: now, Valid: true},
+		LastSeenAt: pgtype.Timestamptz{Time: lastSeen, Valid: !lastSeen.IsZero()},
+	})
+	if err != nil {
+		logger.Error("Failed to record status of asset %s: %v", uuid.UUID(assetID.Bytes), err)
+	}
+}
+
+func refreshCapabilities(assetID pgtype.UUID) {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	if _, _, err := capability.Refresh(ctx, assetID); err != nil {
+		logger.Warn("Failed to refresh capabilities of asset %s: %v", uuid.UUID(assetID.Bytes), err)
+	}
+}
+
+func (m *Monitor) flush(ctx context.Context) {
+	m.mu.Lock()
+	pending := map[pgtype.UUID]time.Time{}
+	for assetID, state := range m.assets {
+		if state.lastSeen.After(state.flushed) {
+			pending[assetID] = state.lastSeen
+			state.flushed = state.lastSeen
+		}
+	}
+	m.mu.Unlock()
+
+	for assetID, lastSeen := range pending {
+		err := m.queries.UpdateAssetLastSeen(ctx, query.UpdateAssetLastSeenParams{
+			AssetID:    assetID,
+			LastSeenAt: pgtype.Timestamptz{Time: lastSeen, Valid: true},
+		})
+		if err != nil {
+			logger.Error("Failed to update last seen time of asset %s: %v", uuid.UUID(assetID.Bytes), err)
+		}
+	}
+}
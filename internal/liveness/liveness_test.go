@@ -0,0 +1,172 @@
+package liveness
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/SyntinelNyx/syntinel-server/internal/database/query"
+)
+
+// statusLog records the statuses written by SetAssetStatus, the only query
+// observe runs.
+type statusLog struct {
+	statuses []string
+}
+
+func (l *statusLog) Exec(ctx context.Context, sql string, args ...interface{}) (pgconn.CommandTag, error) {
+	l.statuses = append(l.statuses, args[1].(string))
+	return pgconn.CommandTag{}, nil
+}
+
+func (l *statusLog) Query(ctx context.Context, sql string, args ...interface{}) (pgx.Rows, error) {
+	return nil, errors.New("unexpected query")
+}
+
+func (l *statusLog) QueryRow(ctx context.Context, sql string, args ...interface{}) pgx.Row {
+	return nil
+}
+
+var testAsset = pgtype.UUID{Bytes: [16]byte{1}, Valid: true}
+
+func newTestMonitor(t *testing.T) (*Monitor, *statusLog) {
+	log := &statusLog{}
+	m := NewMonitor(query.New(log), Config{
+		ProbeInterval:  time.Minute,
+		ProbeTimeout:   time.Second,
+		OfflineAfter:   90 * time.Second,
+		MaxConcurrency: 1,
+	})
+	m.resolve = func(ctx context.Context, assetID pgtype.UUID) (string, error) {
+		return "agent:50051", nil
+	}
+
+	return m, log
+}
+
+func TestObserve(t *testing.T) {
+	start := time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC)
+	probeErr := errors.New("connection refused")
+
+	tests := []struct {
+		name     string
+		state    *assetState
+		probeErr error
+		after    time.Duration
+		status   string
+		written  []string
+	}{
+		{
+			name:    "new asset responds",
+			status:  StatusOnline,
+			written: []string{StatusOnline},
+		},
+		{
+			name:     "new asset never responds",
+			probeErr: probeErr,
+			status:   StatusOffline,
+			written:  []string{StatusOffline},
+		},
+		{
+			name:     "online asset misses a probe within OfflineAfter",
+			state:    &assetState{status: StatusOnline, lastSeen: start},
+			probeErr: probeErr,
+			after:    89 * time.Second,
+			status:   StatusOnline,
+		},
+		{
+			name:     "online asset unseen for OfflineAfter",
+			state:    &assetState{status: StatusOnline, lastSeen: start},
+			probeErr: probeErr,
+			after:    90 * time.Second,
+			status:   StatusOffline,
+			written:  []string{StatusOffline},
+		},
+		{
+			name:     "offline asset still unreachable",
+			state:    &assetState{status: StatusOffline, lastSeen: start},
+			probeErr: probeErr,
+			after:    time.Hour,
+			status:   StatusOffline,
+		},
+		{
+			name:    "offline asset responds again",
+			state:   &assetState{status: StatusOffline, lastSeen: start},
+			after:   time.Hour,
+			status:  StatusOnline,
+			written: []string{StatusOnline},
+		},
+		{
+			name:   "online asset responds",
+			state:  &assetState{status: StatusOnline, lastSeen: start},
+			after:  time.Minute,
+			status: StatusOnline,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m, log := newTestMonitor(t)
+			if tt.state != nil {
+				m.assets[testAsset] = tt.state
+			}
+
+			m.observe(testAsset, tt.probeErr, start.Add(tt.after))
+
+			assert.Equal(t, tt.status, m.assets[testAsset].status)
+			assert.Equal(t, tt.written, log.statuses)
+		})
+	}
+}
+
+func TestObserveTransitions(t *testing.T) {
+	m, log := newTestMonitor(t)
+	start := time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC)
+	probeErr := errors.New("connection refused")
+
+	m.observe(testAsset, nil, start)
+	for _, after := range []time.Duration{30 * time.Second, 60 * time.Second} {
+		m.observe(testAsset, probeErr, start.Add(after))
+	}
+	assert.Equal(t, StatusOnline, m.assets[testAsset].status)
+
+	m.observe(testAsset, probeErr, start.Add(90*time.Second))
+	m.observe(testAsset, probeErr, start.Add(120*time.Second))
+	assert.Equal(t, StatusOffline, m.assets[testAsset].status)
+
+	m.observe(testAsset, nil, start.Add(150*time.Second))
+	assert.Equal(t, StatusOnline, m.assets[testAsset].status)
+	assert.Equal(t, start.Add(150*time.Second), m.assets[testAsset].lastSeen)
+
+	assert.Equal(t, []string{StatusOnline, StatusOffline, StatusOnline}, log.statuses)
+}
+
+func TestCheckProbesOfflineAsset(t *testing.T) {
+	m, _ := newTestMonitor(t)
+	lastSeen := time.Now().Add(-time.Hour)
+
+	m.assets[testAsset] = &assetState{status: StatusOffline, lastSeen: lastSeen}
+	m.probe = func(ctx context.Context, assetID pgtype.UUID, target string) error {
+		return errors.New("connection refused")
+	}
+	err := m.Check(testAsset)
+	require.ErrorIs(t, err, ErrAgentOffline)
+	assert.Contains(t, err.Error(), lastSeen.Format(time.RFC3339))
+
+	m.probe = func(ctx context.Context, assetID pgtype.UUID, target string) error {
+		assert.Equal(t, "agent:50051", target)
+		return nil
+	}
+	assert.NoError(t, m.Check(testAsset))
+	assert.Equal(t, StatusOnline, m.assets[testAsset].status)
+
+	unknown := pgtype.UUID{Bytes: [16]byte{2}, Valid: true}
+	assert.NoError(t, m.Check(unknown))
+}
@@ -0,0 +1,178 @@
+package pki
+
+import (
+	"crypto"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/hex"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"math/big"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/spf13/viper"
+)
+
+type CA struct {
+	Cert    *x509.Certificate
+	CertPEM []byte
+	key     crypto.Signer
+}
+
+type Issued struct {
+	CertPEM     []byte
+	Fingerprint string
+	Serial      string
+	NotAfter    time.Time
+}
+
+var ErrNotLoaded = errors.New("certificate authority not loaded")
+
+var ca *CA
+
+// Load reads the CA certificate and key from DATA_PATH so that agent
+// certificates can be issued at enrollment.
+func Load() error {
+	certPEM, err := os.ReadFile(filepath.Join(os.Getenv("DATA_PATH"), "ca_cert.pem"))
+	if err != nil {
+		return fmt.Errorf("failed to read CA certificate: %v", err)
+	}
+
+	keyPEM, err := os.ReadFile(filepath.Join(os.Getenv("DATA_PATH"), "ca_key.pem"))
+	if err != nil {
+		return fmt.Errorf("failed to read CA key: %v", err)
+	}
+
+	loaded, err := NewCA(certPEM, keyPEM)
+	if err != nil {
+		return err
+	}
+
+	ca = loaded
+	return nil
+}
+
+func NewCA(certPEM []byte, keyPEM []byte) (*CA, error) {
+	certBlock, _ := pem.Decode(certPEM)
+	if certBlock == nil {
+		return nil, fmt.Errorf("failed to decode CA certificate PEM")
+	}
+
+	cert, err := x509.ParseCertificate(certBlock.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse CA certificate: %v", err)
+	}
+
+	keyBlock, _ := pem.Decode(keyPEM)
+	if keyBlock == nil {
+		return nil, fmt.Errorf("failed to decode CA key PEM")
+	}
+
+	var key any
+	switch keyBlock.Type {
+	case "EC PRIVATE KEY":
+		key, err = x509.ParseECPrivateKey(keyBlock.Bytes)
+	case "RSA PRIVATE KEY":
+		key, err = x509.ParsePKCS1PrivateKey(keyBlock.Bytes)
+	default:
+		key, err = x509.ParsePKCS8PrivateKey(keyBlock.Bytes)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse CA key: %v", err)
+	}
+
+	signer, ok := key.(crypto.Signer)
+	if !ok {
+		return nil, fmt.Errorf("unsupported CA key type %T", key)
+	}
+
+	return &CA{Cert: cert, CertPEM: certPEM, key: signer}, nil
+}
+
+// Sign issues a certificate for the public key in csrPEM. The agent's asset
+// ID is used as the subject and as the only DNS name so that the server can
+// dial the agent with the asset ID as the TLS server name.
+func (c *CA) Sign(csrPEM []byte, assetID string, validity time.Duration) (*Issued, error) {
+	block, _ := pem.Decode(csrPEM)
+	if block == nil || block.Type != "CERTIFICATE REQUEST" {
+		return nil, fmt.Errorf("failed to decode CSR PEM")
+	}
+
+	csr, err := x509.ParseCertificateRequest(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse CSR: %v", err)
+	}
+
+	if err := csr.CheckSignature(); err != nil {
+		return nil, fmt.Errorf("invalid CSR signature: %v", err)
+	}
+
+	serial, err := rand.Int(rand.Reader, new(big.Int).Lsh(big.NewInt(1), 128))
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate serial number: %v", err)
+	}
+
+	now := time.Now()
+	template := &x509.Certificate{
+		SerialNumber: serial,
+		Subject:      pkix.Name{CommonName: assetID, Organization: c.Cert.Subject.Organization},
+		DNSNames:     []string{assetID},
+		NotBefore:    now.Add(-5 * time.Minute),
+		NotAfter:     now.Add(validity),
+		KeyUsage:     x509.KeyUsageDigitalSignature | x509.KeyUsageKeyEncipherment,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth},
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, template, c.Cert, csr.PublicKey, c.key)
+	if err != nil {
+		return nil, fmt.Errorf("failed to sign certificate: %v", err)
+	}
+
+	return &Issued{
+		CertPEM:     pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}),
+		Fingerprint: Fingerprint(der),
+		Serial:      serial.Text(16),
+		NotAfter:    template.NotAfter,
+	}, nil
+}
+
+// Fingerprint returns the hex-encoded SHA-256 of a DER certificate.
+func Fingerprint(der []byte) string {
+	sum := sha256.Sum256(der)
+	return hex.EncodeToString(sum[:])
+}
+
+func Validity() time.Duration {
+	if viper.IsSet("agent.certificate.validity") {
+		return viper.GetDuration("agent.certificate.validity")
+	}
+	return 365 * 24 * time.Hour
+}
+
+// LegacyAgents reports whether agents that predate per-agent certificates
+// may still enroll without a CSR or enrollment token and be contacted without
+// a pinned certificate. It is a migration mode, off unless agent.legacy_agents
+// is set, and every use of it is logged.
+func LegacyAgents() bool {
+	return viper.GetBool("agent.legacy_agents")
+}
+
+func SignAgentCSR(csrPEM []byte, assetID string) (*Issued, error) {
+	if ca == nil {
+		return nil, ErrNotLoaded
+	}
+
+	return ca.Sign(csrPEM, assetID, Validity())
+}
+
+func CACertPEM() []byte {
+	if ca == nil {
+		return nil
+	}
+	return ca.CertPEM
+}
@@ -0,0 +1,87 @@
+package pki
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestCA(t *testing.T) *CA {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "test-ca"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+		KeyUsage:              x509.KeyUsageCertSign,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	require.NoError(t, err)
+
+	keyDER, err := x509.MarshalPKCS8PrivateKey(key)
+	require.NoError(t, err)
+
+	ca, err := NewCA(
+		pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}),
+		pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: keyDER}),
+	)
+	require.NoError(t, err)
+
+	return ca
+}
+
+func newTestCSR(t *testing.T) []byte {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	der, err := x509.CreateCertificateRequest(rand.Reader, &x509.CertificateRequest{Subject: pkix.Name{CommonName: "agent"}}, key)
+	require.NoError(t, err)
+
+	return pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE REQUEST", Bytes: der})
+}
+
+func TestSignIssuesAssetCertificate(t *testing.T) {
+	ca := newTestCA(t)
+	assetID := "4f1d2a3b-5c6d-4e7f-8a9b-0c1d2e3f4a5b"
+
+	issued, err := ca.Sign(newTestCSR(t), assetID, time.Hour)
+	require.NoError(t, err)
+
+	block, _ := pem.Decode(issued.CertPEM)
+	require.NotNil(t, block)
+	cert, err := x509.ParseCertificate(block.Bytes)
+	require.NoError(t, err)
+
+	assert.Equal(t, assetID, cert.Subject.CommonName)
+	assert.Equal(t, Fingerprint(cert.Raw), issued.Fingerprint)
+	assert.Equal(t, cert.SerialNumber.Text(16), issued.Serial)
+
+	roots := x509.NewCertPool()
+	roots.AddCert(ca.Cert)
+	_, err = cert.Verify(x509.VerifyOptions{
+		DNSName:   assetID,
+		Roots:     roots,
+		KeyUsages: []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	})
+	assert.NoError(t, err)
+}
+
+func TestSignRejectsInvalidCSR(t *testing.T) {
+	ca := newTestCA(t)
+
+	_, err := ca.Sign([]byte("not a csr"), "asset", time.Hour)
+	assert.Error(t, err)
+}
@@ -20,6 +20,9 @@ const _ = grpc.SupportPackageIsVersion7
 
 const (
 	AgentService_Control_FullMethodName = "/control.AgentService/Control"
+	AgentService_Upload_FullMethodName = "/control.AgentService/Upload"
+	AgentService_Download_FullMethodName = "/control.AgentService/Download"
+	AgentService_Handshake_FullMethodName = "/control.AgentService/Handshake"
 )
 
 // AgentServiceClient is the client API for AgentService service.
@@ -27,6 +30,14 @@ const (
 // For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
 type AgentServiceClient interface {
 	Control(ctx context.Context, opts ...grpc.CallOption) (AgentService_ControlClient, error)
+	// Upload transfers a single file to the agent. See FileChunk.
+	Upload(ctx context.Context, opts ...grpc.CallOption) (AgentService_UploadClient, error)
+	// Download streams a file from the agent back to the server. See
+	// FileTransfer.
+	Download(ctx context.Context, in *FileTransfer, opts ...grpc.CallOption) (AgentService_DownloadClient, error)
+	// Handshake reports the agent's version and what it is able to run. The
+	// server calls it whenever the agent comes online.
+	Handshake(ctx context.Context, in *HandshakeRequest, opts ...grpc.CallOption) (*Capabilities, error)
 }
 
 type agentServiceClient struct {
@@ -68,11 +79,91 @@ func (x *agentServiceControlClient) Recv() (*ControlResponse, error) {
 	return m, nil
 }
 
+func (c *agentServiceClient) Upload(ctx context.Context, opts ...grpc.CallOption) (AgentService_UploadClient, error) {
+	stream, err := c.cc.NewStream(ctx, &AgentService_ServiceDesc.Streams[1], AgentService_Upload_FullMethodName, opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &agentServiceUploadClient{stream}
+	return x, nil
+}
+
+type AgentService_UploadClient interface {
+	Send(*FileChunk) error
+	Recv() (*UploadAck, error)
+	grpc.ClientStream
+}
+
+type agentServiceUploadClient struct {
+	grpc.ClientStream
+}
+
+func (x *agentServiceUploadClient) Send(m *FileChunk) error {
+	return x.ClientStream.SendMsg(m)
+}
+
+func (x *agentServiceUploadClient) Recv() (*UploadAck, error) {
+	m := new(UploadAck)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func (c *agentServiceClient) Download(ctx context.Context, in *FileTransfer, opts ...grpc.CallOption) (AgentService_DownloadClient, error) {
+	stream, err := c.cc.NewStream(ctx, &AgentService_ServiceDesc.Streams[2], AgentService_Download_FullMethodName, opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &agentServiceDownloadClient{stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+type AgentService_DownloadClient interface {
+	Recv() (*FileChunk, error)
+	grpc.ClientStream
+}
+
+type agentServiceDownloadClient struct {
+	grpc.ClientStream
+}
+
+func (x *agentServiceDownloadClient) Recv() (*FileChunk, error) {
+	m := new(FileChunk)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func (c *agentServiceClient) Handshake(ctx context.Context, in *HandshakeRequest, opts ...grpc.CallOption) (*Capabilities, error) {
+	out := new(Capabilities)
+	err := c.cc.Invoke(ctx, AgentService_Handshake_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 // AgentServiceServer is the server API for AgentService service.
 // All implementations must embed UnimplementedAgentServiceServer
 // for forward compatibility
 type AgentServiceServer interface {
 	Control(AgentService_ControlServer) error
+	// Upload transfers a single file to the agent. See FileChunk.
+	Upload(AgentService_UploadServer) error
+	// Download streams a file from the agent back to the server. See
+	// FileTransfer.
+	Download(*FileTransfer, AgentService_DownloadServer) error
+	// Handshake reports the agent's version and what it is able to run. The
+	// server calls it whenever the agent comes online.
+	Handshake(context.Context, *HandshakeRequest) (*Capabilities, error)
 	mustEmbedUnimplementedAgentServiceServer()
 }
 
@@ -83,6 +174,15 @@ type UnimplementedAgentServiceServer struct {
 func (UnimplementedAgentServiceServer) Control(AgentService_ControlServer) error {
 	return status.Errorf(codes.Unimplemented, "method Control not implemented")
 }
+func (UnimplementedAgentServiceServer) Upload(AgentService_UploadServer) error {
+	return status.Errorf(codes.Unimplemented, "method Upload not implemented")
+}
+func (UnimplementedAgentServiceServer) Download(*FileTransfer, AgentService_DownloadServer) error {
+	return status.Errorf(codes.Unimplemented, "method Download not implemented")
+}
+func (UnimplementedAgentServiceServer) Handshake(context.Context, *HandshakeRequest) (*Capabilities, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Handshake not implemented")
+}
 func (UnimplementedAgentServiceServer) mustEmbedUnimplementedAgentServiceServer() {}
 
 // UnsafeAgentServiceServer may be embedded to opt out of forward compatibility for this service.
@@ -122,13 +222,83 @@ func (x *agentServiceControlServer) Recv() (*ControlMessage, error) {
 	return m, nil
 }
 
+func _AgentService_Upload_Handler(srv interface{}, stream grpc.ServerStream) error {
+	return srv.(AgentServiceServer).Upload(&agentServiceUploadServer{stream})
+}
+
+type AgentService_UploadServer interface {
+	Send(*UploadAck) error
+	Recv() (*FileChunk, error)
+	grpc.ServerStream
+}
+
+type agentServiceUploadServer struct {
+	grpc.ServerStream
+}
+
+func (x *agentServiceUploadServer) Send(m *UploadAck) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+func (x *agentServiceUploadServer) Recv() (*FileChunk, error) {
+	m := new(FileChunk)
+	if err := x.ServerStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func _AgentService_Download_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(FileTransfer)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(AgentServiceServer).Download(m, &agentServiceDownloadServer{stream})
+}
+
+type AgentService_DownloadServer interface {
+	Send(*FileChunk) error
+	grpc.ServerStream
+}
+
+type agentServiceDownloadServer struct {
+	grpc.ServerStream
+}
+
+func (x *agentServiceDownloadServer) Send(m *FileChunk) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+func _AgentService_Handshake_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(HandshakeRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(AgentServiceServer).Handshake(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: AgentService_Handshake_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(AgentServiceServer).Handshake(ctx, req.(*HandshakeRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 // AgentService_ServiceDesc is the grpc.ServiceDesc for AgentService service.
 // It's only intended for direct use with grpc.RegisterService,
 // and not to be introspected or modified (even as a copy)
 var AgentService_ServiceDesc = grpc.ServiceDesc{
 	ServiceName: "control.AgentService",
 	HandlerType: (*AgentServiceServer)(nil),
-	Methods:     []grpc.MethodDesc{},
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "Handshake",
+			Handler:    _AgentService_Handshake_Handler,
+		},
+	},
 	Streams: []grpc.StreamDesc{
 		{
 			StreamName:    "Control",
@@ -136,6 +306,139 @@ var AgentService_ServiceDesc = grpc.ServiceDesc{
 			ServerStreams: true,
 			ClientStreams: true,
 		},
+		{
+			StreamName:    "Upload",
+			Handler:       _AgentService_Upload_Handler,
+			ServerStreams: true,
+			ClientStreams: true,
+		},
+		{
+			StreamName:    "Download",
+			Handler:       _AgentService_Download_Handler,
+			ServerStreams: true,
+		},
+	},
+	Metadata: "internal/proto/control.proto",
+}
+
+const (
+	TunnelService_Tunnel_FullMethodName = "/control.TunnelService/Tunnel"
+)
+
+// TunnelServiceClient is the client API for TunnelService service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+type TunnelServiceClient interface {
+	Tunnel(ctx context.Context, opts ...grpc.CallOption) (TunnelService_TunnelClient, error)
+}
+
+type tunnelServiceClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewTunnelServiceClient(cc grpc.ClientConnInterface) TunnelServiceClient {
+	return &tunnelServiceClient{cc}
+}
+
+func (c *tunnelServiceClient) Tunnel(ctx context.Context, opts ...grpc.CallOption) (TunnelService_TunnelClient, error) {
+	stream, err := c.cc.NewStream(ctx, &TunnelService_ServiceDesc.Streams[0], TunnelService_Tunnel_FullMethodName, opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &tunnelServiceTunnelClient{stream}
+	return x, nil
+}
+
+type TunnelService_TunnelClient interface {
+	Send(*TunnelUplink) error
+	Recv() (*TunnelDownlink, error)
+	grpc.ClientStream
+}
+
+type tunnelServiceTunnelClient struct {
+	grpc.ClientStream
+}
+
+func (x *tunnelServiceTunnelClient) Send(m *TunnelUplink) error {
+	return x.ClientStream.SendMsg(m)
+}
+
+func (x *tunnelServiceTunnelClient) Recv() (*TunnelDownlink, error) {
+	m := new(TunnelDownlink)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// TunnelServiceServer is the server API for TunnelService service.
+// All implementations must embed UnimplementedTunnelServiceServer
+// for forward compatibility
+type TunnelServiceServer interface {
+	Tunnel(TunnelService_TunnelServer) error
+	mustEmbedUnimplementedTunnelServiceServer()
+}
+
+// UnimplementedTunnelServiceServer must be embedded to have forward compatible implementations.
+type UnimplementedTunnelServiceServer struct {
+}
+
+func (UnimplementedTunnelServiceServer) Tunnel(TunnelService_TunnelServer) error {
+	return status.Errorf(codes.Unimplemented, "method Tunnel not implemented")
+}
+func (UnimplementedTunnelServiceServer) mustEmbedUnimplementedTunnelServiceServer() {}
+
+// UnsafeTunnelServiceServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to TunnelServiceServer will
+// result in compilation errors.
+type UnsafeTunnelServiceServer interface {
+	mustEmbedUnimplementedTunnelServiceServer()
+}
+
+func RegisterTunnelServiceServer(s grpc.ServiceRegistrar, srv TunnelServiceServer) {
+	s.RegisterService(&TunnelService_ServiceDesc, srv)
+}
+
+func _TunnelService_Tunnel_Handler(srv interface{}, stream grpc.ServerStream) error {
+	return srv.(TunnelServiceServer).Tunnel(&tunnelServiceTunnelServer{stream})
+}
+
+type TunnelService_TunnelServer interface {
+	Send(*TunnelDownlink) error
+	Recv() (*TunnelUplink, error)
+	grpc.ServerStream
+}
+
+type tunnelServiceTunnelServer struct {
+	grpc.ServerStream
+}
+
+func (x *tunnelServiceTunnelServer) Send(m *TunnelDownlink) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+func (x *tunnelServiceTunnelServer) Recv() (*TunnelUplink, error) {
+	m := new(TunnelUplink)
+	if err := x.ServerStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// TunnelService_ServiceDesc is the grpc.ServiceDesc for TunnelService service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var TunnelService_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "control.TunnelService",
+	HandlerType: (*TunnelServiceServer)(nil),
+	Methods:     []grpc.MethodDesc{},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "Tunnel",
+			Handler:       _TunnelService_Tunnel_Handler,
+			ServerStreams: true,
+			ClientStreams: true,
+		},
 	},
 	Metadata: "internal/proto/control.proto",
 }
@@ -11,15 +11,24 @@ import (
 )
 
 var RoutePermissions = map[string]string{
-	"/assets":      "Assets.View",
-	"/assets/min":  "Assets.View",
-	"/assets/{id}": "Assets.View",
+	"/assets":             "Assets.View",
+	"/assets/min":         "Assets.View",
+	"/assets/connections": "Assets.View",
+	"/assets/{id}":        "Assets.View",
 
 	"/assets/create-snapshot/{assetID}": "Assets.Manage",
 	"/assets/snapshots/{assetID}":       "Assets.View",
 	"/assets/{assetID}/telemetry-usage": "Assets.View",
 	"/assets/{assetID}/terminal":        "Assets.Manage",
 
+	"/assets/{assetID}/revoke-certificate": "Assets.Manage",
+	"/assets/{assetID}/endpoint":           "Assets.Manage",
+	"/assets/{assetID}/tags":               "Assets.Manage",
+
+	"/assets/{assetID}/files":          "AssetFiles.View",
+	"/assets/{assetID}/files/{fileID}": "AssetFiles.View",
+	"/assets/{assetID}/files/pull":     "AssetFiles.Create",
+
 	"/action/retrieve": "Actions.View",
 	"/action/create":   "Actions.Create",
 	"/action/run":      "Actions.Manage",
@@ -33,14 +42,30 @@ var RoutePermissions = map[string]string{
 	"/role/update":                 "RoleManagement.Manage",
 	"/role/delete":                 "RoleManagement.Manage",
 
-	"/scan/launch":                   "Scans.Create",
-	"/scan/update-notes":             "Scans.Manage",
-	"/scan/retrieve":                 "Scans.View",
-	"/scan/retrieve-scan-parameters": "Scans.Manage",
-
-	"/vuln/retrieve":               "Vulnerabilities.View",
-	"/vuln/retrieve-data/{vulnID}": "Vulnerabilities.View",
-	"/vuln/retrieve-scan/{scanID}": "Vulnerabilities.View",
+	"/scan/launch":                             "Scans.Create",
+	"/scan/jobs":                               "Scans.View",
+	"/scan/jobs/{jobID}":                       "Scans.View",
+	"/scan/schedules":                          "Scans.View",
+	"/scan/schedules/create":                   "Scans.Manage",
+	"/scan/schedules/{scheduleID}":             "Scans.View",
+	"/scan/schedules/{scheduleID}/update":      "Scans.Manage",
+	"/scan/schedules/{scheduleID}/delete":      "Scans.Manage",
+	"/scan/import-sbom/{assetID}":              "Scans.Create",
+	"/scan/import-results/{scanner}/{assetID}": "Scans.Create",
+	"/scan/update-notes":                       "Scans.Manage",
+	"/scan/retrieve":                           "Scans.View",
+	"/scan/retrieve-scan-parameters":           "Scans.Manage",
+
+	"/vuln/retrieve":                        "Vulnerabilities.View",
+	"/vuln/retrieve-data/{vulnID}":          "Vulnerabilities.View",
+	"/vuln/retrieve-scan/{scanID}":          "Vulnerabilities.View",
+	"/vuln/retrieve-scan-packages/{scanID}": "Vulnerabilities.View",
+	"/vuln/scan-diff/{scanID}":              "Vulnerabilities.View",
+	"/vuln/scan-diff/{scanID}/{baseScanID}": "Vulnerabilities.View",
+
+	"/finding/retrieve":                 "Vulnerabilities.View",
+	"/finding/retrieve-scan/{scanID}":   "Vulnerabilities.View",
+	"/finding/retrieve-asset/{assetID}": "Vulnerabilities.View",
 
 	"/user/create":   "UserManagement.Create",
 	"/user/retrieve": "UserManagement.View",
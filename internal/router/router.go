@@ -15,6 +15,7 @@ import (
 	"github.com/SyntinelNyx/syntinel-server/internal/auth"
 	"github.com/SyntinelNyx/syntinel-server/internal/database/query"
 	"github.com/SyntinelNyx/syntinel-server/internal/environment"
+	"github.com/SyntinelNyx/syntinel-server/internal/finding"
 	"github.com/SyntinelNyx/syntinel-server/internal/limiter"
 	"github.com/SyntinelNyx/syntinel-server/internal/logger"
 	"github.com/SyntinelNyx/syntinel-server/internal/response"
@@ -54,7 +55,6 @@ func SetupRouter(q *query.Queries, origins []string) *Router {
 	router.Use(middleware.RealIP)
 	router.Use(middleware.Logger)
 	router.Use(middleware.Recoverer)
-	router.Use(middleware.Timeout(60 * time.Second))
 
 	router.Use(logger.Middleware(zlogger))
 
@@ -65,8 +65,13 @@ func SetupRouter(q *query.Queries, origins []string) *Router {
 		rateLimiter: rl,
 	}
 
+	// Agent operations that can run for longer are bounded by their
+	// agent.timeouts deadline instead.
+	requestTimeout := middleware.Timeout(60 * time.Second)
+
 	r.router.Route("/v1/api", func(apiRouter chi.Router) {
 		apiRouter.Group(func(subRouter chi.Router) {
+			subRouter.Use(requestTimeout)
 			subRouter.Use(r.rateLimiter.Middleware(rate.Every(1*time.Second), 30))
 
 			assetHandler := asset.NewHandler(r.queries)
@@ -78,6 +83,7 @@ func SetupRouter(q *query.Queries, origins []string) *Router {
 		})
 
 		apiRouter.Group(func(subRouter chi.Router) {
+			subRouter.Use(requestTimeout)
 			subRouter.Use(r.rateLimiter.Middleware(rate.Every(1*time.Second), 3))
 
 			authHandler := auth.NewHandler(r.queries)
@@ -94,6 +100,7 @@ func SetupRouter(q *query.Queries, origins []string) *Router {
 			actionHandler := action.NewHandler(r.queries)
 			scanHandler := scan.NewHandler(r.queries)
 			vulnHandler := vuln.NewHandler(r.queries)
+			findingHandler := finding.NewHandler(r.queries)
 			assetHandler := asset.NewHandler(r.queries)
 			snapshotsHandler := snapshots.NewHandler(r.queries)
 			telemetryHandler := telemetry.NewHandler(r.queries)
@@ -105,48 +112,77 @@ func SetupRouter(q *query.Queries, origins []string) *Router {
 			subRouter.Use(authHandler.CSRFMiddleware)
 			subRouter.Use(roleHandler.PermissionsMiddleware)
 
-			subRouter.Get("/assets", assetHandler.Retrieve)
-			subRouter.Get("/assets/min", assetHandler.RetrieveMin)
-			subRouter.Get("/assets/{id}", assetHandler.RetrieveData)
+			subRouter.Post("/assets/{assetID}/files/pull", assetHandler.PullFile)
 			subRouter.Post("/assets/create-snapshot/{assetID}", snapshotsHandler.CreateSnapshot)
-			subRouter.Get("/assets/snapshots/{assetID}", snapshotsHandler.ListSnapshots)
-
-			subRouter.Get("/action/retrieve", actionHandler.Retrieve)
-			subRouter.Post("/action/create", actionHandler.Create)
 			subRouter.Post("/action/run", actionHandler.Run)
 
-			subRouter.Get("/env/retrieve", envHandler.Retrieve)
-			subRouter.Post("/env/create", envHandler.Create)
-			subRouter.Post("/env/add-asset", envHandler.AddAsset)
-
-			subRouter.Post("/assets/{assetID}/terminal", terminal.Terminal)
-			subRouter.Get("/assets/{assetID}/telemetry-usage", telemetryHandler.LatestUsage)
-
-			subRouter.Get("/telemetry-uptime", telemetryHandler.Uptime)
-			subRouter.Get("/telemetry-usage-all", telemetryHandler.LatestUsageAll)
-
-			subRouter.Get("/role/retrieve", roleHandler.Retrieve)
-			subRouter.Get("/role/retrieve-data/{roleID}", roleHandler.RetrieveData)
-			subRouter.Post("/role/create", roleHandler.Create)
-			subRouter.Post("/role/update", roleHandler.Update)
-			subRouter.Post("/role/delete", roleHandler.DeleteRole)
-
-			subRouter.Post("/scan/launch", scanHandler.Launch)
-			subRouter.Post("/scan/update-notes", scanHandler.UpdateNotes)
-			subRouter.Get("/scan/retrieve", scanHandler.Retrieve)
-			subRouter.Get("/scan/retrieve-scan-parameters", scanHandler.RetrieveScanParameters)
-
-			subRouter.Get("/vuln/retrieve", vulnHandler.Retrieve)
-			subRouter.Get("/vuln/retrieve-data/{vulnID}", vulnHandler.RetrieveData)
-			subRouter.Get("/vuln/retrieve-scan/{scanID}", vulnHandler.RetrieveScan)
-
-			subRouter.Post("/user/create", userHandler.CreateUser)
-			subRouter.Get("/user/retrieve", userHandler.Retrieve)
-			subRouter.Post("/user/delete", userHandler.DeleteUser)
-			subRouter.Post("/user/update", userHandler.UpdateUser)
+			subRouter.Group(func(subRouter chi.Router) {
+				subRouter.Use(requestTimeout)
+
+				subRouter.Get("/assets", assetHandler.Retrieve)
+				subRouter.Get("/assets/min", assetHandler.RetrieveMin)
+				subRouter.Get("/assets/connections", assetHandler.Connections)
+				subRouter.Get("/assets/{id}", assetHandler.RetrieveData)
+				subRouter.Post("/assets/{assetID}/revoke-certificate", assetHandler.RevokeCertificate)
+				subRouter.Put("/assets/{assetID}/endpoint", assetHandler.UpdateEndpoint)
+				subRouter.Put("/assets/{assetID}/tags", assetHandler.UpdateTags)
+				subRouter.Get("/assets/{assetID}/files", assetHandler.ListFiles)
+				subRouter.Get("/assets/{assetID}/files/{fileID}", assetHandler.DownloadFile)
+				subRouter.Get("/assets/snapshots/{assetID}", snapshotsHandler.ListSnapshots)
+
+				subRouter.Get("/action/retrieve", actionHandler.Retrieve)
+				subRouter.Post("/action/create", actionHandler.Create)
+
+				subRouter.Get("/env/retrieve", envHandler.Retrieve)
+				subRouter.Post("/env/create", envHandler.Create)
+				subRouter.Post("/env/add-asset", envHandler.AddAsset)
+
+				subRouter.Post("/assets/{assetID}/terminal", terminal.Terminal)
+				subRouter.Get("/assets/{assetID}/telemetry-usage", telemetryHandler.LatestUsage)
+
+				subRouter.Get("/telemetry-uptime", telemetryHandler.Uptime)
+				subRouter.Get("/telemetry-usage-all", telemetryHandler.LatestUsageAll)
+
+				subRouter.Get("/role/retrieve", roleHandler.Retrieve)
+				subRouter.Get("/role/retrieve-data/{roleID}", roleHandler.RetrieveData)
+				subRouter.Post("/role/create", roleHandler.Create)
+				subRouter.Post("/role/update", roleHandler.Update)
+				subRouter.Post("/role/delete", roleHandler.DeleteRole)
+
+				subRouter.Post("/scan/launch", scanHandler.Launch)
+				subRouter.Get("/scan/jobs", scanHandler.ListJobs)
+				subRouter.Get("/scan/jobs/{jobID}", scanHandler.RetrieveJob)
+				subRouter.Get("/scan/schedules", scanHandler.ListSchedules)
+				subRouter.Post("/scan/schedules/create", scanHandler.CreateSchedule)
+				subRouter.Get("/scan/schedules/{scheduleID}", scanHandler.RetrieveSchedule)
+				subRouter.Put("/scan/schedules/{scheduleID}/update", scanHandler.UpdateSchedule)
+				subRouter.Delete("/scan/schedules/{scheduleID}/delete", scanHandler.DeleteSchedule)
+				subRouter.Post("/scan/import-sbom/{assetID}", scanHandler.ImportSBOM)
+				subRouter.Post("/scan/import-results/{scanner}/{assetID}", scanHandler.ImportResults)
+				subRouter.Post("/scan/update-notes", scanHandler.UpdateNotes)
+				subRouter.Get("/scan/retrieve", scanHandler.Retrieve)
+				subRouter.Get("/scan/retrieve-scan-parameters", scanHandler.RetrieveScanParameters)
+
+				subRouter.Get("/vuln/retrieve", vulnHandler.Retrieve)
+				subRouter.Get("/vuln/retrieve-data/{vulnID}", vulnHandler.RetrieveData)
+				subRouter.Get("/vuln/retrieve-scan/{scanID}", vulnHandler.RetrieveScan)
+				subRouter.Get("/vuln/retrieve-scan-packages/{scanID}", vulnHandler.RetrieveScanPackages)
+				subRouter.Get("/vuln/scan-diff/{scanID}", vulnHandler.DiffScan)
+				subRouter.Get("/vuln/scan-diff/{scanID}/{baseScanID}", vulnHandler.DiffScan)
+
+				subRouter.Get("/finding/retrieve", findingHandler.Retrieve)
+				subRouter.Get("/finding/retrieve-scan/{scanID}", findingHandler.RetrieveScan)
+				subRouter.Get("/finding/retrieve-asset/{assetID}", findingHandler.RetrieveAsset)
+
+				subRouter.Post("/user/create", userHandler.CreateUser)
+				subRouter.Get("/user/retrieve", userHandler.Retrieve)
+				subRouter.Post("/user/delete", userHandler.DeleteUser)
+				subRouter.Post("/user/update", userHandler.UpdateUser)
+			})
 		})
 
 		apiRouter.Group(func(subRouter chi.Router) {
+			subRouter.Use(requestTimeout)
 			subRouter.Use(r.rateLimiter.Middleware(rate.Every(1*time.Second), 10))
 
 			authHandler := auth.NewHandler(r.queries)
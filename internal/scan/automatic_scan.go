@@ -1,88 +1,246 @@
 package scan
 
 import (
+	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"strings"
+	"sync"
 	"time"
 
-	"github.com/SyntinelNyx/syntinel-server/internal/scan/strategies"
 	"github.com/go-co-op/gocron/v2"
-	"github.com/spf13/viper"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/robfig/cron/v3"
+
+	"github.com/SyntinelNyx/syntinel-server/internal/database/query"
+	"github.com/SyntinelNyx/syntinel-server/internal/logger"
+	"github.com/SyntinelNyx/syntinel-server/internal/response"
+	"github.com/SyntinelNyx/syntinel-server/internal/scan/flags"
 )
 
-var scheduler gocron.Scheduler
+var scheduler *scheduleRunner
 
-func InitalizeScheduler(h *Handler) error {
-	if err := viper.ReadInConfig(); err != nil {
-		return fmt.Errorf("error reading config file: %s", err)
-	}
+// scheduleRunner keeps one gocron job for each enabled scan schedule.
+type scheduleRunner struct {
+	h         *Handler
+	scheduler gocron.Scheduler
+
+	mu   sync.Mutex
+	jobs map[[16]byte]uuid.UUID
+}
 
-	defaultScanner := viper.GetString("scan.default")
-	if defaultScanner == "" {
-		return fmt.Errorf("error default scanner undefined")
+// StartScheduler loads every enabled scan schedule and starts running them.
+// Schedules changed through the API are re-registered as they are saved.
+func StartScheduler(h *Handler) error {
+	s, err := gocron.NewScheduler()
+	if err != nil {
+		return fmt.Errorf("error creating job scheduler: %s", err)
 	}
 
-	time, err := time.Parse("3:04 PM", viper.GetString("scan.time"))
+	scheduler = &scheduleRunner{h: h, scheduler: s, jobs: make(map[[16]byte]uuid.UUID)}
+	s.Start()
+
+	schedules, err := h.queries.GetEnabledScanSchedules(context.Background())
 	if err != nil {
-		return fmt.Errorf("error reading reading time: %s", err)
+		return err
+	}
+	for _, schedule := range schedules {
+		if err := scheduler.register(schedule); err != nil {
+			logger.Error("Scan schedule %s: %v", response.UuidToString(schedule.ScheduleID), err)
+		}
 	}
 
-	frequency := viper.GetString("scan.frequency")
-	if frequency != "" {
-		return fmt.Errorf("error frequency undefined")
+	return nil
+}
+
+func ShutdownScheduler() {
+	if scheduler == nil {
+		return
+	}
+	if err := scheduler.scheduler.Shutdown(); err != nil {
+		logger.Error("Error stopping scan scheduler: %v", err)
 	}
+}
 
-	scheduler, err := gocron.NewScheduler()
+// scheduleChanged re-registers a schedule after it is created or updated.
+func scheduleChanged(schedule query.ScanSchedule) {
+	if scheduler == nil {
+		return
+	}
+	if err := scheduler.register(schedule); err != nil {
+		logger.Error("Scan schedule %s: %v", response.UuidToString(schedule.ScheduleID), err)
+	}
+}
 
+func scheduleDeleted(scheduleID pgtype.UUID) {
+	if scheduler == nil {
+		return
+	}
+	scheduler.remove(scheduleID)
+}
+
+func (r *scheduleRunner) register(schedule query.ScanSchedule) error {
+	r.remove(schedule.ScheduleID)
+
+	if !schedule.Enabled {
+		return r.h.queries.SetScanScheduleNextRun(context.Background(), query.SetScanScheduleNextRunParams{
+			ScheduleID: schedule.ScheduleID,
+		})
+	}
+
+	crontab, err := cronSpec(schedule.CronExpression, schedule.Timezone)
 	if err != nil {
-		return fmt.Errorf("error creating job scheduler: %s", err)
+		return err
 	}
 
-	startTime := gocron.NewAtTimes(
-		gocron.NewAtTime(uint(time.Hour()), uint(time.Minute()), uint(time.Second())),
+	job, err := r.scheduler.NewJob(
+		gocron.CronJob(crontab, false),
+		gocron.NewTask(r.run, schedule.ScheduleID),
+		gocron.WithSingletonMode(gocron.LimitModeReschedule),
 	)
+	if err != nil {
+		return fmt.Errorf("error creating new job: %s", err)
+	}
+
+	r.mu.Lock()
+	r.jobs[schedule.ScheduleID.Bytes] = job.ID()
+	r.mu.Unlock()
+
+	return r.setNextRun(schedule.ScheduleID, crontab)
+}
+
+func (r *scheduleRunner) remove(scheduleID pgtype.UUID) {
+	r.mu.Lock()
+	jobID, ok := r.jobs[scheduleID.Bytes]
+	delete(r.jobs, scheduleID.Bytes)
+	r.mu.Unlock()
+
+	if ok {
+		if err := r.scheduler.RemoveJob(jobID); err != nil && !errors.Is(err, gocron.ErrJobNotFound) {
+			logger.Error("Error removing scan schedule %s: %v", response.UuidToString(scheduleID), err)
+		}
+	}
+}
+
+// run queues a scan job for the schedule, as its root account, against the
+// assets its target currently matches.
+func (r *scheduleRunner) run(scheduleID pgtype.UUID) {
+	ctx := context.Background()
+
+	schedule, err := r.h.queries.GetScanSchedule(ctx, scheduleID)
+	if err != nil {
+		logger.Error("Scan schedule %s: %v", response.UuidToString(scheduleID), err)
+		return
+	}
+	if !schedule.Enabled {
+		return
+	}
+
+	job, err := r.h.runSchedule(ctx, schedule)
+
+	run := query.RecordScanScheduleRunParams{ScheduleID: scheduleID, LastJobID: job.JobID}
+	if err != nil {
+		run.LastError = pgtype.Text{String: err.Error(), Valid: true}
+		logger.Error("Scan schedule %s: %v", response.UuidToString(scheduleID), err)
+	}
+	if err := r.h.queries.RecordScanScheduleRun(ctx, run); err != nil {
+		logger.Error("Scan schedule %s: failed to record run: %v", response.UuidToString(scheduleID), err)
+	}
+
+	if crontab, err := cronSpec(schedule.CronExpression, schedule.Timezone); err == nil {
+		if err := r.setNextRun(scheduleID, crontab); err != nil {
+			logger.Error("Scan schedule %s: failed to record next run: %v", response.UuidToString(scheduleID), err)
+		}
+	}
+}
 
-	var jobInterval uint
+func (h *Handler) runSchedule(ctx context.Context, schedule query.ScanSchedule) (query.ScanJob, error) {
+	var scanFlags flags.FlagSet
+	if err := json.Unmarshal(schedule.Flags, &scanFlags); err != nil {
+		return query.ScanJob{}, fmt.Errorf("error decoding scan flags: %v", err)
+	}
 
-	switch frequency {
-	case "daily":
-		jobInterval = 1
-	case "weekly":
-		jobInterval = 7
-	case "monthly":
-		jobInterval = 30
-	default:
-		jobInterval = 1
+	_, scanFlags, err := decodeStoredFlags(schedule.ScannerName, scanFlags)
+	if err != nil {
+		return query.ScanJob{}, err
 	}
 
-	scanner, err := strategies.GetScanner(defaultScanner)
+	requestedBy, requestedByType, err := h.scheduleOwner(ctx, schedule)
 	if err != nil {
-		return fmt.Errorf("error retrieving scanner: %s", err)
+		return query.ScanJob{}, err
 	}
 
-	flags := scanner.DefaultFlags()
+	job, err := h.createJob(ctx, schedule.ScannerName, scanFlags, scheduleTarget(schedule), schedule.RootAccountID, requestedBy, requestedByType)
+	if err != nil {
+		return query.ScanJob{}, err
+	}
 
-	job := gocron.DailyJob(jobInterval, startTime)
-	task := gocron.NewTask(h.LaunchScan, defaultScanner, flags)
+	enqueue(h, job.JobID)
+	return job, nil
+}
 
-	_, err = scheduler.NewJob(
-		job,
-		task,
-	)
+// scheduleOwner returns the account a scheduled scan runs as: the one that
+// created the schedule, or the root account if that IAM user was removed.
+func (h *Handler) scheduleOwner(ctx context.Context, schedule query.ScanSchedule) (pgtype.UUID, string, error) {
+	if schedule.CreatedBy == schedule.RootAccountID {
+		return schedule.RootAccountID, "root", nil
+	}
 
+	_, err := h.queries.GetRootAccountIDForIAMUser(ctx, schedule.CreatedBy)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return schedule.RootAccountID, "root", nil
+	}
 	if err != nil {
-		return fmt.Errorf("error creating new job: %s", err)
+		return pgtype.UUID{}, "", fmt.Errorf("error retrieving schedule owner: %v", err)
 	}
+	return schedule.CreatedBy, "iam", nil
+}
 
-	scheduler.Start()
+func (r *scheduleRunner) setNextRun(scheduleID pgtype.UUID, crontab string) error {
+	next, err := nextRun(crontab, time.Now())
+	if err != nil {
+		return err
+	}
 
-	return nil
+	return r.h.queries.SetScanScheduleNextRun(context.Background(), query.SetScanScheduleNextRunParams{
+		ScheduleID: scheduleID,
+		NextRunAt:  pgtype.Timestamptz{Time: next, Valid: !next.IsZero()},
+	})
 }
 
-func ShutdownScheduler() {
-	if scheduler != nil {
-		scheduler.Shutdown()
-		fmt.Println("Scheduler stopped gracefully.")
-	} else {
-		fmt.Println("Scheduler was not initialized.")
+// cronSpec combines a standard five field cron expression, or a descriptor
+// such as @daily, with the IANA timezone it is evaluated in.
+func cronSpec(expression string, timezone string) (string, error) {
+	expression = strings.TrimSpace(expression)
+	if expression == "" {
+		return "", fmt.Errorf("cron expression is required")
+	}
+	if strings.HasPrefix(expression, "TZ=") || strings.HasPrefix(expression, "CRON_TZ=") {
+		return "", fmt.Errorf("set the timezone separately from the cron expression")
+	}
+
+	if timezone == "" {
+		timezone = "UTC"
+	}
+	if _, err := time.LoadLocation(timezone); err != nil {
+		return "", fmt.Errorf("unknown timezone %q", timezone)
+	}
+
+	crontab := fmt.Sprintf("CRON_TZ=%s %s", timezone, expression)
+	if _, err := cron.ParseStandard(crontab); err != nil {
+		return "", fmt.Errorf("invalid cron expression: %v", err)
+	}
+	return crontab, nil
+}
+
+// nextRun uses the same parser as gocron, so it matches when the job fires.
+func nextRun(crontab string, after time.Time) (time.Time, error) {
+	schedule, err := cron.ParseStandard(crontab)
+	if err != nil {
+		return time.Time{}, err
 	}
+	return schedule.Next(after), nil
 }
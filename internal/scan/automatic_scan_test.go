@@ -1 +1,79 @@
 package scan
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/SyntinelNyx/syntinel-server/internal/scan/flags"
+)
+
+func TestCronSpec(t *testing.T) {
+	crontab, err := cronSpec("30 2 * * 1", "Asia/Singapore")
+	require.NoError(t, err)
+	assert.Equal(t, "CRON_TZ=Asia/Singapore 30 2 * * 1", crontab)
+
+	// Monday 2:30 in Singapore is Sunday 18:30 UTC.
+	next, err := nextRun(crontab, time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC))
+	require.NoError(t, err)
+	assert.Equal(t, time.Date(2024, 6, 2, 18, 30, 0, 0, time.UTC), next.UTC())
+
+	crontab, err = cronSpec("@daily", "")
+	require.NoError(t, err)
+	assert.Equal(t, "CRON_TZ=UTC @daily", crontab)
+
+	for _, tc := range []struct{ expression, timezone string }{
+		{"", "UTC"},
+		{"61 * * * *", "UTC"},
+		{"0 3 * * *", "Mars/Olympus_Mons"},
+		{"CRON_TZ=UTC 0 3 * * *", "UTC"},
+	} {
+		_, err := cronSpec(tc.expression, tc.timezone)
+		assert.Error(t, err, tc.expression)
+	}
+}
+
+func TestScheduleRequestValidate(t *testing.T) {
+	scanFlags := flags.FlagSet{{Label: "Filesystem", InputType: "string", Value: "/"}}
+
+	params, err := ScheduleRequest{
+		Name:    "Nightly",
+		Cron:    "0 3 * * *",
+		Scanner: "trivy",
+		Flags:   scanFlags,
+		Target:  ScanTarget{Environments: []string{"3f1c2f7e-8a4b-4e0c-9d1a-5b6e7f8a9b0c"}, Tags: []string{"web", ""}},
+	}.validate()
+	require.NoError(t, err)
+	assert.Equal(t, "UTC", params.timezone)
+	assert.True(t, params.enabled)
+	assert.Len(t, params.target.environmentIDs, 1)
+	assert.Empty(t, params.target.assetIDs)
+	assert.Equal(t, []string{"web"}, params.target.tags)
+	assert.JSONEq(t, `[
+		{"label": "Filesystem", "inputType": "string", "value": "/", "required": false},
+		{"label": "Scanners", "inputType": "strings", "value": ["vuln"], "required": false, "options": ["vuln", "secret", "misconfig", "license"]},
+		{"label": "Severity", "inputType": "strings", "value": ["HIGH", "CRITICAL"], "required": false, "options": ["UNKNOWN", "LOW", "MEDIUM", "HIGH", "CRITICAL"]},
+		{"label": "IgnoreUnfixed", "inputType": "boolean", "value": false, "required": false},
+		{"label": "SkipFiles", "inputType": "strings", "value": ["./file.js", "./docs/**/*.md"], "required": false},
+		{"label": "SkipDirectory", "inputType": "strings", "value": ["/docs/", "/testfiles/*"], "required": false}
+	]`, string(params.flags))
+
+	disabled := false
+	for name, req := range map[string]ScheduleRequest{
+		"no name":     {Cron: "0 3 * * *", Scanner: "trivy", Flags: scanFlags, Target: ScanTarget{Tags: []string{"web"}}},
+		"bad cron":    {Name: "x", Cron: "daily", Scanner: "trivy", Flags: scanFlags, Target: ScanTarget{Tags: []string{"web"}}},
+		"bad scanner": {Name: "x", Cron: "0 3 * * *", Scanner: "nessus", Flags: scanFlags, Target: ScanTarget{Tags: []string{"web"}}},
+		"empty path":  {Name: "x", Cron: "0 3 * * *", Scanner: "trivy", Flags: flags.FlagSet{{Label: "Filesystem", Value: ""}}, Target: ScanTarget{Tags: []string{"web"}}},
+		"no target":   {Name: "x", Cron: "0 3 * * *", Scanner: "trivy", Flags: scanFlags, Enabled: &disabled},
+		"bad asset":   {Name: "x", Cron: "0 3 * * *", Scanner: "trivy", Flags: scanFlags, Target: ScanTarget{Assets: []string{"web-01"}}},
+		"hostname":    {Name: "x", Cron: "0 3 * * *", Scanner: "trivy", Flags: scanFlags, Target: ScanTarget{Hostnames: []string{"web-01"}}},
+		"option path": {Name: "x", Cron: "0 3 * * *", Scanner: "trivy", Flags: flags.FlagSet{{Label: "Filesystem", Value: "--config=/tmp/x"}}, Target: ScanTarget{Tags: []string{"web"}}},
+		"no scanners": {Name: "x", Cron: "0 3 * * *", Scanner: "trivy", Flags: append(flags.FlagSet{{Label: "Scanners", Value: []any{}}}, scanFlags...), Target: ScanTarget{Tags: []string{"web"}}},
+		"bad flag":    {Name: "x", Cron: "0 3 * * *", Scanner: "trivy", Flags: append(flags.FlagSet{{Label: "IgnoreUnfixed", Value: "yes"}}, scanFlags...), Target: ScanTarget{Tags: []string{"web"}}},
+	} {
+		_, err := req.validate()
+		assert.Error(t, err, name)
+	}
+}
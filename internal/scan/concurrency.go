@@ -0,0 +1,99 @@
+package scan
+
+import (
+	"context"
+	"sync"
+
+	"github.com/spf13/viper"
+)
+
+const (
+	defaultAccountConcurrency = 8
+	defaultScannerConcurrency = 16
+)
+
+// Scans of individual assets are bounded per root account and per scanner,
+// across every job that is running. Limits are read from
+// scan.concurrency.{per_account,per_scanner}, and may be overridden with
+// scan.concurrency.accounts.<root account ID> and
+// scan.concurrency.scanners.<scanner name>.
+var limits = &limiter{slots: make(map[string]*slots)}
+
+type limiter struct {
+	mu    sync.Mutex
+	slots map[string]*slots
+}
+
+// slots counts the scans holding a key. The limit is read again whenever a
+// scan waits for the key, so configuration changes apply without a restart.
+type slots struct {
+	used  int
+	freed chan struct{}
+}
+
+func (l *limiter) acquire(ctx context.Context, key string, limit func() int) (func(), error) {
+	for {
+		l.mu.Lock()
+		s, ok := l.slots[key]
+		if !ok {
+			s = &slots{freed: make(chan struct{})}
+			l.slots[key] = s
+		}
+		if s.used < max(limit(), 1) {
+			s.used++
+			l.mu.Unlock()
+			return func() { l.release(s) }, nil
+		}
+		freed := s.freed
+		l.mu.Unlock()
+
+		select {
+		case <-freed:
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+}
+
+func (l *limiter) release(s *slots) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	s.used--
+	close(s.freed)
+	s.freed = make(chan struct{})
+}
+
+// acquireScanSlot waits for a free slot for both the account and the scanner.
+// Slots are always taken in that order so that waiting scans cannot deadlock.
+func acquireScanSlot(ctx context.Context, rootAccountID string, scannerName string) (func(), error) {
+	releaseAccount, err := limits.acquire(ctx, "account:"+rootAccountID, func() int {
+		return concurrencyLimit("accounts", rootAccountID, "per_account", defaultAccountConcurrency)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	releaseScanner, err := limits.acquire(ctx, "scanner:"+scannerName, func() int {
+		return concurrencyLimit("scanners", scannerName, "per_scanner", defaultScannerConcurrency)
+	})
+	if err != nil {
+		releaseAccount()
+		return nil, err
+	}
+
+	return func() {
+		releaseScanner()
+		releaseAccount()
+	}, nil
+}
+
+func concurrencyLimit(group string, name string, fallbackKey string, fallback int) int {
+	if key := "scan.concurrency." + group + "." + name; viper.IsSet(key) {
+		return viper.GetInt(key)
+	}
+	if key := "scan.concurrency." + fallbackKey; viper.IsSet(key) {
+		return viper.GetInt(key)
+	}
+	return fallback
+}
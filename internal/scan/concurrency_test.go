@@ -0,0 +1,97 @@
+package scan
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/SyntinelNyx/syntinel-server/internal/vuln"
+)
+
+func TestScanSlotsBoundConcurrency(t *testing.T) {
+	viper.Set("scan.concurrency.accounts.account-a", 2)
+	t.Cleanup(func() { viper.Set("scan.concurrency.accounts.account-a", nil) })
+
+	var running, peak atomic.Int32
+	var wg sync.WaitGroup
+	for range 10 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			release, err := acquireScanSlot(context.Background(), "account-a", "trivy")
+			require.NoError(t, err)
+			defer release()
+
+			n := running.Add(1)
+			for {
+				p := peak.Load()
+				if n <= p || peak.CompareAndSwap(p, n) {
+					break
+				}
+			}
+			time.Sleep(10 * time.Millisecond)
+			running.Add(-1)
+		}()
+	}
+	wg.Wait()
+
+	assert.EqualValues(t, 2, peak.Load())
+}
+
+func TestScanSlotHonoursContext(t *testing.T) {
+	viper.Set("scan.concurrency.scanners.slow", 1)
+	t.Cleanup(func() { viper.Set("scan.concurrency.scanners.slow", nil) })
+
+	release, err := acquireScanSlot(context.Background(), "account-b", "slow")
+	require.NoError(t, err)
+	defer release()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+
+	_, err = acquireScanSlot(ctx, "account-c", "slow")
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+
+	// The account slot taken before the scanner slot timed out is given back.
+	release, err = acquireScanSlot(context.Background(), "account-c", "other")
+	require.NoError(t, err)
+	release()
+}
+
+func TestScanSlotsFollowConfig(t *testing.T) {
+	viper.Set("scan.concurrency.accounts.account-d", 1)
+	t.Cleanup(func() { viper.Set("scan.concurrency.accounts.account-d", nil) })
+
+	release, err := acquireScanSlot(context.Background(), "account-d", "trivy")
+	require.NoError(t, err)
+	defer release()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	_, err = acquireScanSlot(ctx, "account-d", "trivy")
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+
+	viper.Set("scan.concurrency.accounts.account-d", 2)
+	second, err := acquireScanSlot(context.Background(), "account-d", "trivy")
+	require.NoError(t, err)
+	second()
+}
+
+func TestMergeVulns(t *testing.T) {
+	seen := make(map[string]vuln.Vulnerability)
+
+	mergeVulns(seen, []vuln.Vulnerability{{ID: "CVE-1", Name: "one"}, {ID: "CVE-2", Name: "two"}}, []string{"CVE-2"})
+	mergeVulns(seen, []vuln.Vulnerability{{ID: "CVE-1", Name: "other"}, {ID: "CVE-3", Name: "three"}}, nil)
+
+	assert.Equal(t, "one", seen["CVE-1"].Name)
+	assert.Equal(t, vuln.Vulnerability{}, seen["CVE-2"])
+	assert.Equal(t, "three", seen["CVE-3"].Name)
+	assert.Len(t, seen, 3)
+}
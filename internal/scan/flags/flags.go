@@ -1,9 +1,37 @@
 package flags
 
 type Flag struct {
-	Label     string `json:"label"`
-	InputType string `json:"inputType"`
-	Value     any    `json:"value"`
-	Required  bool   `json:"required"`
+	Label     string   `json:"label"`
+	InputType string   `json:"inputType"`
+	Value     any      `json:"value"`
+	Required  bool     `json:"required"`
+	Options   []string `json:"options,omitempty"`
 }
+
 type FlagSet []Flag
+
+// Bool, String and Strings read a flag of a FlagSet returned by
+// Schema.Decode, where every value has the Go type of its flag's Type.
+func (s FlagSet) Bool(label string) bool {
+	value, _ := s.value(label).(bool)
+	return value
+}
+
+func (s FlagSet) String(label string) string {
+	value, _ := s.value(label).(string)
+	return value
+}
+
+func (s FlagSet) Strings(label string) []string {
+	value, _ := s.value(label).([]string)
+	return value
+}
+
+func (s FlagSet) value(label string) any {
+	for _, flag := range s {
+		if flag.Label == label {
+			return flag.Value
+		}
+	}
+	return nil
+}
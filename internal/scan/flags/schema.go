@@ -0,0 +1,210 @@
+package flags
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+)
+
+type Type string
+
+const (
+	String  Type = "string"
+	Bool    Type = "boolean"
+	Strings Type = "strings"
+	Enum    Type = "enum"
+)
+
+// Spec declares a flag a scanner accepts. Options lists the values allowed
+// for an Enum flag, or for each item of a Strings flag. Validate, if set, is
+// called with the decoded value.
+type Spec struct {
+	Label    string
+	Type     Type
+	Default  any
+	Required bool
+	Options  []string
+	Validate func(value any) error
+}
+
+type Schema []Spec
+
+type FieldError struct {
+	Field   string `json:"field"`
+	Message string `json:"message"`
+}
+
+// ValidationError lists every flag that failed to decode.
+type ValidationError struct {
+	Fields []FieldError
+}
+
+func (e *ValidationError) Error() string {
+	var fields []string
+	for _, field := range e.Fields {
+		fields = append(fields, field.Field+": "+field.Message)
+	}
+	return "invalid flags: " + strings.Join(fields, "; ")
+}
+
+// Defaults returns the flags of the schema with their default values, as
+// shown to clients building a scan.
+func (s Schema) Defaults() FlagSet {
+	set := FlagSet{}
+	for _, spec := range s {
+		set = append(set, spec.flag(spec.Default))
+	}
+	return set
+}
+
+// Known splits set into the flags the schema declares and the labels of
+// those it does not.
+func (s Schema) Known(set FlagSet) (FlagSet, []string) {
+	known := FlagSet{}
+	var unknown []string
+	for _, flag := range set {
+		if slices.ContainsFunc(s, func(spec Spec) bool { return spec.Label == flag.Label }) {
+			known = append(known, flag)
+		} else {
+			unknown = append(unknown, flag.Label)
+		}
+	}
+	return known, unknown
+}
+
+// Decode checks set against the schema and returns it with every flag of
+// the schema, in schema order, holding a value of the Go type of its Type:
+// string, bool or []string. Flags that are left out get their default.
+func (s Schema) Decode(set FlagSet) (FlagSet, error) {
+	var fieldErrors []FieldError
+	values := make(map[string]any)
+
+	for _, flag := range set {
+		if !slices.ContainsFunc(s, func(spec Spec) bool { return spec.Label == flag.Label }) {
+			fieldErrors = append(fieldErrors, FieldError{flag.Label, "unknown flag"})
+			continue
+		}
+		if _, exists := values[flag.Label]; exists {
+			fieldErrors = append(fieldErrors, FieldError{flag.Label, "specified more than once"})
+			continue
+		}
+		values[flag.Label] = flag.Value
+	}
+
+	decoded := FlagSet{}
+	for _, spec := range s {
+		value, err := spec.decode(values[spec.Label])
+		if err != nil {
+			fieldErrors = append(fieldErrors, FieldError{spec.Label, err.Error()})
+			continue
+		}
+		decoded = append(decoded, spec.flag(value))
+	}
+
+	if len(fieldErrors) > 0 {
+		return nil, &ValidationError{Fields: fieldErrors}
+	}
+	return decoded, nil
+}
+
+func (spec Spec) flag(value any) Flag {
+	return Flag{
+		Label:     spec.Label,
+		InputType: string(spec.Type),
+		Value:     value,
+		Required:  spec.Required,
+		Options:   spec.Options,
+	}
+}
+
+func (spec Spec) decode(raw any) (any, error) {
+	if raw == nil {
+		if spec.Required {
+			return nil, fmt.Errorf("is required")
+		}
+		return spec.Default, nil
+	}
+
+	var value any
+	switch spec.Type {
+	case String, Enum:
+		str, ok := raw.(string)
+		if !ok {
+			return nil, fmt.Errorf("must be a string")
+		}
+		if str == "" && spec.Required {
+			return nil, fmt.Errorf("is required")
+		}
+		if spec.Type == Enum && !slices.Contains(spec.Options, str) {
+			return nil, fmt.Errorf("must be one of %s", strings.Join(spec.Options, ", "))
+		}
+		value = str
+
+	case Bool:
+		b, ok := raw.(bool)
+		if !ok {
+			return nil, fmt.Errorf("must be a boolean")
+		}
+		value = b
+
+	case Strings:
+		list, err := decodeStrings(raw)
+		if err != nil {
+			return nil, err
+		}
+		if len(list) == 0 && spec.Required {
+			return nil, fmt.Errorf("is required")
+		}
+		if len(spec.Options) > 0 {
+			for _, item := range list {
+				if !slices.Contains(spec.Options, item) {
+					return nil, fmt.Errorf("%q is not one of %s", item, strings.Join(spec.Options, ", "))
+				}
+			}
+		}
+		value = list
+
+	default:
+		return nil, fmt.Errorf("unsupported flag type %q", spec.Type)
+	}
+
+	if spec.Validate != nil {
+		if err := spec.Validate(value); err != nil {
+			return nil, err
+		}
+	}
+	return value, nil
+}
+
+// decodeStrings accepts a list as decoded from JSON, or a comma separated
+// string as sent by older clients.
+func decodeStrings(raw any) ([]string, error) {
+	list := []string{}
+	switch v := raw.(type) {
+	case []string:
+		for _, item := range v {
+			if item != "" {
+				list = append(list, item)
+			}
+		}
+	case []any:
+		for _, item := range v {
+			str, ok := item.(string)
+			if !ok {
+				return nil, fmt.Errorf("must be a list of strings")
+			}
+			if str != "" {
+				list = append(list, str)
+			}
+		}
+	case string:
+		for _, item := range strings.Split(v, ",") {
+			if item = strings.TrimSpace(item); item != "" {
+				list = append(list, item)
+			}
+		}
+	default:
+		return nil, fmt.Errorf("must be a list of strings")
+	}
+	return list, nil
+}
@@ -0,0 +1,68 @@
+package flags
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var testSchema = Schema{
+	{Label: "Path", Type: String, Default: "/", Required: true},
+	{Label: "Verbose", Type: Bool, Default: false},
+	{Label: "Format", Type: Enum, Default: "json", Options: []string{"json", "table"}},
+	{Label: "Severity", Type: Strings, Default: []string{"HIGH"}, Options: []string{"LOW", "HIGH"}},
+	{Label: "Exclude", Type: Strings, Default: []string{}, Validate: func(value any) error {
+		if len(value.([]string)) > 2 {
+			return errors.New("must have at most 2 patterns")
+		}
+		return nil
+	}},
+}
+
+func TestSchemaDecode(t *testing.T) {
+	decoded, err := testSchema.Decode(FlagSet{
+		{Label: "Path", InputType: "bool", Value: "/srv"},
+		{Label: "Verbose", Value: true},
+		{Label: "Severity", Value: "LOW, HIGH"},
+		{Label: "Exclude", Value: []any{"./a", ""}},
+	})
+	require.NoError(t, err)
+	require.Len(t, decoded, len(testSchema))
+	assert.Equal(t, "string", decoded[0].InputType)
+	assert.Equal(t, "/srv", decoded.String("Path"))
+	assert.True(t, decoded.Bool("Verbose"))
+	assert.Equal(t, "json", decoded.String("Format"))
+	assert.Equal(t, []string{"LOW", "HIGH"}, decoded.Strings("Severity"))
+	assert.Equal(t, []string{"./a"}, decoded.Strings("Exclude"))
+
+	_, err = testSchema.Decode(FlagSet{
+		{Label: "Verbose", Value: "true"},
+		{Label: "Format", Value: "xml"},
+		{Label: "Severity", Value: []any{"MEDIUM"}},
+		{Label: "Exclude", Value: []any{"./a", "./b", "./c"}},
+		{Label: "Exclude", Value: []any{}},
+		{Label: "Colour", Value: true},
+	})
+	var validationErr *ValidationError
+	require.ErrorAs(t, err, &validationErr)
+	assert.Equal(t, []FieldError{
+		{"Exclude", "specified more than once"},
+		{"Colour", "unknown flag"},
+		{"Path", "is required"},
+		{"Verbose", "must be a boolean"},
+		{"Format", "must be one of json, table"},
+		{"Severity", `"MEDIUM" is not one of LOW, HIGH`},
+		{"Exclude", "must have at most 2 patterns"},
+	}, validationErr.Fields)
+}
+
+func TestSchemaDefaults(t *testing.T) {
+	defaults := testSchema.Defaults()
+	assert.Equal(t, Flag{Label: "Path", InputType: "string", Value: "/", Required: true}, defaults[0])
+
+	decoded, err := testSchema.Decode(defaults)
+	require.NoError(t, err)
+	assert.Equal(t, defaults, decoded)
+}
@@ -0,0 +1,218 @@
+package scan
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"slices"
+	"strings"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+
+	"github.com/SyntinelNyx/syntinel-server/internal/auth"
+	"github.com/SyntinelNyx/syntinel-server/internal/database/query"
+	"github.com/SyntinelNyx/syntinel-server/internal/finding"
+	"github.com/SyntinelNyx/syntinel-server/internal/response"
+	"github.com/SyntinelNyx/syntinel-server/internal/scan/flags"
+	"github.com/SyntinelNyx/syntinel-server/internal/scan/sbom"
+	"github.com/SyntinelNyx/syntinel-server/internal/scan/strategies"
+	"github.com/SyntinelNyx/syntinel-server/internal/vuln"
+)
+
+var maxImportSize int64 = 50 << 20
+
+// importedScan is what an uploaded document reports. findingTypes are the
+// types of finding the document is a complete account of; earlier findings
+// of other types are left as they are.
+type importedScan struct {
+	scannerName     string
+	findingTypes    []string
+	vulnerabilities []vuln.Vulnerability
+	findings        []finding.Finding
+}
+
+// ImportSBOM records the vulnerabilities in a CycloneDX or SPDX document as a
+// scan of the asset, for hosts that cannot run a scanner themselves.
+func (h *Handler) ImportSBOM(w http.ResponseWriter, r *http.Request) {
+	h.importUpload(w, r, "SBOM", func(data []byte) (importedScan, error) {
+		doc, err := sbom.Parse(data)
+		if err != nil {
+			return importedScan{}, err
+		}
+
+		imported := importedScan{scannerName: doc.Format, vulnerabilities: doc.Vulnerabilities}
+		if doc.Assessed {
+			imported.findingTypes = []string{finding.TypeVulnerability}
+		}
+		return imported, nil
+	})
+}
+
+// ImportResults records the raw output of a registered scanner, run on an
+// asset the server cannot reach, as a scan of that asset. The optional
+// scanners query parameter lists what the scanner was run to look for, in
+// the form of its Scanners flag, and defaults like the flag does.
+func (h *Handler) ImportResults(w http.ResponseWriter, r *http.Request) {
+	scanner, err := strategies.GetScanner(chi.URLParam(r, "scanner"))
+	if err != nil {
+		response.RespondWithError(w, r, http.StatusBadRequest, "Unknown scanner", err)
+		return
+	}
+
+	set := flags.FlagSet{}
+	if scanners := r.URL.Query().Get("scanners"); scanners != "" {
+		set = append(set, flags.Flag{Label: "Scanners", Value: scanners})
+	}
+	scanFlags, err := scanner.FlagSchema().Decode(set)
+	if err != nil {
+		response.RespondWithError(w, r, http.StatusBadRequest, err.Error(), err)
+		return
+	}
+
+	h.importUpload(w, r, "scanner output", func(data []byte) (importedScan, error) {
+		vulnerabilities, err := scanner.ParseResults(string(data))
+		if err != nil {
+			return importedScan{}, err
+		}
+
+		findings, err := strategies.ParseFindings(scanner, string(data))
+		if err != nil {
+			return importedScan{}, err
+		}
+
+		return importedScan{
+			scannerName:     scanner.Name(),
+			findingTypes:    strategies.FindingTypes(scanner, scanFlags),
+			vulnerabilities: vulnerabilities,
+			findings:        findings,
+		}, nil
+	})
+}
+
+// importUpload reads an uploaded document for the asset in the URL, parses
+// it and records what it reports as a scan attributed to the uploader.
+func (h *Handler) importUpload(w http.ResponseWriter, r *http.Request, kind string, parse func([]byte) (importedScan, error)) {
+	var assetID pgtype.UUID
+	if err := assetID.Scan(chi.URLParam(r, "assetID")); err != nil {
+		response.RespondWithError(w, r, http.StatusBadRequest, "Invalid AssetID format", err)
+		return
+	}
+
+	data, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxImportSize))
+	var maxBytesErr *http.MaxBytesError
+	if errors.As(err, &maxBytesErr) {
+		response.RespondWithError(w, r, http.StatusRequestEntityTooLarge, fmt.Sprintf("Upload exceeds the %d MB limit", maxImportSize>>20), err)
+		return
+	}
+	if err != nil {
+		response.RespondWithError(w, r, http.StatusBadRequest, "Invalid Request Body", err)
+		return
+	}
+	if len(bytes.TrimSpace(data)) == 0 {
+		response.RespondWithError(w, r, http.StatusBadRequest, "Empty "+kind, nil)
+		return
+	}
+
+	imported, err := parse(data)
+	if err != nil {
+		response.RespondWithError(w, r, http.StatusBadRequest, fmt.Sprintf("Failed to parse %s: %v", kind, err), err)
+		return
+	}
+
+	claims := auth.GetClaims(r.Context())
+
+	rootId, err := h.rootAccountID(r)
+	if err != nil {
+		response.RespondWithError(w, r, http.StatusInternalServerError, "Failed to get associated root account", err)
+		return
+	}
+
+	_, err = h.queries.GetAssetEndpointForRootAccount(context.Background(), query.GetAssetEndpointForRootAccountParams{
+		AssetID:       assetID,
+		RootAccountID: rootId,
+	})
+	if errors.Is(err, pgx.ErrNoRows) {
+		response.RespondWithError(w, r, http.StatusNotFound, "Asset not found", err)
+		return
+	}
+	if err != nil {
+		response.RespondWithError(w, r, http.StatusInternalServerError, "Failed to get asset", err)
+		return
+	}
+
+	scanUUID, err := h.importFindings(context.Background(), imported, assetID, rootId, claims.AccountID, claims.AccountType)
+	if err != nil {
+		response.RespondWithError(w, r, http.StatusInternalServerError, "Failed to import "+kind, err)
+		return
+	}
+
+	response.RespondWithJSON(w, http.StatusCreated, map[string]any{
+		"message":            "Scan Imported",
+		"scanId":             response.UuidToString(scanUUID),
+		"scannerName":        imported.scannerName,
+		"vulnerabilityCount": len(imported.vulnerabilities),
+		"findingCount":       len(imported.findings),
+	})
+}
+
+// importFindings records vulnerabilities and other findings found outside of
+// a scan job as a new scan of a single asset. The scan has no path, so it
+// only resolves vulnerabilities last reported by an import of the same
+// scanner.
+func (h *Handler) importFindings(ctx context.Context, imported importedScan, assetID pgtype.UUID, rootAccountID pgtype.UUID, accountID pgtype.UUID, accountType string) (pgtype.UUID, error) {
+	assetIDs := []pgtype.UUID{assetID}
+	target, err := targetParams{assetIDs: assetIDs}.marshal()
+	if err != nil {
+		return pgtype.UUID{}, err
+	}
+
+	unchanged, err := h.addVulnerabilities(ctx, imported.vulnerabilities)
+	if err != nil {
+		return pgtype.UUID{}, err
+	}
+
+	if err := h.addFindingData(ctx, imported.findings); err != nil {
+		return pgtype.UUID{}, err
+	}
+
+	// The scan is only created once everything the upload reports is
+	// recorded with it.
+	var scanUUID pgtype.UUID
+	err = h.inTx(ctx, func(tx *Handler) error {
+		var err error
+		scanUUID, err = tx.createScanEntry(ctx, imported.scannerName, "", rootAccountID, accountID, accountType, target, assetIDs)
+		if err != nil {
+			return err
+		}
+
+		if err := tx.recordFindings(ctx, assetID, scanUUID, imported.vulnerabilities); err != nil {
+			return err
+		}
+
+		return tx.recordOtherFindings(ctx, assetID, scanUUID, imported.findings)
+	})
+	if err != nil {
+		return pgtype.UUID{}, err
+	}
+
+	// An inventory without vulnerability data, or output of a scan that did
+	// not look for them, must not resolve the vulnerabilities found earlier.
+	var errs []string
+	if slices.Contains(imported.findingTypes, finding.TypeVulnerability) {
+		allVulnsSeen := make(map[string]vuln.Vulnerability)
+		mergeVulns(allVulnsSeen, imported.vulnerabilities, unchanged)
+		errs = h.updateVulnerabilities(ctx, rootAccountID, scanUUID, assetIDs, allVulnsSeen)
+	}
+	errs = append(errs, h.updateFindings(ctx, rootAccountID, scanUUID, assetIDs, imported.findingTypes)...)
+
+	if len(errs) > 0 {
+		return scanUUID, fmt.Errorf("%s", strings.Join(errs, "\n"))
+	}
+
+	return scanUUID, nil
+}
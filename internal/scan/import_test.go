@@ -0,0 +1,82 @@
+package scan
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/assert"
+)
+
+func importRequest(body string, params map[string]string) *http.Request {
+	routeCtx := chi.NewRouteContext()
+	for key, value := range params {
+		routeCtx.URLParams.Add(key, value)
+	}
+
+	req := httptest.NewRequest(http.MethodPost, "/scan/import", strings.NewReader(body))
+	return req.WithContext(context.WithValue(req.Context(), chi.RouteCtxKey, routeCtx))
+}
+
+func TestImportRejectsBadUploads(t *testing.T) {
+	h := NewHandler(nil)
+	assetID := "3f1c2f7e-8a4b-4e0c-9d1a-5b6e7f8a9b0c"
+
+	tests := []struct {
+		name    string
+		handler http.HandlerFunc
+		body    string
+		params  map[string]string
+		code    int
+		message string
+	}{
+		{"unknown scanner", h.ImportResults, "{}", map[string]string{"scanner": "nessus", "assetID": assetID}, http.StatusBadRequest, "Unknown scanner"},
+		{"invalid asset", h.ImportResults, "{}", map[string]string{"scanner": "trivy", "assetID": "web-01"}, http.StatusBadRequest, "Invalid AssetID format"},
+		{"empty output", h.ImportResults, " \n", map[string]string{"scanner": "trivy", "assetID": assetID}, http.StatusBadRequest, "Empty scanner output"},
+		{"malformed output", h.ImportResults, "Results: none", map[string]string{"scanner": "grype", "assetID": assetID}, http.StatusBadRequest, "Failed to parse scanner output"},
+		{"unknown document", h.ImportSBOM, `{"name": "billing-api"}`, map[string]string{"assetID": assetID}, http.StatusBadRequest, "Failed to parse SBOM"},
+		{"too large", h.ImportSBOM, strings.Repeat(" ", 1<<20+1), map[string]string{"assetID": assetID}, http.StatusRequestEntityTooLarge, "Upload exceeds the 1 MB limit"},
+	}
+
+	defer func(size int64) { maxImportSize = size }(maxImportSize)
+	maxImportSize = 1 << 20
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rr := httptest.NewRecorder()
+			tt.handler(rr, importRequest(tt.body, tt.params))
+
+			assert.Equal(t, tt.code, rr.Code)
+			assert.Contains(t, rr.Body.String(), tt.message)
+		})
+	}
+}
+
+func TestImportResultsRejectsInvalidScanners(t *testing.T) {
+	h := NewHandler(nil)
+
+	tests := []struct {
+		name    string
+		scanner string
+		query   string
+	}{
+		{"scanner without scanners flag", "grype", "secret"},
+		{"unknown finding type", "trivy", "vuln,nessus"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := importRequest("{}", map[string]string{"scanner": tt.scanner, "assetID": "3f1c2f7e-8a4b-4e0c-9d1a-5b6e7f8a9b0c"})
+			req.URL.RawQuery = "scanners=" + tt.query
+
+			rr := httptest.NewRecorder()
+			h.ImportResults(rr, req)
+
+			assert.Equal(t, http.StatusBadRequest, rr.Code)
+			assert.Contains(t, rr.Body.String(), "Scanners")
+		})
+	}
+}
@@ -0,0 +1,222 @@
+package scan
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/spf13/viper"
+
+	"github.com/SyntinelNyx/syntinel-server/internal/auth"
+	"github.com/SyntinelNyx/syntinel-server/internal/database/query"
+	"github.com/SyntinelNyx/syntinel-server/internal/logger"
+	"github.com/SyntinelNyx/syntinel-server/internal/response"
+)
+
+const defaultJobWorkers = 2
+
+var jobs chan pgtype.UUID
+
+type JobProgress struct {
+	Total     int32 `json:"total"`
+	Succeeded int32 `json:"succeeded"`
+	Failed    int32 `json:"failed"`
+}
+
+type JobSummary struct {
+	JobID       string      `json:"jobId"`
+	ScannerName string      `json:"scannerName"`
+	Status      string      `json:"status"`
+	Error       string      `json:"error,omitempty"`
+	ScanID      string      `json:"scanId,omitempty"`
+	CreatedAt   string      `json:"createdAt"`
+	StartedAt   string      `json:"startedAt,omitempty"`
+	FinishedAt  string      `json:"finishedAt,omitempty"`
+	Progress    JobProgress `json:"progress"`
+}
+
+type JobAsset struct {
+	AssetID            string `json:"assetId"`
+	Hostname           string `json:"hostname"`
+	Status             string `json:"status"`
+	Error              string `json:"error,omitempty"`
+	VulnerabilityCount int32  `json:"vulnerabilityCount"`
+	StartedAt          string `json:"startedAt,omitempty"`
+	FinishedAt         string `json:"finishedAt,omitempty"`
+}
+
+type JobDetails struct {
+	JobSummary
+	Assets []JobAsset `json:"assets"`
+}
+
+// StartJobs starts the workers that run queued scan jobs and requeues any
+// job left queued or running by a previous server process.
+func StartJobs(h *Handler) error {
+	workers := defaultJobWorkers
+	if viper.IsSet("scan.workers") {
+		workers = max(viper.GetInt("scan.workers"), 1)
+	}
+
+	jobs = make(chan pgtype.UUID, 1024)
+	for range workers {
+		go func() {
+			for jobID := range jobs {
+				if err := h.RunJob(context.Background(), jobID); err != nil {
+					logger.Error("Scan job %s: %v", response.UuidToString(jobID), err)
+				}
+			}
+		}()
+	}
+
+	unfinished, err := h.queries.GetUnfinishedScanJobs(context.Background())
+	if err != nil {
+		return err
+	}
+	for _, jobID := range unfinished {
+		enqueue(h, jobID)
+	}
+
+	return nil
+}
+
+func enqueue(h *Handler, jobID pgtype.UUID) {
+	if jobs == nil {
+		go h.RunJob(context.Background(), jobID)
+		return
+	}
+
+	select {
+	case jobs <- jobID:
+	default:
+		go func() { jobs <- jobID }()
+	}
+}
+
+func formatTime(t pgtype.Timestamptz) string {
+	if !t.Valid {
+		return ""
+	}
+	return t.Time.Format(time.RFC3339)
+}
+
+func (h *Handler) rootAccountID(r *http.Request) (pgtype.UUID, error) {
+	account := auth.GetClaims(r.Context())
+	if account.AccountType == "root" {
+		return account.AccountID, nil
+	}
+	return h.queries.GetRootAccountIDForIAMUser(context.Background(), account.AccountID)
+}
+
+func (h *Handler) ListJobs(w http.ResponseWriter, r *http.Request) {
+	rootId, err := h.rootAccountID(r)
+	if err != nil {
+		response.RespondWithError(w, r, http.StatusInternalServerError, "Failed to get associated root account", err)
+		return
+	}
+
+	rows, err := h.queries.ListScanJobs(context.Background(), rootId)
+	if err != nil {
+		response.RespondWithError(w, r, http.StatusInternalServerError, "Failed to list scan jobs", err)
+		return
+	}
+
+	summaries := []JobSummary{}
+	for _, row := range rows {
+		summaries = append(summaries, JobSummary{
+			JobID:       response.UuidToString(row.JobID),
+			ScannerName: row.ScannerName,
+			Status:      row.Status,
+			Error:       row.Error.String,
+			ScanID:      uuidOrEmpty(row.ScanID),
+			CreatedAt:   formatTime(row.CreatedAt),
+			StartedAt:   formatTime(row.StartedAt),
+			FinishedAt:  formatTime(row.FinishedAt),
+			Progress: JobProgress{
+				Total:     row.TotalAssets,
+				Succeeded: row.SucceededAssets,
+				Failed:    row.FailedAssets,
+			},
+		})
+	}
+
+	response.RespondWithJSON(w, http.StatusOK, summaries)
+}
+
+func (h *Handler) RetrieveJob(w http.ResponseWriter, r *http.Request) {
+	rootId, err := h.rootAccountID(r)
+	if err != nil {
+		response.RespondWithError(w, r, http.StatusInternalServerError, "Failed to get associated root account", err)
+		return
+	}
+
+	var jobID pgtype.UUID
+	if err := jobID.Scan(chi.URLParam(r, "jobID")); err != nil {
+		response.RespondWithError(w, r, http.StatusBadRequest, "Invalid JobID format", err)
+		return
+	}
+
+	job, err := h.queries.GetScanJobForRootAccount(context.Background(), query.GetScanJobForRootAccountParams{
+		JobID:         jobID,
+		RootAccountID: rootId,
+	})
+	if errors.Is(err, pgx.ErrNoRows) {
+		response.RespondWithError(w, r, http.StatusNotFound, "Scan job not found", err)
+		return
+	}
+	if err != nil {
+		response.RespondWithError(w, r, http.StatusInternalServerError, "Failed to get scan job", err)
+		return
+	}
+
+	rows, err := h.queries.GetScanJobAssets(context.Background(), jobID)
+	if err != nil {
+		response.RespondWithError(w, r, http.StatusInternalServerError, "Failed to get scan job assets", err)
+		return
+	}
+
+	details := JobDetails{
+		JobSummary: JobSummary{
+			JobID:       response.UuidToString(job.JobID),
+			ScannerName: job.ScannerName,
+			Status:      job.Status,
+			Error:       job.Error.String,
+			ScanID:      uuidOrEmpty(job.ScanID),
+			CreatedAt:   formatTime(job.CreatedAt),
+			StartedAt:   formatTime(job.StartedAt),
+			FinishedAt:  formatTime(job.FinishedAt),
+			Progress:    JobProgress{Total: int32(len(rows))},
+		},
+		Assets: []JobAsset{},
+	}
+	for _, row := range rows {
+		switch row.Status {
+		case JobSucceeded:
+			details.Progress.Succeeded++
+		case JobFailed:
+			details.Progress.Failed++
+		}
+		details.Assets = append(details.Assets, JobAsset{
+			AssetID:            response.UuidToString(row.AssetID),
+			Hostname:           row.Hostname.String,
+			Status:             row.Status,
+			Error:              row.Error.String,
+			VulnerabilityCount: row.VulnerabilityCount,
+			StartedAt:          formatTime(row.StartedAt),
+			FinishedAt:         formatTime(row.FinishedAt),
+		})
+	}
+
+	response.RespondWithJSON(w, http.StatusOK, details)
+}
+
+func uuidOrEmpty(id pgtype.UUID) string {
+	if !id.Valid {
+		return ""
+	}
+	return response.UuidToString(id)
+}
@@ -1,7 +1,9 @@
 package scan
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
 	"net/http"
 
 	"github.com/SyntinelNyx/syntinel-server/internal/auth"
@@ -10,9 +12,12 @@ import (
 	"github.com/SyntinelNyx/syntinel-server/internal/scan/flags"
 )
 
+// LaunchScanRequest selects the assets to scan with Target. Assets is a list
+// of hostnames, kept for older clients, and is added to the target.
 type LaunchScanRequest struct {
 	Scanner string        `json:"scanner"`
 	Assets  []string      `json:"assets"`
+	Target  ScanTarget    `json:"target"`
 	Flags   flags.FlagSet `json:"flags"`
 }
 
@@ -25,18 +30,53 @@ func (h *Handler) Launch(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	if req.Scanner == "" || len(req.Assets) == 0 {
+	target := req.Target
+	target.Hostnames = append(target.Hostnames, req.Assets...)
+
+	if req.Scanner == "" || len(target.Assets)+len(target.Environments)+len(target.Tags)+len(target.Hostnames) == 0 {
 		response.RespondWithError(w, r, http.StatusBadRequest, "Missing scanner or assets", nil)
 		return
 	}
 
-	err := h.LaunchScan(req.Scanner, req.Flags, req.Assets, claims.AccountID, claims.AccountType)
+	job, err := h.CreateJob(context.Background(), req.Scanner, req.Flags, target, claims.AccountID, claims.AccountType)
+	if respondWithFlagErrors(w, r, "Invalid scan flags", err) {
+		return
+	}
+	if errors.Is(err, ErrNoAssets) {
+		response.RespondWithError(w, r, http.StatusNotFound, "No matching assets found", err)
+		return
+	}
+	if errors.Is(err, ErrInvalidScan) {
+		response.RespondWithError(w, r, http.StatusBadRequest, "Failed to Launch Scan", err)
+		return
+	}
 	if err != nil {
-		// Logger used for PR test, remove once successful
 		logger.Error("%s", err)
 		response.RespondWithError(w, r, http.StatusInternalServerError, "Failed to Launch Scan", err)
 		return
 	}
 
-	response.RespondWithJSON(w, http.StatusOK, map[string]string{"message": "Scan Launched Successfully"})
+	enqueue(h, job.JobID)
+
+	response.RespondWithJSON(w, http.StatusAccepted, map[string]string{
+		"message": "Scan Queued",
+		"jobId":   response.UuidToString(job.JobID),
+		"status":  job.Status,
+	})
+}
+
+// respondWithFlagErrors responds with each invalid flag listed when err is a
+// *flags.ValidationError, and reports whether it did.
+func respondWithFlagErrors(w http.ResponseWriter, r *http.Request, message string, err error) bool {
+	var flagErr *flags.ValidationError
+	if !errors.As(err, &flagErr) {
+		return false
+	}
+
+	response.SetError(r, err)
+	response.RespondWithJSON(w, http.StatusBadRequest, map[string]any{
+		"error":  message,
+		"fields": flagErr.Fields,
+	})
+	return true
 }
@@ -1,6 +1,7 @@
 package scan
 
 import (
+	"encoding/json"
 	"fmt"
 	"net/http"
 	"time"
@@ -10,11 +11,13 @@ import (
 )
 
 type scanResponse struct {
-	ScanID      string `json:"id"`
-	ScanDate    string `json:"scanDate"`
-	ScannerName string `json:"scannerName"`
-	ScannedBy   string `json:"scannedBy"`
-	Notes       string `json:"notes"`
+	ScanID      string     `json:"id"`
+	ScanDate    string     `json:"scanDate"`
+	ScannerName string     `json:"scannerName"`
+	ScannedBy   string     `json:"scannedBy"`
+	Notes       string     `json:"notes"`
+	Target      ScanTarget `json:"target"`
+	AssetIDs    []string   `json:"assetIds"`
 }
 
 func (h *Handler) Retrieve(w http.ResponseWriter, r *http.Request) {
@@ -45,6 +48,11 @@ func (h *Handler) Retrieve(w http.ResponseWriter, r *http.Request) {
 			ScannerName: scan.ScannerName,
 			ScannedBy:   scan.RootAccountUsername,
 			Notes:       scan.Notes.String,
+			AssetIDs:    []string{},
+		}
+		json.Unmarshal(scan.Target, &resp.Target)
+		for _, id := range scan.AssetIds {
+			resp.AssetIDs = append(resp.AssetIDs, response.UuidToString(id))
 		}
 
 		scansList = append(scansList, resp)
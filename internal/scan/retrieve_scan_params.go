@@ -38,14 +38,7 @@ func (h *Handler) RetrieveScanParameters(w http.ResponseWriter, r *http.Request)
 		scanner, _ := strategies.GetScanner(scannerName)
 
 		scannerFlags[scannerName] = map[string]flags.FlagSet{
-			"flags": append(flags.FlagSet{
-				{
-					Label:     "Filesystem",
-					InputType: "string",
-					Value:     "/",
-					Required:  true,
-				},
-			}, scanner.DefaultFlags()...),
+			"flags": flagSchema(scanner).Defaults(),
 		}
 	}
 
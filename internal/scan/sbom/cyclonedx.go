@@ -0,0 +1,147 @@
+package sbom
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/SyntinelNyx/syntinel-server/internal/vuln"
+)
+
+type cycloneDXSource struct {
+	Name string `json:"name"`
+	URL  string `json:"url"`
+}
+
+type cycloneDXComponent struct {
+	BOMRef     string               `json:"bom-ref"`
+	Name       string               `json:"name"`
+	Version    string               `json:"version"`
+	PURL       string               `json:"purl"`
+	Components []cycloneDXComponent `json:"components"`
+}
+
+type cycloneDXDocument struct {
+	Components      []cycloneDXComponent `json:"components"`
+	Vulnerabilities []struct {
+		ID         string          `json:"id"`
+		Source     cycloneDXSource `json:"source"`
+		References []struct {
+			ID     string          `json:"id"`
+			Source cycloneDXSource `json:"source"`
+		} `json:"references"`
+		Ratings []struct {
+			Score    float64 `json:"score"`
+			Severity string  `json:"severity"`
+			Method   string  `json:"method"`
+		} `json:"ratings"`
+		Description string `json:"description"`
+		Detail      string `json:"detail"`
+		Advisories  []struct {
+			URL string `json:"url"`
+		} `json:"advisories"`
+		Created   string `json:"created"`
+		Published string `json:"published"`
+		Updated   string `json:"updated"`
+		Analysis  struct {
+			State string `json:"state"`
+		} `json:"analysis"`
+		Affects []struct {
+			Ref string `json:"ref"`
+		} `json:"affects"`
+	} `json:"vulnerabilities"`
+}
+
+// Rating methods in order of preference when a vulnerability has several.
+var cycloneDXMethods = []string{"CVSSv4", "CVSSv31", "CVSSv3", "CVSSv2", "OWASP", "SSVC", "other"}
+
+func parseCycloneDX(data []byte) ([]vuln.Vulnerability, error) {
+	var doc cycloneDXDocument
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return nil, fmt.Errorf("invalid CycloneDX document: %v", err)
+	}
+
+	components := make(map[string]vuln.Package)
+	indexComponents(components, doc.Components)
+
+	var found results
+	for _, v := range doc.Vulnerabilities {
+		switch v.Analysis.State {
+		case "not_affected", "false_positive", "resolved", "resolved_with_pedigree":
+			continue
+		}
+		if v.ID == "" {
+			continue
+		}
+
+		var aliases []string
+		references := []string{v.Source.URL}
+		for _, ref := range v.References {
+			aliases = append(aliases, ref.ID)
+			references = append(references, ref.Source.URL)
+		}
+		for _, advisory := range v.Advisories {
+			references = append(references, advisory.URL)
+		}
+
+		var score float64
+		var rating string
+		rank := len(cycloneDXMethods)
+		for _, r := range v.Ratings {
+			i := indexOf(cycloneDXMethods, r.Method)
+			if i < rank || (i == rank && rating == "") {
+				rank, score, rating = i, r.Score, r.Severity
+			}
+		}
+
+		description := v.Description
+		if description == "" {
+			description = v.Detail
+		}
+
+		id := vuln.PreferredID(v.ID, aliases)
+		result := vuln.Vulnerability{
+			ID:           id,
+			Name:         id,
+			Description:  description,
+			Severity:     severity(rating, score),
+			CVSSScore:    score,
+			CreatedOn:    parseTime(v.Published, v.Created),
+			LastModified: parseTime(v.Updated, v.Published, v.Created),
+			References:   references,
+		}
+		for _, affects := range v.Affects {
+			if pkg, ok := components[affects.Ref]; ok {
+				result.AddPackage(pkg)
+			} else {
+				result.AddPackage(purlPackage(affects.Ref))
+			}
+		}
+		found.add(result)
+	}
+
+	return found.list, nil
+}
+
+func indexComponents(index map[string]vuln.Package, components []cycloneDXComponent) {
+	for _, component := range components {
+		pkg := purlPackage(component.PURL)
+		pkg.Name = component.Name
+		pkg.InstalledVersion = component.Version
+		if component.BOMRef != "" {
+			index[component.BOMRef] = pkg
+		}
+		if component.PURL != "" {
+			index[component.PURL] = pkg
+		}
+		indexComponents(index, component.Components)
+	}
+}
+
+func indexOf(list []string, value string) int {
+	for i, item := range list {
+		if item == value {
+			return i
+		}
+	}
+	return len(list)
+}
@@ -0,0 +1,193 @@
+package sbom
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/SyntinelNyx/syntinel-server/internal/vuln"
+)
+
+const (
+	FormatCycloneDX = "cyclonedx"
+	FormatSPDX      = "spdx"
+)
+
+var ErrUnknownFormat = errors.New("document is not a CycloneDX or SPDX JSON document")
+
+// Document is what a CycloneDX or SPDX document reports about
+// vulnerabilities.
+type Document struct {
+	Format          string
+	Vulnerabilities []vuln.Vulnerability
+
+	// Assessed is false for a plain inventory that carries no vulnerability
+	// data, which says nothing about vulnerabilities found earlier. SPDX can
+	// only link the vulnerabilities that were found, so an SPDX document is
+	// assessed if it links any.
+	Assessed bool
+}
+
+// Parse detects the format of a CycloneDX or SPDX JSON document and returns
+// the vulnerabilities it reports. Vulnerabilities that a VEX statement in the
+// document marks as not affecting the software are left out.
+func Parse(data []byte) (Document, error) {
+	var header struct {
+		BOMFormat       string          `json:"bomFormat"`
+		Vulnerabilities json.RawMessage `json:"vulnerabilities"`
+		SPDXVersion     string          `json:"spdxVersion"`
+		Context         json.RawMessage `json:"@context"`
+		Graph           json.RawMessage `json:"@graph"`
+	}
+
+	data = bytes.TrimSpace(data)
+	if len(data) == 0 || data[0] != '{' {
+		return Document{}, ErrUnknownFormat
+	}
+	if err := json.Unmarshal(data, &header); err != nil {
+		return Document{}, fmt.Errorf("invalid JSON document: %v", err)
+	}
+
+	var doc Document
+	var err error
+	switch {
+	case header.BOMFormat == "CycloneDX":
+		doc.Format = FormatCycloneDX
+		doc.Vulnerabilities, err = parseCycloneDX(data)
+		doc.Assessed = header.Vulnerabilities != nil
+	case strings.HasPrefix(header.SPDXVersion, "SPDX-2"):
+		doc.Format = FormatSPDX
+		doc.Vulnerabilities, err = parseSPDX2(data)
+		doc.Assessed = len(doc.Vulnerabilities) > 0
+	case header.Graph != nil && bytes.Contains(header.Context, []byte("spdx.org")):
+		doc.Format = FormatSPDX
+		doc.Vulnerabilities, err = parseSPDX3(data)
+		doc.Assessed = len(doc.Vulnerabilities) > 0
+	default:
+		return Document{}, ErrUnknownFormat
+	}
+	if err != nil {
+		return Document{}, err
+	}
+	return doc, nil
+}
+
+// results collects vulnerabilities in the order they are first reported,
+// merging repeated reports of the same ID.
+type results struct {
+	list []vuln.Vulnerability
+	seen map[string]int
+}
+
+func (r *results) add(v vuln.Vulnerability) {
+	if r.seen == nil {
+		r.seen = make(map[string]int)
+	}
+
+	i, exists := r.seen[v.ID]
+	if !exists {
+		r.seen[v.ID] = len(r.list)
+		v.References = appendUnique(nil, v.References...)
+		r.list = append(r.list, v)
+		return
+	}
+
+	existing := &r.list[i]
+	if existing.Description == "" {
+		existing.Description = v.Description
+	}
+	if v.CVSSScore > existing.CVSSScore {
+		existing.CVSSScore = v.CVSSScore
+		existing.Severity = v.Severity
+	}
+	if existing.Severity == "Unknown" {
+		existing.Severity = v.Severity
+	}
+	if v.LastModified.After(existing.LastModified) {
+		existing.LastModified = v.LastModified
+	}
+	existing.References = appendUnique(existing.References, v.References...)
+	for _, pkg := range v.Packages {
+		existing.AddPackage(pkg)
+	}
+}
+
+func severity(rating string, score float64) string {
+	switch strings.ToLower(rating) {
+	case "critical":
+		return "Critical"
+	case "high":
+		return "High"
+	case "medium", "moderate":
+		return "Medium"
+	case "low", "info", "none":
+		return "Low"
+	}
+
+	switch {
+	case score >= 9:
+		return "Critical"
+	case score >= 7:
+		return "High"
+	case score >= 4:
+		return "Medium"
+	case score > 0:
+		return "Low"
+	default:
+		return "Unknown"
+	}
+}
+
+// purlPackage reads the name, version and type of a package URL such as
+// pkg:npm/lodash@4.17.20. Anything that is not a package URL gives an empty
+// package.
+func purlPackage(purl string) vuln.Package {
+	rest, ok := strings.CutPrefix(purl, "pkg:")
+	if !ok {
+		return vuln.Package{}
+	}
+	rest, _, _ = strings.Cut(rest, "?")
+	rest, _, _ = strings.Cut(rest, "#")
+
+	pkgType, rest, ok := strings.Cut(rest, "/")
+	if !ok {
+		return vuln.Package{}
+	}
+	name, version, _ := strings.Cut(rest, "@")
+	if unescaped, err := url.PathUnescape(name); err == nil {
+		name = unescaped
+	}
+	if unescaped, err := url.PathUnescape(version); err == nil {
+		version = unescaped
+	}
+	if i := strings.LastIndex(name, "/"); i >= 0 {
+		name = name[i+1:]
+	}
+
+	return vuln.Package{Name: name, InstalledVersion: version, Type: pkgType}
+}
+
+// parseTime accepts the RFC 3339 timestamps used by both formats, returning
+// the zero time for anything else.
+func parseTime(values ...string) time.Time {
+	for _, value := range values {
+		if t, err := time.Parse(time.RFC3339, value); err == nil {
+			return t
+		}
+	}
+	return time.Time{}
+}
+
+func appendUnique(list []string, values ...string) []string {
+	for _, value := range values {
+		if value != "" && !slices.Contains(list, value) {
+			list = append(list, value)
+		}
+	}
+	return list
+}
@@ -0,0 +1,125 @@
+package sbom
+
+import (
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/SyntinelNyx/syntinel-server/internal/vuln"
+)
+
+func TestParseCycloneDX(t *testing.T) {
+	data, err := os.ReadFile("testdata/cyclonedx.json")
+	require.NoError(t, err)
+
+	doc, err := Parse(data)
+	require.NoError(t, err)
+	assert.Equal(t, FormatCycloneDX, doc.Format)
+	assert.True(t, doc.Assessed)
+	vulnerabilities := doc.Vulnerabilities
+	require.Len(t, vulnerabilities, 2)
+
+	log4j := vulnerabilities[0]
+	assert.Equal(t, "CVE-2021-44228", log4j.ID)
+	assert.Equal(t, "Critical", log4j.Severity)
+	assert.Equal(t, 10.0, log4j.CVSSScore)
+	assert.Equal(t, 2021, log4j.CreatedOn.Year())
+	assert.Equal(t, 2023, log4j.LastModified.Year())
+	assert.Equal(t, []string{
+		"https://github.com/advisories/GHSA-jfh8-c2jp-5v3q",
+		"https://nvd.nist.gov/vuln/detail/CVE-2021-44228",
+		"https://logging.apache.org/log4j/2.x/security.html",
+		"https://www.cisa.gov/known-exploited-vulnerabilities-catalog",
+	}, log4j.References)
+	assert.Equal(t, []vuln.Package{
+		{Name: "log4j-core", InstalledVersion: "2.14.1", Type: "maven"},
+		{Name: "log4j-api", InstalledVersion: "2.14.1", Type: "maven"},
+	}, log4j.Packages)
+
+	lodash := vulnerabilities[1]
+	assert.Equal(t, "CVE-2021-23337", lodash.ID)
+	assert.Equal(t, "High", lodash.Severity)
+	assert.Contains(t, lodash.Description, "Command Injection")
+	assert.Equal(t, lodash.CreatedOn, lodash.LastModified)
+	assert.Equal(t, []vuln.Package{{Name: "lodash", InstalledVersion: "4.17.20", Type: "npm"}}, lodash.Packages)
+}
+
+func TestParseSPDX2(t *testing.T) {
+	data, err := os.ReadFile("testdata/spdx-2.json")
+	require.NoError(t, err)
+
+	doc, err := Parse(data)
+	require.NoError(t, err)
+	assert.Equal(t, FormatSPDX, doc.Format)
+	assert.True(t, doc.Assessed)
+	vulnerabilities := doc.Vulnerabilities
+	require.Len(t, vulnerabilities, 2)
+
+	assert.Equal(t, "CVE-2021-23337", vulnerabilities[0].ID)
+	assert.Equal(t, "Unknown", vulnerabilities[0].Severity)
+	assert.Equal(t, "CVE-2021-44228", vulnerabilities[1].ID)
+	assert.Len(t, vulnerabilities[1].References, 2)
+	assert.Equal(t, []vuln.Package{{Name: "lodash", InstalledVersion: "4.17.20", Type: "npm"}}, vulnerabilities[0].Packages)
+	assert.Equal(t, []vuln.Package{
+		{Name: "log4j-core", InstalledVersion: "2.14.1"},
+		{Name: "log4j-api", InstalledVersion: "2.14.1"},
+	}, vulnerabilities[1].Packages)
+}
+
+func TestAdvisoryID(t *testing.T) {
+	tests := map[string]string{
+		"https://nvd.nist.gov/vuln/detail/CVE-2021-44228":       "CVE-2021-44228",
+		"https://github.com/advisories/GHSA-jfh8-c2jp-5v3q/":    "GHSA-jfh8-c2jp-5v3q",
+		"https://osv.dev/vulnerability/PYSEC-2021-123":          "PYSEC-2021-123",
+		"https://rustsec.org/advisories/RUSTSEC-2021-0001.html": "RUSTSEC-2021-0001",
+		"https://www.openssl.org/news/secadv/20231024.txt":      "",
+		"https://example.com/":                                  "",
+		"not a url\x7f":                                         "",
+	}
+
+	for locator, id := range tests {
+		assert.Equal(t, id, advisoryID(locator), locator)
+	}
+}
+
+func TestParseSPDX3(t *testing.T) {
+	data, err := os.ReadFile("testdata/spdx-3.json")
+	require.NoError(t, err)
+
+	doc, err := Parse(data)
+	require.NoError(t, err)
+	assert.Equal(t, FormatSPDX, doc.Format)
+	assert.True(t, doc.Assessed)
+	vulnerabilities := doc.Vulnerabilities
+	require.Len(t, vulnerabilities, 1)
+
+	log4j := vulnerabilities[0]
+	assert.Equal(t, "CVE-2021-44228", log4j.ID)
+	assert.Equal(t, "Critical", log4j.Severity)
+	assert.Equal(t, 10.0, log4j.CVSSScore)
+	assert.Equal(t, 2023, log4j.LastModified.Year())
+	assert.Equal(t, []vuln.Package{{Name: "log4j-core", InstalledVersion: "2.14.1"}}, log4j.Packages)
+}
+
+func TestParseUnknownFormat(t *testing.T) {
+	for _, doc := range []string{"", `<bom xmlns="http://cyclonedx.org/schema/bom/1.5"/>`, `{"name": "x"}`, "SPDXVersion: SPDX-2.3"} {
+		_, err := Parse([]byte(doc))
+		assert.ErrorIs(t, err, ErrUnknownFormat, doc)
+	}
+
+	_, err := Parse([]byte(`{"bomFormat": "CycloneDX", "vulnerabilities": {}}`))
+	assert.Error(t, err)
+}
+
+func TestParseInventory(t *testing.T) {
+	doc, err := Parse([]byte(`{"bomFormat": "CycloneDX", "components": [{"name": "lodash", "version": "4.17.20"}]}`))
+	require.NoError(t, err)
+	assert.False(t, doc.Assessed)
+
+	doc, err = Parse([]byte(`{"bomFormat": "CycloneDX", "vulnerabilities": []}`))
+	require.NoError(t, err)
+	assert.True(t, doc.Assessed)
+	assert.Empty(t, doc.Vulnerabilities)
+}
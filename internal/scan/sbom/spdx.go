@@ -0,0 +1,205 @@
+package sbom
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"path"
+	"regexp"
+	"strings"
+
+	"github.com/SyntinelNyx/syntinel-server/internal/vuln"
+)
+
+type spdx2Document struct {
+	Packages []struct {
+		Name         string `json:"name"`
+		VersionInfo  string `json:"versionInfo"`
+		ExternalRefs []struct {
+			ReferenceCategory string `json:"referenceCategory"`
+			ReferenceType     string `json:"referenceType"`
+			ReferenceLocator  string `json:"referenceLocator"`
+		} `json:"externalRefs"`
+	} `json:"packages"`
+}
+
+// SPDX 2 has no vulnerability elements, only advisory links on packages, so
+// the vulnerability ID is taken from the end of the advisory URL. Links that
+// do not end in a recognised ID are skipped.
+func parseSPDX2(data []byte) ([]vuln.Vulnerability, error) {
+	var doc spdx2Document
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return nil, fmt.Errorf("invalid SPDX document: %v", err)
+	}
+
+	var found results
+	for _, pkg := range doc.Packages {
+		affected := vuln.Package{Name: pkg.Name, InstalledVersion: pkg.VersionInfo}
+		for _, ref := range pkg.ExternalRefs {
+			if ref.ReferenceType == "purl" {
+				affected.Type = purlPackage(ref.ReferenceLocator).Type
+			}
+		}
+
+		for _, ref := range pkg.ExternalRefs {
+			if !strings.EqualFold(ref.ReferenceCategory, "SECURITY") || ref.ReferenceType != "advisory" {
+				continue
+			}
+
+			id := advisoryID(ref.ReferenceLocator)
+			if id == "" {
+				continue
+			}
+
+			result := vuln.Vulnerability{
+				ID:         id,
+				Name:       id,
+				Severity:   "Unknown",
+				References: []string{ref.ReferenceLocator},
+			}
+			result.AddPackage(affected)
+			found.add(result)
+		}
+	}
+
+	return found.list, nil
+}
+
+// advisoryIDPattern matches CVE and GHSA IDs and the ecosystem IDs OSV
+// publishes, such as PYSEC-2021-123 or RUSTSEC-2021-0001.
+var advisoryIDPattern = regexp.MustCompile(`^(CVE-\d{4}-\d{4,}|GHSA(-[23456789cfghjmpqrvwx]{4}){3}|(OSV|PYSEC|RUSTSEC|GO|GSD|MAL)-\d{4}-[0-9A-Za-z-]+)$`)
+
+// advisoryID returns the vulnerability ID at the end of an advisory URL, or
+// "" if the URL does not end in one, as with a vendor's dated bulletin.
+func advisoryID(locator string) string {
+	u, err := url.Parse(locator)
+	if err != nil || u.Path == "" {
+		return ""
+	}
+
+	id := path.Base(strings.TrimSuffix(u.Path, "/"))
+	id = strings.TrimSuffix(id, path.Ext(id))
+	if !advisoryIDPattern.MatchString(id) {
+		return ""
+	}
+	return id
+}
+
+type spdx3Element struct {
+	Type               string `json:"type"`
+	SPDXID             string `json:"spdxId"`
+	Name               string `json:"name"`
+	Summary            string `json:"summary"`
+	Description        string `json:"description"`
+	ExternalIdentifier []struct {
+		Type       string `json:"externalIdentifierType"`
+		Identifier string `json:"identifier"`
+	} `json:"externalIdentifier"`
+	ExternalRef []struct {
+		Locator []string `json:"locator"`
+	} `json:"externalRef"`
+	PublishedTime string `json:"security_publishedTime"`
+	ModifiedTime  string `json:"security_modifiedTime"`
+
+	// Set on software packages.
+	PackageVersion string `json:"software_packageVersion"`
+	PackageURL     string `json:"software_packageUrl"`
+
+	// Set on vulnerability assessment relationships.
+	From     string   `json:"from"`
+	To       []string `json:"to"`
+	Score    float64  `json:"security_score"`
+	Severity string   `json:"security_severity"`
+}
+
+// Assessment relationship types that say a vulnerability does not affect the
+// software it is linked to.
+var spdx3Excluded = map[string]bool{
+	"security_VexNotAffectedVulnAssessmentRelationship": true,
+	"security_VexFixedVulnAssessmentRelationship":       true,
+}
+
+func parseSPDX3(data []byte) ([]vuln.Vulnerability, error) {
+	var doc struct {
+		Graph []spdx3Element `json:"@graph"`
+	}
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return nil, fmt.Errorf("invalid SPDX document: %v", err)
+	}
+
+	excluded := make(map[string]bool)
+	assessments := make(map[string][]spdx3Element)
+	affects := make(map[string][]string)
+	packages := make(map[string]vuln.Package)
+	for _, element := range doc.Graph {
+		switch {
+		case spdx3Excluded[element.Type]:
+			excluded[element.From] = true
+		case strings.HasPrefix(element.Type, "security_Cvss"):
+			assessments[element.From] = append(assessments[element.From], element)
+			affects[element.From] = append(affects[element.From], element.To...)
+		case strings.HasPrefix(element.Type, "security_Vex"):
+			affects[element.From] = append(affects[element.From], element.To...)
+		case element.Type == "software_Package":
+			pkg := purlPackage(element.PackageURL)
+			pkg.Name = element.Name
+			pkg.InstalledVersion = element.PackageVersion
+			packages[element.SPDXID] = pkg
+		}
+	}
+
+	var found results
+	for _, element := range doc.Graph {
+		if element.Type != "security_Vulnerability" || excluded[element.SPDXID] {
+			continue
+		}
+
+		var aliases, references []string
+		for _, identifier := range element.ExternalIdentifier {
+			aliases = append(aliases, identifier.Identifier)
+		}
+		for _, ref := range element.ExternalRef {
+			references = append(references, ref.Locator...)
+		}
+
+		id := element.Name
+		if id == "" && len(aliases) > 0 {
+			id = aliases[0]
+		}
+		id = vuln.PreferredID(id, aliases)
+		if id == "" {
+			continue
+		}
+
+		// CVSS v4 assessments sort after v2 and v3, so the newest version wins.
+		var score float64
+		var rating, method string
+		for _, assessment := range assessments[element.SPDXID] {
+			if assessment.Type >= method {
+				method, score, rating = assessment.Type, assessment.Score, assessment.Severity
+			}
+		}
+
+		description := element.Description
+		if description == "" {
+			description = element.Summary
+		}
+
+		result := vuln.Vulnerability{
+			ID:           id,
+			Name:         id,
+			Description:  description,
+			Severity:     severity(rating, score),
+			CVSSScore:    score,
+			CreatedOn:    parseTime(element.PublishedTime),
+			LastModified: parseTime(element.ModifiedTime, element.PublishedTime),
+			References:   references,
+		}
+		for _, to := range affects[element.SPDXID] {
+			result.AddPackage(packages[to])
+		}
+		found.add(result)
+	}
+
+	return found.list, nil
+}
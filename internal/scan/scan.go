@@ -2,207 +2,705 @@ package scan
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
-	"net"
+	"slices"
+	"sort"
 	"strings"
+	"sync"
 
+	"github.com/jackc/pgx/v5/pgtype"
+
+	"github.com/SyntinelNyx/syntinel-server/internal/capability"
 	"github.com/SyntinelNyx/syntinel-server/internal/commands"
 	"github.com/SyntinelNyx/syntinel-server/internal/database/query"
+	"github.com/SyntinelNyx/syntinel-server/internal/endpoint"
+	"github.com/SyntinelNyx/syntinel-server/internal/finding"
+	"github.com/SyntinelNyx/syntinel-server/internal/logger"
 	"github.com/SyntinelNyx/syntinel-server/internal/proto/controlpb"
+	"github.com/SyntinelNyx/syntinel-server/internal/response"
 	"github.com/SyntinelNyx/syntinel-server/internal/scan/flags"
 	"github.com/SyntinelNyx/syntinel-server/internal/scan/strategies"
 	"github.com/SyntinelNyx/syntinel-server/internal/vuln"
-	"github.com/jackc/pgx/v5/pgtype"
 )
 
-func (h *Handler) LaunchScan(scannerName string, flags flags.FlagSet, assetsList []string, accountID pgtype.UUID, accountType string) error {
-	ctx := context.Background()
+const (
+	JobQueued          = "queued"
+	JobRunning         = "running"
+	JobSucceeded       = "succeeded"
+	JobPartiallyFailed = "partially-failed"
+	JobFailed          = "failed"
+)
+
+var ErrNoAssets = errors.New("no assets found")
 
-	scanner, err := strategies.GetScanner(scannerName)
+// ErrInvalidScan wraps errors caused by the scanner, flags or target a scan
+// was requested with, as opposed to failures to create it.
+var ErrInvalidScan = errors.New("invalid scan request")
+
+// LaunchScan creates a scan job and runs it to completion before returning.
+func (h *Handler) LaunchScan(ctx context.Context, scannerName string, flags flags.FlagSet, target ScanTarget, accountID pgtype.UUID, accountType string) error {
+	job, err := h.CreateJob(ctx, scannerName, flags, target, accountID, accountType)
 	if err != nil {
-		return fmt.Errorf("failed to get find scanner \"%s\": %v", scannerName, err)
+		return err
 	}
 
-	var scanUUID pgtype.UUID
-	if accountType == "root" {
-		param := query.CreateScanEntryRootParams{
-			ScannerName:   scannerName,
-			RootAccountID: accountID,
-		}
+	return h.RunJob(ctx, job.JobID)
+}
 
-		scanUUID, err = h.queries.CreateScanEntryRoot(ctx, param)
+// CreateJob validates a scan request and records it as a queued job with one
+// entry per asset the target resolves to in the caller's root account.
+// Nothing is sent to the agents until the job is run.
+func (h *Handler) CreateJob(ctx context.Context, scannerName string, flags flags.FlagSet, target ScanTarget, accountID pgtype.UUID, accountType string) (query.ScanJob, error) {
+	_, flags, err := decodeFlags(scannerName, flags)
+	if err != nil {
+		return query.ScanJob{}, fmt.Errorf("%w: %w", ErrInvalidScan, err)
+	}
+
+	params, err := target.params()
+	if err != nil {
+		return query.ScanJob{}, fmt.Errorf("%w: %w", ErrInvalidScan, err)
+	}
 
+	rootID := accountID
+	if accountType != "root" {
+		rootID, err = h.queries.GetRootAccountIDForIAMUser(ctx, accountID)
 		if err != nil {
-			return fmt.Errorf("error creating scan entry as Root User: %v", err)
-		}
-	} else {
-		param := query.CreateScanEntryIAMUserParams{
-			ScannerName:   scannerName,
-			ScannedByUser: accountID,
+			return query.ScanJob{}, fmt.Errorf("error getting root account for IAM user: %v", err)
 		}
+	}
 
-		scanUUID, err = h.queries.CreateScanEntryIAMUser(ctx, param)
+	return h.createJob(ctx, scannerName, flags, params, rootID, accountID, accountType)
+}
 
-		if err != nil {
-			return fmt.Errorf("error creating scan entry as IAM User: %v", err)
-		}
+func (h *Handler) createJob(ctx context.Context, scannerName string, flags flags.FlagSet, target targetParams, rootID pgtype.UUID, accountID pgtype.UUID, accountType string) (query.ScanJob, error) {
+	assetIDs, err := h.resolveTarget(ctx, rootID, target)
+	if err != nil {
+		return query.ScanJob{}, err
+	}
+	if len(assetIDs) == 0 {
+		return query.ScanJob{}, ErrNoAssets
 	}
 
-	assets, err := h.queries.GetAssetsByHostnames(ctx, assetsList)
+	flagsJSON, err := json.Marshal(flags)
+	if err != nil {
+		return query.ScanJob{}, fmt.Errorf("error encoding scan flags: %v", err)
+	}
 
-	if err != nil || len(assets) == 0 {
-		h.queries.RemoveScanEntry(ctx, scanUUID)
-		return fmt.Errorf("error retrieving assets, no assets found")
+	targetJSON, err := target.marshal()
+	if err != nil {
+		return query.ScanJob{}, err
 	}
 
-	allVulnsSeen := make(map[string]vuln.Vulnerability)
+	job, err := h.queries.CreateScanJob(ctx, query.CreateScanJobParams{
+		RootAccountID:   rootID,
+		RequestedBy:     accountID,
+		RequestedByType: accountType,
+		ScannerName:     scannerName,
+		Flags:           flagsJSON,
+		Target:          targetJSON,
+	})
+	if err != nil {
+		return query.ScanJob{}, fmt.Errorf("error creating scan job: %v", err)
+	}
 
-	var filepath string
-	for _, flag := range flags {
-		if flag.Label == "Filesystem" {
-			if path, ok := flag.Value.(string); ok {
-				filepath = path
-			}
-			break
-		}
+	if err := h.queries.AddScanJobAssets(ctx, query.AddScanJobAssetsParams{
+		JobID:    job.JobID,
+		AssetIds: assetIDs,
+	}); err != nil {
+		return query.ScanJob{}, fmt.Errorf("error adding assets to scan job: %v", err)
 	}
-	if filepath == "" {
-		h.queries.RemoveScanEntry(ctx, scanUUID)
-		return fmt.Errorf("missing required 'Filesystem' flag")
+
+	return job, nil
+}
+
+// RunJob scans every asset of the job that has not finished yet, recording
+// progress as it goes, so a job interrupted by a restart can be run again.
+func (h *Handler) RunJob(ctx context.Context, jobID pgtype.UUID) error {
+	job, err := h.queries.GetScanJob(ctx, jobID)
+	if err != nil {
+		return fmt.Errorf("error retrieving scan job: %v", err)
 	}
 
-	canDeleteScanEntry := true
-	var globalErrors []string
-	var assetErrors []string
-	for _, asset := range assets {
-		payload, err := scanner.CalculateCommand(asset.Os.String, filepath, flags)
-		if err != nil {
-			assetErrors = append(assetErrors, fmt.Sprintf("asset %s: command generation failed: %v", asset.Hostname.String, err))
-			continue
-		}
+	var scanFlags flags.FlagSet
+	if err := json.Unmarshal(job.Flags, &scanFlags); err != nil {
+		return h.failJob(ctx, job, fmt.Errorf("error decoding scan flags: %v", err))
+	}
 
-		controlMessages := []*controlpb.ControlMessage{
-			{
-				Command: "exec",
-				Payload: payload,
-			},
-		}
+	scanner, scanFlags, err := decodeStoredFlags(job.ScannerName, scanFlags)
+	if err != nil {
+		return h.failJob(ctx, job, err)
+	}
 
-		ip := net.ParseIP(asset.IpAddress.String())
-		if ip == nil {
-			assetErrors = append(assetErrors, fmt.Sprintf("asset %s: invalid IP address (%s)", asset.Hostname.String, asset.IpAddress.String()))
-			continue
-		}
+	assets, err := h.queries.GetScanJobAssets(ctx, job.JobID)
+	if err != nil {
+		return h.failJob(ctx, job, fmt.Errorf("error retrieving assets: %v", err))
+	}
 
-		addr := asset.IpAddress.String()
-		if ip.To4() == nil {
-			addr = fmt.Sprintf("[%s]", addr)
+	filepath := scanFlags.String(filesystemFlag.Label)
+
+	scanUUID := job.ScanID
+	if !scanUUID.Valid {
+		assetIDs := []pgtype.UUID{}
+		for _, asset := range assets {
+			assetIDs = append(assetIDs, asset.AssetID)
 		}
-		target := fmt.Sprintf("%s:50051", addr)
 
-		responses, err := commands.Command(target, controlMessages)
+		scanUUID, err = h.createScanEntry(ctx, job.ScannerName, filepath, job.RootAccountID, job.RequestedBy, job.RequestedByType, job.Target, assetIDs)
 		if err != nil {
-			assetErrors = append(assetErrors, fmt.Sprintf("asset %s: command failed: %v", asset.Hostname.String, err))
-			continue
+			return h.failJob(ctx, job, err)
 		}
+	}
 
-		vulnerabilitiesList, err := scanner.ParseResults(responses[0].Result)
-		if err != nil {
-			assetErrors = append(assetErrors, fmt.Sprintf("asset %s: result parsing failed: %v", asset.Hostname.String, err))
+	if err := h.queries.StartScanJob(ctx, query.StartScanJobParams{JobID: job.JobID, ScanID: scanUUID}); err != nil {
+		return fmt.Errorf("error starting scan job: %v", err)
+	}
+
+	rootID := response.UuidToString(job.RootAccountID)
+
+	var (
+		mu           sync.Mutex
+		wg           sync.WaitGroup
+		scanned      []pgtype.UUID
+		assetErrors  []string
+		globalErrors []string
+		allVulnsSeen = make(map[string]vuln.Vulnerability)
+	)
+	for _, asset := range assets {
+		switch asset.Status {
+		case JobSucceeded:
+			scanned = append(scanned, asset.AssetID)
+			continue
+		case JobFailed:
+			assetErrors = append(assetErrors, fmt.Sprintf("asset %s: %s", asset.Hostname.String, asset.Error.String))
 			continue
 		}
 
-		var currentVulnIDs []string
-		unverifiedVulns := query.RetrieveUnchangedVulnerabilitiesParams{
-			VulnList:     []string{},
-			ModifiedList: []pgtype.Timestamptz{},
-		}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			release, err := acquireScanSlot(ctx, rootID, scanner.Name())
+			if err != nil {
+				// ctx may be what ended the wait, so the failure is recorded
+				// without it.
+				err = fmt.Errorf("failed to wait for a scan slot: %v", err)
+				progressErr := h.queries.FinishScanJobAsset(context.Background(), query.FinishScanJobAssetParams{
+					JobID:   job.JobID,
+					AssetID: asset.AssetID,
+					Status:  JobFailed,
+					Error:   pgtype.Text{String: err.Error(), Valid: true},
+				})
+
+				mu.Lock()
+				defer mu.Unlock()
+				assetErrors = append(assetErrors, fmt.Sprintf("asset %s: %v", asset.Hostname.String, err))
+				if progressErr != nil {
+					globalErrors = append(globalErrors, fmt.Sprintf("failed to update scan job progress: %v", progressErr))
+				}
+				return
+			}
+			defer release()
+
+			if err := h.queries.StartScanJobAsset(ctx, query.StartScanJobAssetParams{JobID: job.JobID, AssetID: asset.AssetID}); err != nil {
+				mu.Lock()
+				globalErrors = append(globalErrors, fmt.Sprintf("failed to update scan job progress: %v", err))
+				mu.Unlock()
+			}
 
-		for _, vuln := range vulnerabilitiesList {
-			currentVulnIDs = append(currentVulnIDs, vuln.ID)
+			found, findings, err := h.scanAsset(ctx, scanner, filepath, scanFlags, asset)
+			var unchanged []string
+			if err == nil {
+				unchanged, err = h.recordJobAsset(ctx, job.JobID, asset.AssetID, scanUUID, found, findings)
+			}
 
-			if _, exists := allVulnsSeen[vuln.ID]; !exists {
-				allVulnsSeen[vuln.ID] = vuln
-				unverifiedVulns.VulnList = append(unverifiedVulns.VulnList, vuln.ID)
-				unverifiedVulns.ModifiedList = append(unverifiedVulns.ModifiedList, pgtype.Timestamptz{Time: vuln.LastModified, Valid: true})
+			var progressErr error
+			if err != nil {
+				progressErr = h.queries.FinishScanJobAsset(ctx, query.FinishScanJobAssetParams{
+					JobID:   job.JobID,
+					AssetID: asset.AssetID,
+					Status:  JobFailed,
+					Error:   pgtype.Text{String: err.Error(), Valid: true},
+				})
 			}
-		}
 
-		err = h.queries.InsertNewVulnerabilities(ctx, unverifiedVulns.VulnList)
-		if err != nil {
-			assetErrors = append(assetErrors, fmt.Sprintf("asset %s: failed to insert new vulnerabiilities: %v", asset.Hostname.String, err))
-			continue
-		}
+			mu.Lock()
+			defer mu.Unlock()
+			if err != nil {
+				assetErrors = append(assetErrors, fmt.Sprintf("asset %s: %v", asset.Hostname.String, err))
+			} else {
+				scanned = append(scanned, asset.AssetID)
+				mergeVulns(allVulnsSeen, found, unchanged)
+			}
+			if progressErr != nil {
+				globalErrors = append(globalErrors, fmt.Sprintf("failed to update scan job progress: %v", progressErr))
+			}
+		}()
+	}
+	wg.Wait()
+	sort.Strings(assetErrors)
+
+	if len(scanned) == 0 {
+		h.removeScan(ctx, scanUUID)
+		job.ScanID = pgtype.UUID{}
+		return h.failJob(ctx, job, fmt.Errorf("scan completed with errors:\n%s", strings.Join(assetErrors, "\n")))
+	}
+
+	// A scan that did not look for a type of finding must not resolve the
+	// findings of that type reported earlier.
+	findingTypes := strategies.FindingTypes(scanner, scanFlags)
+	if slices.Contains(findingTypes, finding.TypeVulnerability) {
+		globalErrors = append(globalErrors, h.updateVulnerabilities(ctx, job.RootAccountID, scanUUID, scanned, allVulnsSeen)...)
+	}
+	globalErrors = append(globalErrors, h.updateFindings(ctx, job.RootAccountID, scanUUID, scanned, findingTypes)...)
+
+	status := JobSucceeded
+	var jobErr error
+	allErrors := append(assetErrors, globalErrors...)
+	if len(allErrors) > 0 {
+		status = JobPartiallyFailed
+		jobErr = fmt.Errorf("scan completed with errors:\n%s", strings.Join(allErrors, "\n"))
+	}
+
+	if err := h.finishJob(ctx, job.JobID, status, scanUUID, jobErr); err != nil {
+		return err
+	}
+
+	return jobErr
+}
+
+// scanAsset runs the scanner on one asset and returns the vulnerabilities and
+// other findings it reported.
+func (h *Handler) scanAsset(ctx context.Context, scanner strategies.Scanner, filepath string, flags flags.FlagSet, asset query.GetScanJobAssetsRow) ([]vuln.Vulnerability, []finding.Finding, error) {
+	// Prefer the OS the agent reports over the one recorded at enrollment.
+	osName := asset.Os.String
+	if capabilities, ok := capability.Get(asset.AssetID); ok && capabilities.OS != "" {
+		osName = capabilities.OS
+	}
 
-		unchangedVulns, err := h.queries.RetrieveUnchangedVulnerabilities(ctx, unverifiedVulns)
+	args, err := scanner.CalculateCommand(osName, filepath, flags)
+	if err != nil {
+		return nil, nil, fmt.Errorf("command generation failed: %v", err)
+	}
+
+	controlMessages := []*controlpb.ControlMessage{
+		commands.RunScan(scanner.Name(), filepath, args),
+	}
+
+	target, err := endpoint.New(asset.IpAddress, asset.AgentHostname, asset.AgentPort, asset.AgentEndpointOverride).Target()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	responses, err := commands.Command(ctx, asset.AssetID, target, controlMessages)
+	if err != nil {
+		return nil, nil, fmt.Errorf("command failed: %v", err)
+	}
+
+	if len(responses) == 0 {
+		return nil, nil, fmt.Errorf("no response from agent")
+	}
+
+	output, err := commands.CompleteOutput(responses[0], strategies.ExitCodes(scanner)...)
+	if err != nil {
+		return nil, nil, fmt.Errorf("scanner failed: %v", err)
+	}
+
+	vulnerabilitiesList, err := scanner.ParseResults(output)
+	if err != nil {
+		return nil, nil, fmt.Errorf("result parsing failed: %v", err)
+	}
+
+	findings, err := strategies.ParseFindings(scanner, output)
+	if err != nil {
+		return nil, nil, fmt.Errorf("result parsing failed: %v", err)
+	}
+
+	return vulnerabilitiesList, findings, nil
+}
+
+// inTx runs fn with a handler whose queries run in one transaction.
+func (h *Handler) inTx(ctx context.Context, fn func(tx *Handler) error) error {
+	return h.queries.InTx(ctx, func(q *query.Queries) error {
+		return fn(&Handler{queries: q})
+	})
+}
+
+// recordJobAsset links what a scan found on an asset to scanUUID and marks
+// the asset as done in one transaction, replacing anything an interrupted
+// earlier run of the job recorded for it. It returns the IDs of the
+// vulnerabilities whose stored data is already current.
+func (h *Handler) recordJobAsset(ctx context.Context, jobID pgtype.UUID, assetID pgtype.UUID, scanUUID pgtype.UUID, vulnerabilitiesList []vuln.Vulnerability, findings []finding.Finding) ([]string, error) {
+	unchangedVulns, err := h.addVulnerabilities(ctx, vulnerabilitiesList)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := h.addFindingData(ctx, findings); err != nil {
+		return nil, err
+	}
+
+	err = h.inTx(ctx, func(tx *Handler) error {
+		err := tx.queries.RemoveScanAssetResults(ctx, query.RemoveScanAssetResultsParams{
+			ScanID:  scanUUID,
+			AssetID: assetID,
+		})
 		if err != nil {
-			assetErrors = append(assetErrors, fmt.Sprintf("asset %s: failed to retrieve vulnerabilities: %v", asset.Hostname.String, err))
-			continue
+			return fmt.Errorf("failed to remove earlier results: %v", err)
 		}
 
-		for _, vulnID := range unchangedVulns {
-			allVulnsSeen[vulnID] = vuln.Vulnerability{}
+		if err := tx.recordFindings(ctx, assetID, scanUUID, vulnerabilitiesList); err != nil {
+			return err
 		}
 
-		params := query.BatchUpdateAVSParams{
-			AssetID:  asset.AssetID,
-			ScanID:   scanUUID,
-			VulnList: currentVulnIDs,
+		if err := tx.recordOtherFindings(ctx, assetID, scanUUID, findings); err != nil {
+			return err
 		}
 
-		err = h.queries.BatchUpdateAVS(ctx, params)
+		err = tx.queries.FinishScanJobAsset(ctx, query.FinishScanJobAssetParams{
+			JobID:              jobID,
+			AssetID:            assetID,
+			Status:             JobSucceeded,
+			VulnerabilityCount: int32(len(vulnerabilitiesList)),
+		})
 		if err != nil {
-			assetErrors = append(assetErrors, fmt.Sprintf("asset %s: failed to update relationship table %v", asset.Hostname.String, err))
-			continue
+			return fmt.Errorf("failed to update scan job progress: %v", err)
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return unchangedVulns, nil
+}
+
+// removeScan deletes a scan together with the results recorded for it.
+func (h *Handler) removeScan(ctx context.Context, scanUUID pgtype.UUID) {
+	err := h.inTx(ctx, func(tx *Handler) error {
+		if err := tx.queries.RemoveScanResults(ctx, scanUUID); err != nil {
+			return err
 		}
+		return tx.queries.RemoveScanEntry(ctx, scanUUID)
+	})
+	if err != nil {
+		logger.Error("Failed to remove scan %s: %v", response.UuidToString(scanUUID), err)
+	}
+}
 
-		canDeleteScanEntry = false
+// addVulnerabilities adds the vulnerabilities not seen before and returns the
+// IDs of those whose stored data is already current. The data is shared by
+// every scan, so it is added outside of the transaction recording an asset.
+func (h *Handler) addVulnerabilities(ctx context.Context, vulnerabilitiesList []vuln.Vulnerability) ([]string, error) {
+	unverifiedVulns := query.RetrieveUnchangedVulnerabilitiesParams{
+		VulnList:     []string{},
+		ModifiedList: []pgtype.Timestamptz{},
 	}
 
-	if canDeleteScanEntry {
-		h.queries.RemoveScanEntry(ctx, scanUUID)
-		return fmt.Errorf("scan completed with errors:\n%s", strings.Join(assetErrors, "\n"))
+	for _, vuln := range vulnerabilitiesList {
+		unverifiedVulns.VulnList = append(unverifiedVulns.VulnList, vuln.ID)
+		unverifiedVulns.ModifiedList = append(unverifiedVulns.ModifiedList, pgtype.Timestamptz{Time: vuln.LastModified, Valid: !vuln.LastModified.IsZero()})
 	}
 
-	var vulnIDs []string
-	var changedVulns []vuln.Vulnerability
+	// Every ID is inserted, not just ones new to this job, because the asset
+	// that first reported a vulnerability may still be scanning.
+	err := h.queries.InsertNewVulnerabilities(ctx, unverifiedVulns.VulnList)
+	if err != nil {
+		return nil, fmt.Errorf("failed to insert new vulnerabiilities: %v", err)
+	}
+
+	unchangedVulns, err := h.queries.RetrieveUnchangedVulnerabilities(ctx, unverifiedVulns)
+	if err != nil {
+		return nil, fmt.Errorf("failed to retrieve vulnerabilities: %v", err)
+	}
+
+	return unchangedVulns, nil
+}
+
+// recordFindings links the vulnerabilities found on an asset to scanUUID.
+// They must have been added with addVulnerabilities.
+func (h *Handler) recordFindings(ctx context.Context, assetID pgtype.UUID, scanUUID pgtype.UUID, vulnerabilitiesList []vuln.Vulnerability) error {
+	var currentVulnIDs []string
+	for _, vuln := range vulnerabilitiesList {
+		currentVulnIDs = append(currentVulnIDs, vuln.ID)
+	}
+
+	params := query.BatchUpdateAVSParams{
+		AssetID:  assetID,
+		ScanID:   scanUUID,
+		VulnList: currentVulnIDs,
+	}
+
+	err := h.queries.BatchUpdateAVS(ctx, params)
+	if err != nil {
+		return fmt.Errorf("failed to update relationship table %v", err)
+	}
+
+	packages, err := vuln.GetPackagesJSON(vulnerabilitiesList)
+	if err != nil {
+		return fmt.Errorf("failed to encode affected packages: %v", err)
+	}
+
+	err = h.queries.BatchInsertVulnerabilityPackages(ctx, query.BatchInsertVulnerabilityPackagesParams{
+		ScanID:   scanUUID,
+		AssetID:  assetID,
+		Packages: packages,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to record affected packages: %v", err)
+	}
+
+	return nil
+}
+
+// addFindingData updates the stored data of the secret, misconfiguration and
+// license findings, outside of the transaction recording an asset like
+// addVulnerabilities.
+func (h *Handler) addFindingData(ctx context.Context, findings []finding.Finding) error {
+	if len(findings) == 0 {
+		return nil
+	}
+
+	findingsJSON, err := finding.GetFindingsJSON(findings)
+	if err != nil {
+		return err
+	}
+
+	if err := h.queries.UpsertFindingData(ctx, findingsJSON); err != nil {
+		return fmt.Errorf("failed to update finding data: %v", err)
+	}
+
+	return nil
+}
+
+// recordOtherFindings links the secret, misconfiguration and license
+// findings on an asset to scanUUID. They must have been added with
+// addFindingData.
+func (h *Handler) recordOtherFindings(ctx context.Context, assetID pgtype.UUID, scanUUID pgtype.UUID, findings []finding.Finding) error {
+	if len(findings) == 0 {
+		return nil
+	}
+
+	findingsJSON, err := finding.GetFindingsJSON(findings)
+	if err != nil {
+		return err
+	}
+
+	err = h.queries.BatchInsertAssetFindings(ctx, query.BatchInsertAssetFindingsParams{
+		ScanID:   scanUUID,
+		AssetID:  assetID,
+		Findings: findingsJSON,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to record findings: %v", err)
+	}
+
+	return nil
+}
+
+// updateFindings moves the findings of the given types on each scanned asset
+// through their lifecycle states and derives the account-level states.
+func (h *Handler) updateFindings(ctx context.Context, rootAccountID pgtype.UUID, scanUUID pgtype.UUID, scannedAssets []pgtype.UUID, findingTypes []string) []string {
+	if !slices.ContainsFunc(findingTypes, func(t string) bool { return t != finding.TypeVulnerability }) {
+		return nil
+	}
+
+	err := h.queries.BatchUpdateAssetFindingState(ctx, query.BatchUpdateAssetFindingStateParams{
+		ScanID:       scanUUID,
+		AssetIds:     scannedAssets,
+		FindingTypes: findingTypes,
+	})
+	if err != nil {
+		return []string{fmt.Sprintf("failed to update asset finding states: %v", err)}
+	}
+
+	err = h.queries.BatchUpdateFindingState(ctx, query.BatchUpdateFindingStateParams{
+		RootAccountID: rootAccountID,
+		AssetIds:      scannedAssets,
+	})
+	if err != nil {
+		return []string{fmt.Sprintf("failed to update finding states: %v", err)}
+	}
+
+	return nil
+}
 
-	for id, vulnData := range allVulnsSeen {
-		vulnIDs = append(vulnIDs, id)
+// updateVulnerabilities moves the vulnerabilities on each scanned asset
+// through their lifecycle states, derives the account-level states from
+// them, and refreshes the stored data of those that changed. Findings on
+// assets the scan did not cover are left as they are.
+func (h *Handler) updateVulnerabilities(ctx context.Context, rootAccountID pgtype.UUID, scanUUID pgtype.UUID, scannedAssets []pgtype.UUID, allVulnsSeen map[string]vuln.Vulnerability) []string {
+	var errs []string
+	var changedVulns []vuln.Vulnerability
 
+	for _, vulnData := range allVulnsSeen {
 		if vulnData.ID != "" {
 			changedVulns = append(changedVulns, vulnData)
 		}
 	}
 
-	param := query.BatchUpdateVulnerabilityStateParams{
-		AccountID: accountID,
-		VulnList:  vulnIDs,
-	}
+	err := h.queries.BatchUpdateAssetVulnerabilityState(ctx, query.BatchUpdateAssetVulnerabilityStateParams{
+		ScanID:   scanUUID,
+		AssetIds: scannedAssets,
+	})
+	if err != nil {
+		errs = append(errs, fmt.Sprintf("failed to update asset vulnerability states: %v", err))
+	} else {
+		param := query.BatchUpdateVulnerabilityStateParams{
+			AccountID: rootAccountID,
+			AssetIds:  scannedAssets,
+		}
 
-	if err := h.queries.BatchUpdateVulnerabilityState(ctx, param); err != nil {
-		globalErrors = append(globalErrors, fmt.Sprintf("failed to update vulnerability states: %v", err))
+		if err := h.queries.BatchUpdateVulnerabilityState(ctx, param); err != nil {
+			errs = append(errs, fmt.Sprintf("failed to update vulnerability states: %v", err))
+		}
 	}
 
 	if len(changedVulns) > 0 {
 		vulnJSON, err := vuln.GetVulnerabilitiesJSON(changedVulns)
 		if err != nil {
-			globalErrors = append(globalErrors, fmt.Sprintf("failed to generate vulnerability JSON: %v", err))
+			errs = append(errs, fmt.Sprintf("failed to generate vulnerability JSON: %v", err))
 		}
 
 		// err == nil is kind of strange here, I do not know another way to skip
 		// update if GetVulnerabilitiesJSON without having a nasty if else if -Chris
 		if err == nil {
 			if err := h.queries.BatchUpdateVulnerabilityData(ctx, vulnJSON); err != nil {
-				globalErrors = append(globalErrors, fmt.Sprintf("failed to batch update vulnerability data: %v", err))
+				errs = append(errs, fmt.Sprintf("failed to batch update vulnerability data: %v", err))
 			}
 		}
 	}
 
-	allErrors := append(assetErrors, globalErrors...)
-	if len(allErrors) > 0 {
-		return fmt.Errorf("scan completed with errors:\n%s", strings.Join(allErrors, "\n"))
+	return errs
+}
+
+// mergeVulns adds one asset's results to seen. Vulnerabilities whose stored
+// data is current are kept as empty entries so only their state is updated.
+func mergeVulns(seen map[string]vuln.Vulnerability, found []vuln.Vulnerability, unchanged []string) {
+	for _, v := range found {
+		if _, exists := seen[v.ID]; !exists {
+			seen[v.ID] = v
+		}
+	}
+	for _, id := range unchanged {
+		seen[id] = vuln.Vulnerability{}
+	}
+}
+
+// createScanEntry records a scan along with the target it was started with,
+// the assets that target resolved to and the path that was scanned on them.
+func (h *Handler) createScanEntry(ctx context.Context, scannerName string, scanPath string, rootAccountID pgtype.UUID, requestedBy pgtype.UUID, requestedByType string, target []byte, assetIDs []pgtype.UUID) (pgtype.UUID, error) {
+	if len(target) == 0 {
+		target = []byte("{}")
 	}
 
+	if requestedByType == "root" {
+		scanUUID, err := h.queries.CreateScanEntryRoot(ctx, query.CreateScanEntryRootParams{
+			ScannerName:   scannerName,
+			RootAccountID: rootAccountID,
+			Target:        target,
+			AssetIds:      assetIDs,
+			ScanPath:      scanPath,
+		})
+		if err != nil {
+			return pgtype.UUID{}, fmt.Errorf("error creating scan entry as Root User: %v", err)
+		}
+		return scanUUID, nil
+	}
+
+	scanUUID, err := h.queries.CreateScanEntryIAMUser(ctx, query.CreateScanEntryIAMUserParams{
+		ScannerName:   scannerName,
+		ScannedByUser: requestedBy,
+		Target:        target,
+		AssetIds:      assetIDs,
+		ScanPath:      scanPath,
+	})
+	if err != nil {
+		return pgtype.UUID{}, fmt.Errorf("error creating scan entry as IAM User: %v", err)
+	}
+	return scanUUID, nil
+}
+
+func (h *Handler) failJob(ctx context.Context, job query.ScanJob, jobErr error) error {
+	if err := h.finishJob(ctx, job.JobID, JobFailed, job.ScanID, jobErr); err != nil {
+		return err
+	}
+	return jobErr
+}
+
+func (h *Handler) finishJob(ctx context.Context, jobID pgtype.UUID, status string, scanID pgtype.UUID, jobErr error) error {
+	params := query.FinishScanJobParams{
+		JobID:  jobID,
+		Status: status,
+		ScanID: scanID,
+	}
+	if jobErr != nil {
+		params.Error = pgtype.Text{String: jobErr.Error(), Valid: true}
+	}
+
+	if err := h.queries.FinishScanJob(ctx, params); err != nil {
+		return fmt.Errorf("error finishing scan job: %v", err)
+	}
 	return nil
 }
+
+// filesystemFlag is the path to scan, which every scanner takes in addition
+// to the flags of its own schema. Scans that leave it out scan the root.
+var filesystemFlag = flags.Spec{
+	Label:   "Filesystem",
+	Type:    flags.String,
+	Default: "/",
+	Validate: func(value any) error {
+		path := value.(string)
+		if path == "" {
+			return fmt.Errorf("must not be empty")
+		}
+		if strings.HasPrefix(path, "-") {
+			return fmt.Errorf("must be a path, not an option")
+		}
+		return nil
+	},
+}
+
+func flagSchema(scanner strategies.Scanner) flags.Schema {
+	return append(flags.Schema{filesystemFlag}, scanner.FlagSchema()...)
+}
+
+// decodeFlags checks the flags of a scan against the scanner's schema. An
+// invalid flag is reported as a *flags.ValidationError.
+func decodeFlags(scannerName string, set flags.FlagSet) (strategies.Scanner, flags.FlagSet, error) {
+	scanner, err := strategies.GetScanner(scannerName)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to get find scanner \"%s\": %v", scannerName, err)
+	}
+
+	decoded, err := flagSchema(scanner).Decode(set)
+	if err != nil {
+		return nil, nil, err
+	}
+	return scanner, decoded, nil
+}
+
+// decodeStoredFlags decodes the flags saved with a job or schedule. Flags
+// saved before the scanner's schema was enforced may have labels it does not
+// know; those are ignored with a warning rather than failing every run.
+func decodeStoredFlags(scannerName string, set flags.FlagSet) (strategies.Scanner, flags.FlagSet, error) {
+	scanner, err := strategies.GetScanner(scannerName)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to get find scanner \"%s\": %v", scannerName, err)
+	}
+
+	schema := flagSchema(scanner)
+	known, unknown := schema.Known(set)
+	if len(unknown) > 0 {
+		logger.Warn("Ignoring unknown %s flags saved with a scan: %s", scannerName, strings.Join(unknown, ", "))
+	}
+
+	decoded, err := schema.Decode(known)
+	if err != nil {
+		return nil, nil, err
+	}
+	return scanner, decoded, nil
+}
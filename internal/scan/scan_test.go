@@ -8,9 +8,12 @@ import (
 	"testing"
 	"time"
 
+	"github.com/SyntinelNyx/syntinel-server/internal/agenttest"
 	"github.com/SyntinelNyx/syntinel-server/internal/database"
 	"github.com/SyntinelNyx/syntinel-server/internal/database/query"
+	"github.com/SyntinelNyx/syntinel-server/internal/finding"
 	"github.com/SyntinelNyx/syntinel-server/internal/grpc"
+	"github.com/SyntinelNyx/syntinel-server/internal/scan/flags"
 	"github.com/SyntinelNyx/syntinel-server/internal/vuln"
 	"github.com/google/uuid"
 	"github.com/jackc/pgx/v5/pgtype"
@@ -47,7 +50,7 @@ func setupTestDB(t *testing.T) (*Handler, *pgxpool.Pool) {
 }
 
 func cleanupTestDB(t *testing.T, conn *pgxpool.Pool) {
-	_, err := conn.Exec(context.Background(), "DROP TABLE IF EXISTS vulnerability_data, scans, assets, root_accounts CASCADE;")
+	_, err := conn.Exec(context.Background(), "DROP TABLE IF EXISTS vulnerability_data, finding_data, asset_finding_scan, asset_finding_state_history, finding_state_history, scans, scan_jobs, scan_job_assets, scan_schedules, asset_tags, asset_vulnerability_packages, asset_vulnerability_state_history, assets, root_accounts CASCADE;")
 	require.NoError(t, err, "Failed to drop tables")
 	_, err = conn.Exec(context.Background(), "DROP TABLE IF EXISTS vulnerability_state_history CASCADE;")
 	require.NoError(t, err, "Failed to drop tables")
@@ -119,41 +122,61 @@ func TestVulnerabilityQueries(t *testing.T) {
 	err = handler.queries.BatchUpdateVulnerabilityData(ctx, vulnData)
 	assert.NoError(t, err)
 
-	var vulnList []string
-	for _, vulns := range existingVulns {
-		vulnList = append(vulnList, vulns.ID)
+	asset := query.AddAssetParams{
+		Hostname:      pgtype.Text{String: "dummy-host", Valid: true},
+		AssetID:       pgtype.UUID{Bytes: uuid.New(), Valid: true},
+		IpAddress:     netip.MustParseAddr("192.168.1.1"),
+		RootAccountID: rootAccount.AccountID,
 	}
-	paramB := query.BatchUpdateVulnerabilityStateParams{
-		AccountID: rootAccount.AccountID,
-		VulnList:  vulnList,
+	require.NoError(t, handler.queries.AddAsset(ctx, asset))
+	assets := []pgtype.UUID{asset.AssetID}
+
+	scan := func(scannerName string, scanPath string, vulnList ...string) {
+		scanUUID, err := handler.queries.CreateScanEntryRoot(ctx, query.CreateScanEntryRootParams{
+			ScannerName:   scannerName,
+			RootAccountID: rootAccount.AccountID,
+			Target:        []byte("{}"),
+			AssetIds:      assets,
+			ScanPath:      scanPath,
+		})
+		require.NoError(t, err)
+		require.NoError(t, handler.queries.BatchUpdateAVS(ctx, query.BatchUpdateAVSParams{
+			AssetID:  asset.AssetID,
+			ScanID:   scanUUID,
+			VulnList: append([]string{}, vulnList...),
+		}))
+		require.Empty(t, handler.updateVulnerabilities(ctx, rootAccount.AccountID, scanUUID, assets, map[string]vuln.Vulnerability{}))
 	}
-
-	err = handler.queries.BatchUpdateVulnerabilityState(ctx, paramB)
-	assert.NoError(t, err)
-
-	paramB = query.BatchUpdateVulnerabilityStateParams{
-		AccountID: rootAccount.AccountID,
-		VulnList:  []string{"CVE-0001", "CVE-0002"},
+	states := func() map[string]query.Vulnstate {
+		rows, err := handler.queries.GetVulnerabilities(ctx, rootAccount.AccountID)
+		require.NoError(t, err)
+		states := make(map[string]query.Vulnstate)
+		for _, row := range rows {
+			states[row.VulnerabilityID] = row.VulnerabilityState
+		}
+		return states
 	}
 
-	err = handler.queries.BatchUpdateVulnerabilityState(ctx, paramB)
-	assert.NoError(t, err)
+	scan("trivy", "/", "CVE-0001", "CVE-0002", "CVE-0003")
+	assert.Equal(t, map[string]query.Vulnstate{"CVE-0001": query.VulnstateNew, "CVE-0002": query.VulnstateNew, "CVE-0003": query.VulnstateNew}, states())
 
-	paramB = query.BatchUpdateVulnerabilityStateParams{
-		AccountID: rootAccount.AccountID,
-		VulnList:  []string{"CVE-0003"},
-	}
+	scan("trivy", "/", "CVE-0001", "CVE-0002")
+	assert.Equal(t, map[string]query.Vulnstate{"CVE-0001": query.VulnstateActive, "CVE-0002": query.VulnstateActive, "CVE-0003": query.VulnstateResolved}, states())
 
-	err = handler.queries.BatchUpdateVulnerabilityState(ctx, paramB)
-	assert.NoError(t, err)
+	scan("trivy", "/", "CVE-0003")
+	assert.Equal(t, map[string]query.Vulnstate{"CVE-0001": query.VulnstateResolved, "CVE-0002": query.VulnstateResolved, "CVE-0003": query.VulnstateResurfaced}, states())
 
-	paramB = query.BatchUpdateVulnerabilityStateParams{
-		AccountID: rootAccount.AccountID,
-		VulnList:  []string{"CVE-0003"},
-	}
+	scan("trivy", "/", "CVE-0003")
+	assert.Equal(t, query.VulnstateResurfaced, states()["CVE-0003"])
 
-	err = handler.queries.BatchUpdateVulnerabilityState(ctx, paramB)
-	assert.NoError(t, err)
+	// Neither another scanner nor a scan of another path resolves what Trivy
+	// last found on "/".
+	scan("grype", "/")
+	scan("trivy", "/opt")
+	assert.Equal(t, query.VulnstateResurfaced, states()["CVE-0003"])
+
+	scan("trivy", "/")
+	assert.Equal(t, query.VulnstateResolved, states()["CVE-0003"])
 
 	stateHistoryTable, err := handler.queries.GetVulnerabilitiesStateHistory(ctx)
 	assert.NoError(t, err)
@@ -285,6 +308,8 @@ func TestMultipleAssets(t *testing.T) {
 	scanParam := query.CreateScanEntryRootParams{
 		ScannerName:   "trivy",
 		RootAccountID: rootAccount.AccountID,
+		Target:        []byte("{}"),
+		AssetIds:      []pgtype.UUID{assetA.AssetID, assetB.AssetID},
 	}
 
 	assetAVulnerabilities := []vuln.Vulnerability{
@@ -336,11 +361,6 @@ func TestMultipleAssets(t *testing.T) {
 	assetAVulnIDs := []string{assetAVulnerabilities[0].ID, assetAVulnerabilities[1].ID}
 	assetBVulnIDs := []string{assetBVulnerabilities[0].ID, assetBVulnerabilities[1].ID}
 
-	updateVulnStateParams := query.BatchUpdateVulnerabilityStateParams{
-		AccountID: rootAccount.AccountID,
-		VulnList:  []string{assetAVulnerabilities[0].ID, assetAVulnerabilities[1].ID, assetBVulnerabilities[1].ID},
-	}
-
 	assetAVulnJSON, _ := vuln.GetVulnerabilitiesJSON(assetAVulnerabilities)
 	assetBVulnJSON, _ := vuln.GetVulnerabilitiesJSON(assetBVulnerabilities)
 
@@ -348,7 +368,6 @@ func TestMultipleAssets(t *testing.T) {
 	handler.queries.InsertNewVulnerabilities(ctx, assetBVulnIDs)
 	handler.queries.BatchUpdateVulnerabilityData(ctx, assetAVulnJSON)
 	handler.queries.BatchUpdateVulnerabilityData(ctx, assetBVulnJSON)
-	handler.queries.BatchUpdateVulnerabilityState(ctx, updateVulnStateParams)
 
 	rows, _ := conn.Query(context.Background(), `
 	SELECT vulnerability_id
@@ -383,6 +402,19 @@ func TestMultipleAssets(t *testing.T) {
 	err = handler.queries.BatchUpdateAVS(ctx, updateB)
 	assert.NoError(t, err)
 
+	scannedAssets := []pgtype.UUID{assetA.AssetID, assetB.AssetID}
+	err = handler.queries.BatchUpdateAssetVulnerabilityState(ctx, query.BatchUpdateAssetVulnerabilityStateParams{
+		ScanID:   scanUUID,
+		AssetIds: scannedAssets,
+	})
+	assert.NoError(t, err)
+
+	err = handler.queries.BatchUpdateVulnerabilityState(ctx, query.BatchUpdateVulnerabilityStateParams{
+		AccountID: rootAccount.AccountID,
+		AssetIds:  scannedAssets,
+	})
+	assert.NoError(t, err)
+
 	vulnTable, err := handler.queries.RetrieveVulnTable(ctx, rootAccount.AccountID)
 	assert.NoError(t, err)
 
@@ -409,6 +441,140 @@ func TestMultipleAssets(t *testing.T) {
 	assert.Equal(t, []string{"dummy-hostA"}, vulnTable[1].AssetsAffected)
 	assert.Equal(t, []string{"dummy-hostA", "dummy-hostB"}, vulnTable[2].AssetsAffected)
 
+	// A second scan of asset A alone no longer finds VULN-002. It is resolved
+	// on asset A but stays open on asset B, which was not scanned.
+	rescanUUID, err := handler.queries.CreateScanEntryRoot(ctx, scanParam)
+	assert.NoError(t, err)
+
+	err = handler.queries.BatchUpdateAVS(ctx, query.BatchUpdateAVSParams{
+		AssetID:  assetA.AssetID,
+		ScanID:   rescanUUID,
+		VulnList: []string{"VULN-001"},
+	})
+	assert.NoError(t, err)
+
+	rescanned := []pgtype.UUID{assetA.AssetID}
+	err = handler.queries.BatchUpdateAssetVulnerabilityState(ctx, query.BatchUpdateAssetVulnerabilityStateParams{
+		ScanID:   rescanUUID,
+		AssetIds: rescanned,
+	})
+	assert.NoError(t, err)
+
+	err = handler.queries.BatchUpdateVulnerabilityState(ctx, query.BatchUpdateVulnerabilityStateParams{
+		AccountID: rootAccount.AccountID,
+		AssetIds:  rescanned,
+	})
+	assert.NoError(t, err)
+
+	assetStates, err := handler.queries.GetAssetVulnerabilityStates(ctx, query.GetAssetVulnerabilityStatesParams{
+		VulnerabilityID: "VULN-002",
+		RootAccountID:   rootAccount.AccountID,
+	})
+	assert.NoError(t, err)
+	require.Len(t, assetStates, 2)
+	assert.Equal(t, query.VulnstateResolved, assetStates[0].VulnerabilityState)
+	assert.Equal(t, query.VulnstateNew, assetStates[1].VulnerabilityState)
+
+	vulnStates := make(map[string]query.Vulnstate)
+	vulnList, err := handler.queries.GetVulnerabilities(ctx, rootAccount.AccountID)
+	assert.NoError(t, err)
+	for _, row := range vulnList {
+		vulnStates[row.VulnerabilityID] = row.VulnerabilityState
+	}
+	assert.Equal(t, query.VulnstateActive, vulnStates["VULN-001"])
+	assert.Equal(t, query.VulnstateNew, vulnStates["VULN-002"])
+	assert.Equal(t, query.VulnstateNew, vulnStates["VULN-003"])
+}
+
+func TestFindingLifecycle(t *testing.T) {
+	handler, conn := setupTestDB(t)
+	defer cleanupTestDB(t, conn)
+	ctx := context.Background()
+
+	rootAccount, err := handler.queries.CreateRootAccount(ctx, query.CreateRootAccountParams{
+		Email:        "a@a.com",
+		Username:     "a",
+		PasswordHash: "",
+	})
+	require.NoError(t, err)
+
+	asset := query.AddAssetParams{
+		Hostname:      pgtype.Text{String: "dummy-host", Valid: true},
+		AssetID:       pgtype.UUID{Bytes: uuid.New(), Valid: true},
+		IpAddress:     netip.MustParseAddr("192.168.1.1"),
+		RootAccountID: rootAccount.AccountID,
+	}
+	require.NoError(t, handler.queries.AddAsset(ctx, asset))
+	assets := []pgtype.UUID{asset.AssetID}
+
+	secret := finding.Finding{
+		Type:      finding.TypeSecret,
+		RuleID:    "aws-access-key-id",
+		Title:     "AWS Access Key ID",
+		Severity:  "Critical",
+		FilePath:  "opt/app/.env",
+		StartLine: 3,
+		EndLine:   3,
+	}
+	movedSecret := secret
+	movedSecret.FilePath = "opt/app/config.env"
+	license := finding.Finding{
+		Type:        finding.TypeLicense,
+		RuleID:      "GPL-3.0",
+		Title:       "GPL-3.0",
+		Severity:    "High",
+		LicenseName: "GPL-3.0",
+		Package:     "readline-sync",
+	}
+
+	scan := func(findingTypes []string, findings ...finding.Finding) {
+		scanUUID, err := handler.queries.CreateScanEntryRoot(ctx, query.CreateScanEntryRootParams{
+			ScannerName:   "trivy",
+			RootAccountID: rootAccount.AccountID,
+			Target:        []byte("{}"),
+			AssetIds:      assets,
+		})
+		require.NoError(t, err)
+		require.NoError(t, handler.addFindingData(ctx, findings))
+		require.NoError(t, handler.recordOtherFindings(ctx, asset.AssetID, scanUUID, findings))
+		require.Empty(t, handler.updateFindings(ctx, rootAccount.AccountID, scanUUID, assets, findingTypes))
+	}
+	states := func() map[string]query.Vulnstate {
+		rows, err := handler.queries.GetFindingsByAsset(ctx, query.GetFindingsByAssetParams{
+			AssetID:       asset.AssetID,
+			RootAccountID: rootAccount.AccountID,
+		})
+		require.NoError(t, err)
+		states := make(map[string]query.Vulnstate)
+		for _, row := range rows {
+			states[row.RuleID] = row.FindingState
+		}
+		return states
+	}
+
+	scan([]string{finding.TypeSecret, finding.TypeLicense}, secret, license)
+	assert.Equal(t, map[string]query.Vulnstate{"aws-access-key-id": query.VulnstateNew, "GPL-3.0": query.VulnstateNew}, states())
+
+	// A scan that only looked for secrets leaves the license finding alone.
+	scan([]string{finding.TypeSecret}, secret)
+	assert.Equal(t, map[string]query.Vulnstate{"aws-access-key-id": query.VulnstateActive, "GPL-3.0": query.VulnstateNew}, states())
+
+	// The same rule in another file is tracked on its own, and the finding
+	// stays open while any of its locations is.
+	scan([]string{finding.TypeSecret}, movedSecret)
+	assert.Equal(t, map[string]query.Vulnstate{"aws-access-key-id": query.VulnstateNew, "GPL-3.0": query.VulnstateNew}, states())
+
+	scan([]string{finding.TypeSecret, finding.TypeLicense})
+	assert.Equal(t, map[string]query.Vulnstate{"aws-access-key-id": query.VulnstateResolved, "GPL-3.0": query.VulnstateResolved}, states())
+
+	findings, err := handler.queries.GetFindings(ctx, query.GetFindingsParams{
+		RootAccountID: rootAccount.AccountID,
+		FindingType:   finding.TypeSecret,
+	})
+	require.NoError(t, err)
+	require.Len(t, findings, 1)
+	assert.Equal(t, query.VulnstateResolved, findings[0].FindingState)
+	assert.Equal(t, []string{"dummy-host"}, findings[0].AssetsAffected)
 }
 
 func TestScan(t *testing.T) {
@@ -438,7 +604,168 @@ func TestScan(t *testing.T) {
 
 	grpc.LoadCreds()
 
-	err = handler.LaunchScan("trivy", nil, []string{"asset-1"}, rootAccount.AccountID, "root")
+	err = handler.LaunchScan(context.Background(), "trivy", nil, ScanTarget{Hostnames: []string{"asset-1"}}, rootAccount.AccountID, "root")
 	assert.NoError(t, err)
 
 }
+
+const fakeTrivyOutput = `{"Results":[{"Target":"/","Vulnerabilities":[{
+	"VulnerabilityID":"CVE-2024-0001","Title":"Fake","Description":"Served by agenttest",
+	"Severity":"HIGH","CVSS":{"nvd":{"V3Score":7.5}},"VendorSeverity":{"nvd":3},
+	"PublishedDate":"2024-01-01T00:00:00Z","LastModifiedDate":"2024-01-02T00:00:00Z"}]}]}`
+
+func TestLaunchScanWithFakeAgent(t *testing.T) {
+	handler, conn := setupTestDB(t)
+	defer cleanupTestDB(t, conn)
+	ctx := context.Background()
+
+	rootAccount, err := handler.queries.CreateRootAccount(ctx, query.CreateRootAccountParams{
+		Email:    "fake@agent.test",
+		Username: "fake-agent",
+	})
+	require.NoError(t, err)
+
+	assetID := pgtype.UUID{Bytes: uuid.New(), Valid: true}
+	err = handler.queries.AddAsset(ctx, query.AddAssetParams{
+		Hostname:      pgtype.Text{String: "fake-host", Valid: true},
+		Os:            pgtype.Text{String: "linux", Valid: true},
+		AssetID:       assetID,
+		IpAddress:     netip.MustParseAddr("10.0.0.7"),
+		RootAccountID: rootAccount.AccountID,
+		AgentPort:     50052,
+	})
+	require.NoError(t, err)
+
+	network := agenttest.Install(t)
+	agent := agenttest.NewAgent().On("run_scan", agenttest.Output(fakeTrivyOutput))
+	network.Serve(t, "10.0.0.7:50052", agent)
+
+	scanFlags := flags.FlagSet{{Label: "Filesystem", InputType: "string", Value: "/"}}
+	err = handler.LaunchScan(ctx, "trivy", scanFlags, ScanTarget{Hostnames: []string{"fake-host"}}, rootAccount.AccountID, "root")
+	require.NoError(t, err)
+
+	received := agent.Received()
+	require.Len(t, received, 1)
+	assert.Equal(t, "trivy", received[0].GetRunScan().Scanner)
+
+	vulns, err := handler.queries.GetVulnerabilities(ctx, rootAccount.AccountID)
+	require.NoError(t, err)
+	require.Len(t, vulns, 1)
+	assert.Equal(t, "CVE-2024-0001", vulns[0].VulnerabilityID)
+
+	jobs, err := handler.queries.ListScanJobs(ctx, rootAccount.AccountID)
+	require.NoError(t, err)
+	require.Len(t, jobs, 1)
+	assert.Equal(t, JobSucceeded, jobs[0].Status)
+	assert.EqualValues(t, 1, jobs[0].TotalAssets)
+	assert.EqualValues(t, 1, jobs[0].SucceededAssets)
+}
+
+func TestRecordJobAssetReplacesEarlierResults(t *testing.T) {
+	handler, conn := setupTestDB(t)
+	defer cleanupTestDB(t, conn)
+	ctx := context.Background()
+
+	rootAccount, err := handler.queries.CreateRootAccount(ctx, query.CreateRootAccountParams{
+		Email:    "resume@scan.test",
+		Username: "resume",
+	})
+	require.NoError(t, err)
+
+	assetID := pgtype.UUID{Bytes: uuid.New(), Valid: true}
+	err = handler.queries.AddAsset(ctx, query.AddAssetParams{
+		Hostname:      pgtype.Text{String: "resume-host", Valid: true},
+		AssetID:       assetID,
+		IpAddress:     netip.MustParseAddr("10.0.0.8"),
+		RootAccountID: rootAccount.AccountID,
+	})
+	require.NoError(t, err)
+
+	job, err := handler.CreateJob(ctx, "trivy", nil, ScanTarget{Hostnames: []string{"resume-host"}}, rootAccount.AccountID, "root")
+	require.NoError(t, err)
+	scanUUID, err := handler.createScanEntry(ctx, "trivy", "/", rootAccount.AccountID, rootAccount.AccountID, "root", job.Target, []pgtype.UUID{assetID})
+	require.NoError(t, err)
+
+	vulns := []vuln.Vulnerability{{
+		ID:       "CVE-2024-0001",
+		Packages: []vuln.Package{{Name: "openssl", InstalledVersion: "1.0"}},
+	}}
+
+	// A job resumed after a restart records the asset again.
+	for i := 0; i < 2; i++ {
+		_, err := handler.recordJobAsset(ctx, job.JobID, assetID, scanUUID, vulns, nil)
+		require.NoError(t, err)
+	}
+
+	var scanRows int
+	require.NoError(t, conn.QueryRow(ctx, "SELECT COUNT(*) FROM asset_vulnerability_scan WHERE scan_id = $1", scanUUID).Scan(&scanRows))
+	assert.Equal(t, 1, scanRows)
+
+	packages, err := handler.queries.GetScanPackages(ctx, query.GetScanPackagesParams{ScanID: scanUUID, RootAccountID: rootAccount.AccountID})
+	require.NoError(t, err)
+	assert.Len(t, packages, 1)
+
+	handler.removeScan(ctx, scanUUID)
+	var scans int
+	require.NoError(t, conn.QueryRow(ctx, "SELECT COUNT(*) FROM scans WHERE scan_id = $1", scanUUID).Scan(&scans))
+	assert.Zero(t, scans)
+}
+
+func TestPreviousScansMatchScanPath(t *testing.T) {
+	handler, conn := setupTestDB(t)
+	defer cleanupTestDB(t, conn)
+	ctx := context.Background()
+
+	rootAccount, err := handler.queries.CreateRootAccount(ctx, query.CreateRootAccountParams{
+		Email:    "diff@scan.test",
+		Username: "diff",
+	})
+	require.NoError(t, err)
+
+	assetID := pgtype.UUID{Bytes: uuid.New(), Valid: true}
+	err = handler.queries.AddAsset(ctx, query.AddAssetParams{
+		Hostname:      pgtype.Text{String: "diff-host", Valid: true},
+		AssetID:       assetID,
+		IpAddress:     netip.MustParseAddr("10.0.0.9"),
+		RootAccountID: rootAccount.AccountID,
+	})
+	require.NoError(t, err)
+
+	vulns := []vuln.Vulnerability{{ID: "CVE-2024-0001"}}
+	_, err = handler.addVulnerabilities(ctx, vulns)
+	require.NoError(t, err)
+
+	scan := func(scanPath string) pgtype.UUID {
+		scanUUID, err := handler.createScanEntry(ctx, "trivy", scanPath, rootAccount.AccountID, rootAccount.AccountID, "root", nil, []pgtype.UUID{assetID})
+		require.NoError(t, err)
+		require.NoError(t, handler.recordFindings(ctx, assetID, scanUUID, vulns))
+		return scanUUID
+	}
+	root := scan("/")
+	app := scan("/opt/app")
+	latest := scan("/")
+
+	current, err := handler.queries.GetScanForRootAccount(ctx, query.GetScanForRootAccountParams{
+		ScanID:        latest,
+		RootAccountID: rootAccount.AccountID,
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "/", current.ScanPath)
+
+	previous := func(scanPath string) pgtype.UUID {
+		rows, err := handler.queries.GetPreviousScansForAssets(ctx, query.GetPreviousScansForAssetsParams{
+			AssetIds:      []pgtype.UUID{assetID},
+			RootAccountID: rootAccount.AccountID,
+			ScannerName:   "trivy",
+			ScanPath:      scanPath,
+			ScanDate:      current.ScanDate,
+		})
+		require.NoError(t, err)
+		require.Len(t, rows, 1)
+		return rows[0].ScanID
+	}
+
+	// The scan of /opt/app in between is not the baseline of a scan of /.
+	assert.Equal(t, root, previous("/"))
+	assert.Equal(t, app, previous("/opt/app"))
+}
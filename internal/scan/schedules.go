@@ -0,0 +1,314 @@
+package scan
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+
+	"github.com/SyntinelNyx/syntinel-server/internal/auth"
+	"github.com/SyntinelNyx/syntinel-server/internal/database/query"
+	"github.com/SyntinelNyx/syntinel-server/internal/response"
+	"github.com/SyntinelNyx/syntinel-server/internal/scan/flags"
+)
+
+type ScheduleRequest struct {
+	Name     string        `json:"name"`
+	Cron     string        `json:"cron"`
+	Timezone string        `json:"timezone"`
+	Scanner  string        `json:"scanner"`
+	Flags    flags.FlagSet `json:"flags"`
+	Target   ScanTarget    `json:"target"`
+	Enabled  *bool         `json:"enabled"`
+}
+
+type Schedule struct {
+	ScheduleID string        `json:"scheduleId"`
+	Name       string        `json:"name"`
+	Cron       string        `json:"cron"`
+	Timezone   string        `json:"timezone"`
+	Scanner    string        `json:"scanner"`
+	Flags      flags.FlagSet `json:"flags"`
+	Target     ScanTarget    `json:"target"`
+	Enabled    bool          `json:"enabled"`
+	CreatedAt  string        `json:"createdAt"`
+	UpdatedAt  string        `json:"updatedAt"`
+	NextRunAt  string        `json:"nextRunAt,omitempty"`
+	LastRunAt  string        `json:"lastRunAt,omitempty"`
+	LastJobID  string        `json:"lastJobId,omitempty"`
+	LastError  string        `json:"lastError,omitempty"`
+}
+
+// scheduleParams is a validated ScheduleRequest. The target is resolved
+// each time the schedule fires, so assets added to a targeted environment or
+// tag are picked up by the next run.
+type scheduleParams struct {
+	name     string
+	crontab  string
+	timezone string
+	flags    []byte
+	target   targetParams
+	enabled  bool
+}
+
+func (req ScheduleRequest) validate() (scheduleParams, error) {
+	if req.Name == "" {
+		return scheduleParams{}, fmt.Errorf("name is required")
+	}
+	if req.Timezone == "" {
+		req.Timezone = "UTC"
+	}
+	if _, err := cronSpec(req.Cron, req.Timezone); err != nil {
+		return scheduleParams{}, err
+	}
+	_, scanFlags, err := decodeFlags(req.Scanner, req.Flags)
+	if err != nil {
+		return scheduleParams{}, err
+	}
+
+	params := scheduleParams{
+		name:     req.Name,
+		crontab:  req.Cron,
+		timezone: req.Timezone,
+		enabled:  req.Enabled == nil || *req.Enabled,
+	}
+
+	if params.flags, err = json.Marshal(scanFlags); err != nil {
+		return scheduleParams{}, fmt.Errorf("error encoding scan flags: %v", err)
+	}
+	if len(req.Target.Hostnames) > 0 {
+		return scheduleParams{}, fmt.Errorf("schedules must target assets by ID, environment or tag, not hostname")
+	}
+	if params.target, err = req.Target.params(); err != nil {
+		return scheduleParams{}, err
+	}
+
+	return params, nil
+}
+
+func (h *Handler) ListSchedules(w http.ResponseWriter, r *http.Request) {
+	rootId, err := h.rootAccountID(r)
+	if err != nil {
+		response.RespondWithError(w, r, http.StatusInternalServerError, "Failed to get associated root account", err)
+		return
+	}
+
+	rows, err := h.queries.ListScanSchedules(context.Background(), rootId)
+	if err != nil {
+		response.RespondWithError(w, r, http.StatusInternalServerError, "Failed to list scan schedules", err)
+		return
+	}
+
+	schedules := []Schedule{}
+	for _, row := range rows {
+		schedules = append(schedules, toSchedule(row))
+	}
+
+	response.RespondWithJSON(w, http.StatusOK, schedules)
+}
+
+func (h *Handler) RetrieveSchedule(w http.ResponseWriter, r *http.Request) {
+	rootId, err := h.rootAccountID(r)
+	if err != nil {
+		response.RespondWithError(w, r, http.StatusInternalServerError, "Failed to get associated root account", err)
+		return
+	}
+
+	var scheduleID pgtype.UUID
+	if err := scheduleID.Scan(chi.URLParam(r, "scheduleID")); err != nil {
+		response.RespondWithError(w, r, http.StatusBadRequest, "Invalid ScheduleID format", err)
+		return
+	}
+
+	schedule, err := h.queries.GetScanScheduleForRootAccount(context.Background(), query.GetScanScheduleForRootAccountParams{
+		ScheduleID:    scheduleID,
+		RootAccountID: rootId,
+	})
+	if errors.Is(err, pgx.ErrNoRows) {
+		response.RespondWithError(w, r, http.StatusNotFound, "Scan schedule not found", err)
+		return
+	}
+	if err != nil {
+		response.RespondWithError(w, r, http.StatusInternalServerError, "Failed to get scan schedule", err)
+		return
+	}
+
+	response.RespondWithJSON(w, http.StatusOK, toSchedule(schedule))
+}
+
+func (h *Handler) CreateSchedule(w http.ResponseWriter, r *http.Request) {
+	claims := auth.GetClaims(r.Context())
+
+	rootId, err := h.rootAccountID(r)
+	if err != nil {
+		response.RespondWithError(w, r, http.StatusInternalServerError, "Failed to get associated root account", err)
+		return
+	}
+
+	var req ScheduleRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		response.RespondWithError(w, r, http.StatusBadRequest, "Invalid Request Body", err)
+		return
+	}
+
+	params, err := req.validate()
+	if respondWithFlagErrors(w, r, "Invalid scan schedule flags", err) {
+		return
+	}
+	if err != nil {
+		response.RespondWithError(w, r, http.StatusBadRequest, "Invalid scan schedule: "+err.Error(), err)
+		return
+	}
+
+	schedule, err := h.queries.CreateScanSchedule(context.Background(), query.CreateScanScheduleParams{
+		RootAccountID:        rootId,
+		Name:                 params.name,
+		CronExpression:       params.crontab,
+		Timezone:             params.timezone,
+		ScannerName:          req.Scanner,
+		Flags:                params.flags,
+		TargetAssetIds:       params.target.assetIDs,
+		TargetEnvironmentIds: params.target.environmentIDs,
+		TargetTags:           params.target.tags,
+		Enabled:              params.enabled,
+		CreatedBy:            claims.AccountID,
+	})
+	if err != nil {
+		response.RespondWithError(w, r, http.StatusInternalServerError, "Failed to create scan schedule", err)
+		return
+	}
+
+	scheduleChanged(schedule)
+
+	response.RespondWithJSON(w, http.StatusCreated, toSchedule(h.refreshSchedule(schedule)))
+}
+
+func (h *Handler) UpdateSchedule(w http.ResponseWriter, r *http.Request) {
+	rootId, err := h.rootAccountID(r)
+	if err != nil {
+		response.RespondWithError(w, r, http.StatusInternalServerError, "Failed to get associated root account", err)
+		return
+	}
+
+	var scheduleID pgtype.UUID
+	if err := scheduleID.Scan(chi.URLParam(r, "scheduleID")); err != nil {
+		response.RespondWithError(w, r, http.StatusBadRequest, "Invalid ScheduleID format", err)
+		return
+	}
+
+	var req ScheduleRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		response.RespondWithError(w, r, http.StatusBadRequest, "Invalid Request Body", err)
+		return
+	}
+
+	params, err := req.validate()
+	if respondWithFlagErrors(w, r, "Invalid scan schedule flags", err) {
+		return
+	}
+	if err != nil {
+		response.RespondWithError(w, r, http.StatusBadRequest, "Invalid scan schedule: "+err.Error(), err)
+		return
+	}
+
+	schedule, err := h.queries.UpdateScanSchedule(context.Background(), query.UpdateScanScheduleParams{
+		ScheduleID:           scheduleID,
+		RootAccountID:        rootId,
+		Name:                 params.name,
+		CronExpression:       params.crontab,
+		Timezone:             params.timezone,
+		ScannerName:          req.Scanner,
+		Flags:                params.flags,
+		TargetAssetIds:       params.target.assetIDs,
+		TargetEnvironmentIds: params.target.environmentIDs,
+		TargetTags:           params.target.tags,
+		Enabled:              params.enabled,
+	})
+	if errors.Is(err, pgx.ErrNoRows) {
+		response.RespondWithError(w, r, http.StatusNotFound, "Scan schedule not found", err)
+		return
+	}
+	if err != nil {
+		response.RespondWithError(w, r, http.StatusInternalServerError, "Failed to update scan schedule", err)
+		return
+	}
+
+	scheduleChanged(schedule)
+
+	response.RespondWithJSON(w, http.StatusOK, toSchedule(h.refreshSchedule(schedule)))
+}
+
+func (h *Handler) DeleteSchedule(w http.ResponseWriter, r *http.Request) {
+	rootId, err := h.rootAccountID(r)
+	if err != nil {
+		response.RespondWithError(w, r, http.StatusInternalServerError, "Failed to get associated root account", err)
+		return
+	}
+
+	var scheduleID pgtype.UUID
+	if err := scheduleID.Scan(chi.URLParam(r, "scheduleID")); err != nil {
+		response.RespondWithError(w, r, http.StatusBadRequest, "Invalid ScheduleID format", err)
+		return
+	}
+
+	deleted, err := h.queries.DeleteScanSchedule(context.Background(), query.DeleteScanScheduleParams{
+		ScheduleID:    scheduleID,
+		RootAccountID: rootId,
+	})
+	if err != nil {
+		response.RespondWithError(w, r, http.StatusInternalServerError, "Failed to delete scan schedule", err)
+		return
+	}
+	if deleted == 0 {
+		response.RespondWithError(w, r, http.StatusNotFound, "Scan schedule not found", nil)
+		return
+	}
+
+	scheduleDeleted(scheduleID)
+
+	response.RespondWithJSON(w, http.StatusOK, map[string]string{"message": "Scan schedule deleted"})
+}
+
+// refreshSchedule reloads a schedule so the response includes the next run
+// time recorded when it was registered.
+func (h *Handler) refreshSchedule(schedule query.ScanSchedule) query.ScanSchedule {
+	refreshed, err := h.queries.GetScanSchedule(context.Background(), schedule.ScheduleID)
+	if err != nil {
+		return schedule
+	}
+	return refreshed
+}
+
+func toSchedule(row query.ScanSchedule) Schedule {
+	schedule := Schedule{
+		ScheduleID: response.UuidToString(row.ScheduleID),
+		Name:       row.Name,
+		Cron:       row.CronExpression,
+		Timezone:   row.Timezone,
+		Scanner:    row.ScannerName,
+		Flags:      flags.FlagSet{},
+		Target:     scheduleTarget(row).target(),
+		Enabled:    row.Enabled,
+		CreatedAt:  formatTime(row.CreatedAt),
+		UpdatedAt:  formatTime(row.UpdatedAt),
+		NextRunAt:  formatTime(row.NextRunAt),
+		LastRunAt:  formatTime(row.LastRunAt),
+		LastJobID:  uuidOrEmpty(row.LastJobID),
+		LastError:  row.LastError.String,
+	}
+	json.Unmarshal(row.Flags, &schedule.Flags)
+	return schedule
+}
+
+func scheduleTarget(row query.ScanSchedule) targetParams {
+	return targetParams{
+		assetIDs:       row.TargetAssetIds,
+		environmentIDs: row.TargetEnvironmentIds,
+		tags:           row.TargetTags,
+	}
+}
@@ -7,32 +7,30 @@ import (
 )
 
 type PayloadFunctions interface {
-	PayloadForLinux() (string, error)
-	PayloadForWindows() (string, error)
-	PayloadForMac() (string, error)
+	PayloadForLinux(filePath string, flags flags.FlagSet) ([]string, error)
+	PayloadForWindows(filePath string, flags flags.FlagSet) ([]string, error)
+	PayloadForMac(filePath string, flags flags.FlagSet) ([]string, error)
 }
 
 type BaseScanner struct {
 	ScannerName      string
-	FilePath         string
-	Flags            flags.FlagSet
 	PayloadFunctions interface{}
 }
 
-// Default CalculateCommand logic, concrete class must call base
-func (b *BaseScanner) CalculateCommand(OS string, filePath string, flags flags.FlagSet, p PayloadFunctions) (string, error) {
-	b.FilePath = filePath
-	b.Flags = flags
-
+// Default CalculateCommand logic, concrete class must call base. The result
+// is the scanner's argv without the binary, which the agent resolves itself.
+// Registered scanners are shared, so the command is built from the arguments
+// only and nothing is stored on the scanner.
+func (b *BaseScanner) CalculateCommand(OS string, filePath string, flags flags.FlagSet, p PayloadFunctions) ([]string, error) {
 	switch OS {
 	case "linux":
-		return p.PayloadForLinux()
+		return p.PayloadForLinux(filePath, flags)
 	case "windows":
-		return p.PayloadForWindows()
+		return p.PayloadForWindows(filePath, flags)
 	case "mac":
-		return p.PayloadForMac()
+		return p.PayloadForMac(filePath, flags)
 	default:
-		return "", fmt.Errorf("unsupported OS: %s", OS)
+		return nil, fmt.Errorf("unsupported OS: %s", OS)
 	}
 }
 
@@ -42,14 +40,14 @@ func (b *BaseScanner) Name(name string) string {
 	return name
 }
 
-func (b *BaseScanner) PayloadForLinux() (string, error) {
-	return "", fmt.Errorf("scanner \"%s\" currently not implemented for Linux", b.ScannerName)
+func (b *BaseScanner) PayloadForLinux(filePath string, flags flags.FlagSet) ([]string, error) {
+	return nil, fmt.Errorf("scanner \"%s\" currently not implemented for Linux", b.ScannerName)
 }
 
-func (b *BaseScanner) PayloadForWindows() (string, error) {
-	return "", fmt.Errorf("scanner \"%s\" currently not implemented for Windows", b.ScannerName)
+func (b *BaseScanner) PayloadForWindows(filePath string, flags flags.FlagSet) ([]string, error) {
+	return nil, fmt.Errorf("scanner \"%s\" currently not implemented for Windows", b.ScannerName)
 }
 
-func (b *BaseScanner) PayloadForMac() (string, error) {
-	return "", fmt.Errorf("scanner \"%s\" currently not implemented for Mac", b.ScannerName)
+func (b *BaseScanner) PayloadForMac(filePath string, flags flags.FlagSet) ([]string, error) {
+	return nil, fmt.Errorf("scanner \"%s\" currently not implemented for Mac", b.ScannerName)
 }
@@ -0,0 +1,208 @@
+package grype
+
+import (
+	"encoding/json"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/SyntinelNyx/syntinel-server/internal/scan/flags"
+	base "github.com/SyntinelNyx/syntinel-server/internal/scan/strategies/base"
+	"github.com/SyntinelNyx/syntinel-server/internal/vuln"
+)
+
+type GrypeScanner struct {
+	base.BaseScanner
+}
+
+type grypeVulnerability struct {
+	ID          string   `json:"id"`
+	DataSource  string   `json:"dataSource"`
+	Severity    string   `json:"severity"`
+	URLs        []string `json:"urls"`
+	Description string   `json:"description"`
+	CVSS        []struct {
+		Version string `json:"version"`
+		Metrics struct {
+			BaseScore float64 `json:"baseScore"`
+		} `json:"metrics"`
+	} `json:"cvss"`
+}
+
+type GrypeOutput struct {
+	Matches []struct {
+		Vulnerability struct {
+			grypeVulnerability
+			Fix struct {
+				Versions []string `json:"versions"`
+				State    string   `json:"state"`
+			} `json:"fix"`
+		} `json:"vulnerability"`
+		RelatedVulnerabilities []grypeVulnerability `json:"relatedVulnerabilities"`
+		Artifact               struct {
+			Name      string `json:"name"`
+			Version   string `json:"version"`
+			Type      string `json:"type"`
+			Locations []struct {
+				Path string `json:"path"`
+			} `json:"locations"`
+		} `json:"artifact"`
+	} `json:"matches"`
+}
+
+func (g *GrypeScanner) Name() string {
+	if g.BaseScanner.ScannerName == "" {
+		g.BaseScanner.Name("grype")
+	}
+
+	return g.BaseScanner.ScannerName
+}
+
+func (g *GrypeScanner) CalculateCommand(OS string, filePath string, flags flags.FlagSet) ([]string, error) {
+	return g.BaseScanner.CalculateCommand(OS, filePath, flags, g)
+}
+
+func (g *GrypeScanner) FlagSchema() flags.Schema {
+	return flags.Schema{
+		{
+			Label:   "OnlyFixed",
+			Type:    flags.Bool,
+			Default: false,
+		},
+		{
+			Label:   "Exclude",
+			Type:    flags.Strings,
+			Default: []string{"./proc/**", "./sys/**"},
+		},
+	}
+}
+
+func (g *GrypeScanner) ParseResults(jsonOutput string) ([]vuln.Vulnerability, error) {
+	var output GrypeOutput
+
+	if err := json.Unmarshal([]byte(jsonOutput), &output); err != nil {
+		return nil, fmt.Errorf("Error Unmarshal: %s", err)
+	}
+
+	var results []vuln.Vulnerability
+	seen := make(map[string]int)
+
+	for _, match := range output.Matches {
+		v := match.Vulnerability
+
+		// GHSA and distro matches list the CVE they alias among the related
+		// vulnerabilities; report them under it as the other scanners do.
+		aliases := []string{v.ID}
+		for _, related := range match.RelatedVulnerabilities {
+			aliases = append(aliases, related.ID)
+		}
+		id := vuln.PreferredID(v.ID, aliases)
+
+		affected := vuln.Package{
+			Name:             match.Artifact.Name,
+			InstalledVersion: match.Artifact.Version,
+			FixedVersion:     strings.Join(v.Fix.Versions, ", "),
+			Type:             match.Artifact.Type,
+		}
+		if len(match.Artifact.Locations) > 0 {
+			affected.Path = match.Artifact.Locations[0].Path
+		}
+
+		// The same vulnerability is matched once per affected package; merge
+		// the fix data instead of reporting it twice.
+		if i, exists := seen[id]; exists {
+			results[i].FixedVersions = appendUnique(results[i].FixedVersions, v.Fix.Versions...)
+			if v.Fix.State == "fixed" {
+				results[i].FixState = "fixed"
+			}
+			results[i].AddPackage(affected)
+			continue
+		}
+
+		description := v.Description
+		cvssScore := baseScore(v.grypeVulnerability)
+		references := appendUnique(nil, v.DataSource)
+		references = appendUnique(references, v.URLs...)
+
+		// GHSA and distro records often leave out the description and CVSS
+		// that the related NVD record has.
+		for _, related := range match.RelatedVulnerabilities {
+			if description == "" {
+				description = related.Description
+			}
+			if cvssScore == 0 {
+				cvssScore = baseScore(related)
+			}
+			references = appendUnique(references, related.URLs...)
+		}
+
+		seen[id] = len(results)
+		results = append(results, vuln.Vulnerability{
+			ID:            id,
+			Name:          id,
+			Description:   description,
+			Severity:      severity(v.Severity),
+			CVSSScore:     cvssScore,
+			References:    references,
+			FixState:      v.Fix.State,
+			FixedVersions: appendUnique(nil, v.Fix.Versions...),
+		})
+		results[len(results)-1].AddPackage(affected)
+	}
+
+	return results, nil
+}
+
+func (g *GrypeScanner) PayloadForLinux(filePath string, flags flags.FlagSet) ([]string, error) {
+	args := []string{"dir:" + filePath, "-o", "json"}
+
+	if flags.Bool("OnlyFixed") {
+		args = append(args, "--only-fixed")
+	}
+	for _, pattern := range flags.Strings("Exclude") {
+		args = append(args, "--exclude", pattern)
+	}
+
+	return args, nil
+}
+
+func (g *GrypeScanner) PayloadForMac(filePath string, flags flags.FlagSet) ([]string, error) {
+	return g.PayloadForLinux(filePath, flags)
+}
+
+// baseScore returns the base score of the newest CVSS version reported.
+func baseScore(v grypeVulnerability) float64 {
+	var score float64
+	var version string
+	for _, cvss := range v.CVSS {
+		if cvss.Version >= version {
+			version = cvss.Version
+			score = cvss.Metrics.BaseScore
+		}
+	}
+	return score
+}
+
+func severity(s string) string {
+	switch strings.ToLower(s) {
+	case "negligible", "low":
+		return "Low"
+	case "medium":
+		return "Medium"
+	case "high":
+		return "High"
+	case "critical":
+		return "Critical"
+	default:
+		return "Unknown"
+	}
+}
+
+func appendUnique(list []string, values ...string) []string {
+	for _, value := range values {
+		if value != "" && !slices.Contains(list, value) {
+			list = append(list, value)
+		}
+	}
+	return list
+}
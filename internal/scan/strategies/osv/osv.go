@@ -0,0 +1,272 @@
+package osv
+
+import (
+	"encoding/json"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/SyntinelNyx/syntinel-server/internal/scan/flags"
+	base "github.com/SyntinelNyx/syntinel-server/internal/scan/strategies/base"
+	"github.com/SyntinelNyx/syntinel-server/internal/vuln"
+)
+
+type OSVScanner struct {
+	base.BaseScanner
+}
+
+type osvRecord struct {
+	ID        string   `json:"id"`
+	Aliases   []string `json:"aliases"`
+	Summary   string   `json:"summary"`
+	Details   string   `json:"details"`
+	Published string   `json:"published"`
+	Modified  string   `json:"modified"`
+	Affected  []struct {
+		Package struct {
+			Name string `json:"name"`
+		} `json:"package"`
+		Ranges []struct {
+			Events []struct {
+				Fixed string `json:"fixed"`
+			} `json:"events"`
+		} `json:"ranges"`
+	} `json:"affected"`
+	References []struct {
+		URL string `json:"url"`
+	} `json:"references"`
+	DatabaseSpecific struct {
+		Severity string `json:"severity"`
+	} `json:"database_specific"`
+}
+
+type OSVOutput struct {
+	Results []struct {
+		Source struct {
+			Path string `json:"path"`
+			Type string `json:"type"`
+		} `json:"source"`
+		Packages []struct {
+			Package struct {
+				Name      string `json:"name"`
+				Version   string `json:"version"`
+				Ecosystem string `json:"ecosystem"`
+			} `json:"package"`
+			Vulnerabilities []osvRecord `json:"vulnerabilities"`
+			Groups          []struct {
+				IDs         []string `json:"ids"`
+				Aliases     []string `json:"aliases"`
+				MaxSeverity string   `json:"max_severity"`
+			} `json:"groups"`
+		} `json:"packages"`
+	} `json:"results"`
+}
+
+func (o *OSVScanner) Name() string {
+	if o.BaseScanner.ScannerName == "" {
+		o.BaseScanner.Name("osv-scanner")
+	}
+
+	return o.BaseScanner.ScannerName
+}
+
+// SuccessExitCodes: osv-scanner exits 1 when it finds vulnerabilities and
+// 128 when there were no lockfiles or manifests to scan.
+func (o *OSVScanner) SuccessExitCodes() []int32 {
+	return []int32{1, 128}
+}
+
+func (o *OSVScanner) CalculateCommand(OS string, filePath string, flags flags.FlagSet) ([]string, error) {
+	return o.BaseScanner.CalculateCommand(OS, filePath, flags, o)
+}
+
+func (o *OSVScanner) FlagSchema() flags.Schema {
+	return flags.Schema{
+		{
+			Label:   "Recursive",
+			Type:    flags.Bool,
+			Default: true,
+		},
+		{
+			Label:   "Lockfiles",
+			Type:    flags.Strings,
+			Default: []string{},
+		},
+		{
+			Label:   "SkipGit",
+			Type:    flags.Bool,
+			Default: false,
+		},
+	}
+}
+
+func (o *OSVScanner) ParseResults(jsonOutput string) ([]vuln.Vulnerability, error) {
+	// Exit code 128 comes with no report at all.
+	if strings.TrimSpace(jsonOutput) == "" {
+		return nil, nil
+	}
+
+	var output OSVOutput
+	if err := json.Unmarshal([]byte(jsonOutput), &output); err != nil {
+		return nil, fmt.Errorf("Error Unmarshal: %s", err)
+	}
+
+	var results []vuln.Vulnerability
+	seen := make(map[string]int)
+
+	for _, result := range output.Results {
+		for _, pkg := range result.Packages {
+			for _, record := range pkg.Vulnerabilities {
+				aliases := append([]string{record.ID}, record.Aliases...)
+				var maxSeverity string
+				for _, group := range pkg.Groups {
+					if slices.Contains(group.IDs, record.ID) {
+						aliases = append(aliases, group.Aliases...)
+						maxSeverity = group.MaxSeverity
+						break
+					}
+				}
+
+				var fixed []string
+				for _, affected := range record.Affected {
+					if affected.Package.Name != pkg.Package.Name {
+						continue
+					}
+					for _, r := range affected.Ranges {
+						for _, event := range r.Events {
+							if event.Fixed != "" && !slices.Contains(fixed, event.Fixed) {
+								fixed = append(fixed, event.Fixed)
+							}
+						}
+					}
+				}
+
+				var references []string
+				for _, ref := range record.References {
+					references = append(references, ref.URL)
+				}
+
+				// OSV, GHSA and PYSEC records for one vulnerability share a
+				// group; report it once under the ID other scanners use.
+				id := vuln.PreferredID(record.ID, aliases)
+				affected := vuln.Package{
+					Name:             pkg.Package.Name,
+					InstalledVersion: pkg.Package.Version,
+					FixedVersion:     strings.Join(fixed, ", "),
+					Target:           result.Source.Path,
+					Type:             pkg.Package.Ecosystem,
+				}
+				if i, exists := seen[id]; exists {
+					merge(&results[i], record, fixed, references)
+					results[i].AddPackage(affected)
+					continue
+				}
+
+				cvssScore, _ := strconv.ParseFloat(maxSeverity, 64)
+				createdOn, _ := time.Parse(time.RFC3339, record.Published)
+				lastModified, _ := time.Parse(time.RFC3339, record.Modified)
+
+				fixState := "not-fixed"
+				if len(fixed) > 0 {
+					fixState = "fixed"
+				}
+
+				seen[id] = len(results)
+				results = append(results, vuln.Vulnerability{
+					ID:            id,
+					Name:          record.Summary,
+					Description:   record.Details,
+					Severity:      severity(record.DatabaseSpecific.Severity, cvssScore),
+					CVSSScore:     cvssScore,
+					CreatedOn:     createdOn,
+					LastModified:  lastModified,
+					References:    references,
+					FixState:      fixState,
+					FixedVersions: fixed,
+				})
+				results[len(results)-1].AddPackage(affected)
+			}
+		}
+	}
+
+	return results, nil
+}
+
+func (o *OSVScanner) PayloadForLinux(filePath string, flags flags.FlagSet) ([]string, error) {
+	args := []string{"--format", "json"}
+
+	if flags.Bool("Recursive") {
+		args = append(args, "--recursive")
+	}
+	if flags.Bool("SkipGit") {
+		args = append(args, "--skip-git")
+	}
+	for _, lockfile := range flags.Strings("Lockfiles") {
+		args = append(args, "--lockfile", lockfile)
+	}
+
+	return append(args, filePath), nil
+}
+
+func (o *OSVScanner) PayloadForWindows(filePath string, flags flags.FlagSet) ([]string, error) {
+	return o.PayloadForLinux(filePath, flags)
+}
+
+func (o *OSVScanner) PayloadForMac(filePath string, flags flags.FlagSet) ([]string, error) {
+	return o.PayloadForLinux(filePath, flags)
+}
+
+func merge(v *vuln.Vulnerability, record osvRecord, fixed []string, references []string) {
+	if v.Name == "" {
+		v.Name = record.Summary
+	}
+	if v.Description == "" {
+		v.Description = record.Details
+	}
+	if modified, err := time.Parse(time.RFC3339, record.Modified); err == nil && modified.After(v.LastModified) {
+		v.LastModified = modified
+	}
+	for _, version := range fixed {
+		if !slices.Contains(v.FixedVersions, version) {
+			v.FixedVersions = append(v.FixedVersions, version)
+		}
+	}
+	if len(v.FixedVersions) > 0 {
+		v.FixState = "fixed"
+	}
+	for _, ref := range references {
+		if !slices.Contains(v.References, ref) {
+			v.References = append(v.References, ref)
+		}
+	}
+}
+
+// severity uses the advisory's own rating when it has one, and otherwise
+// the CVSS qualitative rating of the score.
+func severity(rating string, score float64) string {
+	switch strings.ToUpper(rating) {
+	case "LOW":
+		return "Low"
+	case "MODERATE", "MEDIUM":
+		return "Medium"
+	case "HIGH":
+		return "High"
+	case "CRITICAL":
+		return "Critical"
+	}
+
+	switch {
+	case score >= 9:
+		return "Critical"
+	case score >= 7:
+		return "High"
+	case score >= 4:
+		return "Medium"
+	case score > 0:
+		return "Low"
+	default:
+		return "Unknown"
+	}
+}
@@ -4,6 +4,8 @@ import (
 	"errors"
 	"fmt"
 
+	"github.com/SyntinelNyx/syntinel-server/internal/scan/strategies/grype"
+	"github.com/SyntinelNyx/syntinel-server/internal/scan/strategies/osv"
 	"github.com/SyntinelNyx/syntinel-server/internal/scan/strategies/trivy"
 )
 
@@ -11,6 +13,8 @@ var registeredScanners = make(map[string]Scanner)
 
 func init() {
 	RegisterScanner(&trivy.TrivyScanner{})
+	RegisterScanner(&grype.GrypeScanner{})
+	RegisterScanner(&osv.OSVScanner{})
 }
 
 func RegisterScanner(scannerToAdd Scanner) error {
@@ -5,6 +5,11 @@ import (
 	"testing"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/SyntinelNyx/syntinel-server/internal/finding"
+	"github.com/SyntinelNyx/syntinel-server/internal/scan/flags"
+	"github.com/SyntinelNyx/syntinel-server/internal/vuln"
 )
 
 func MockGRPCOutput() (string, error) {
@@ -17,6 +22,12 @@ func MockGRPCOutput() (string, error) {
 	return string(data), nil
 }
 
+func decodeFlags(t *testing.T, scanner Scanner, set flags.FlagSet) flags.FlagSet {
+	decoded, err := scanner.FlagSchema().Decode(set)
+	require.NoError(t, err)
+	return decoded
+}
+
 func TestTrivyImplementation(t *testing.T) {
 	scanner, err := GetScanner("trivy")
 	assert.NoError(t, err)
@@ -36,12 +47,279 @@ func TestTrivyImplementation(t *testing.T) {
 	// }
 	// t.Logf("Total Vulns: %d", len(vulnerabilities))
 
-	payload, err := scanner.CalculateCommand("linux", "/", scanner.DefaultFlags())
+	payload, err := scanner.CalculateCommand("linux", "/", decodeFlags(t, scanner, nil))
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"fs", "/", "-f", "json", "--scanners", "vuln"}, payload[:6])
+	assert.Contains(t, payload, "HIGH,CRITICAL")
+	t.Logf("Payload: %v", payload)
+
+	payload, err = scanner.CalculateCommand("linux", "/", decodeFlags(t, scanner, flags.FlagSet{
+		{Label: "Scanners", Value: []any{"vuln", "secret"}},
+		{Label: "Severity", Value: []any{"CRITICAL"}},
+		{Label: "IgnoreUnfixed", Value: true},
+		{Label: "SkipFiles", Value: []any{"./a.js"}},
+		{Label: "SkipDirectory", Value: []any{}},
+	}))
 	assert.NoError(t, err)
-	t.Logf("Payload: %s", payload)
+	assert.Equal(t, []string{"vuln", "secret"}, FindingTypes(scanner, decodeFlags(t, scanner, flags.FlagSet{{Label: "Scanners", Value: []any{"vuln", "secret"}}})))
+	assert.Equal(t, []string{"fs", "/", "-f", "json", "--scanners", "vuln,secret", "--severity", "CRITICAL", "--ignore-unfixed", "--skip-files", "./a.js"}, payload)
 
-	_, err = scanner.CalculateCommand("windows", "/", scanner.DefaultFlags())
+	_, err = scanner.CalculateCommand("windows", "/", decodeFlags(t, scanner, nil))
 	assert.Error(t, err)
 	t.Logf("Err: %s", err)
 
 }
+
+func TestTrivyParseResults(t *testing.T) {
+	data, err := os.ReadFile("testdata/trivy.json")
+	require.NoError(t, err)
+
+	scanner, err := GetScanner("trivy")
+	require.NoError(t, err)
+
+	vulnerabilities, err := scanner.ParseResults(string(data))
+	require.NoError(t, err)
+	require.Len(t, vulnerabilities, 1)
+
+	lodash := vulnerabilities[0]
+	assert.Equal(t, "CVE-2021-23337", lodash.ID)
+	assert.Equal(t, "High", lodash.Severity)
+	assert.Equal(t, 7.2, lodash.CVSSScore)
+	assert.Equal(t, []vuln.Package{
+		{
+			Name:             "lodash",
+			InstalledVersion: "4.17.20",
+			FixedVersion:     "4.17.21",
+			Path:             "opt/app/node_modules/lodash/package.json",
+			Target:           "opt/app/package-lock.json",
+			Type:             "npm",
+		},
+		{
+			Name:             "lodash",
+			InstalledVersion: "4.17.15",
+			FixedVersion:     "4.17.21",
+			Target:           "srv/web/package-lock.json",
+			Type:             "npm",
+		},
+	}, lodash.Packages)
+
+	withStderr := "2024-05-01T10:00:00Z\tINFO\tVulnerability scanning is enabled\n" + string(data)
+	vulnerabilities, err = scanner.ParseResults(withStderr)
+	require.NoError(t, err)
+	assert.Len(t, vulnerabilities, 1)
+}
+
+func TestTrivyParseFindings(t *testing.T) {
+	data, err := os.ReadFile("testdata/trivy.json")
+	require.NoError(t, err)
+
+	scanner, err := GetScanner("trivy")
+	require.NoError(t, err)
+
+	findings, err := ParseFindings(scanner, string(data))
+	require.NoError(t, err)
+	assert.Equal(t, []finding.Finding{
+		{
+			Type:        finding.TypeSecret,
+			RuleID:      "aws-access-key-id",
+			Title:       "AWS Access Key ID",
+			Description: "AWS",
+			Severity:    "Critical",
+			FilePath:    "opt/app/.env",
+			StartLine:   3,
+			EndLine:     3,
+			Target:      "opt/app/.env",
+		},
+		{
+			Type:        finding.TypeMisconfig,
+			RuleID:      "DS002",
+			Title:       "Image user should not be 'root'",
+			Description: "Running containers with 'root' user can lead to a container escape situation.",
+			Severity:    "High",
+			Resolution:  "Add 'USER <non root user name>' line to the Dockerfile",
+			References: []string{
+				"https://docs.docker.com/develop/develop-images/dockerfile_best-practices/",
+				"https://avd.aquasec.com/misconfig/ds002",
+			},
+			FilePath:  "opt/app/Dockerfile",
+			StartLine: 1,
+			EndLine:   1,
+			Target:    "opt/app/Dockerfile",
+			Message:   "Specify at least 1 USER command in Dockerfile with non-root user as argument",
+		},
+		{
+			Type:        finding.TypeLicense,
+			RuleID:      "GPL-3.0",
+			Title:       "GPL-3.0",
+			Description: "restricted",
+			Severity:    "High",
+			FilePath:    "opt/app/node_modules/readline-sync/package.json",
+			LicenseName: "GPL-3.0",
+			Package:     "readline-sync",
+			Target:      "Node.js",
+		},
+	}, findings)
+
+	grype, err := GetScanner("grype")
+	require.NoError(t, err)
+	assert.Equal(t, []string{finding.TypeVulnerability}, FindingTypes(grype, nil))
+}
+
+func TestGrypeImplementation(t *testing.T) {
+	scanner, err := GetScanner("grype")
+	assert.NoError(t, err)
+
+	assert.Equal(t, "grype", scanner.Name())
+
+	payload, err := scanner.CalculateCommand("linux", "/", decodeFlags(t, scanner, nil))
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"dir:/", "-o", "json", "--exclude", "./proc/**", "--exclude", "./sys/**"}, payload)
+
+	payload, err = scanner.CalculateCommand("mac", "/Users", decodeFlags(t, scanner, flags.FlagSet{
+		{Label: "OnlyFixed", Value: true},
+		{Label: "Exclude", Value: []any{"./Library/**"}},
+	}))
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"dir:/Users", "-o", "json", "--only-fixed", "--exclude", "./Library/**"}, payload)
+
+	_, err = scanner.CalculateCommand("windows", "/", decodeFlags(t, scanner, nil))
+	assert.Error(t, err)
+}
+
+func TestGrypeParseResults(t *testing.T) {
+	data, err := os.ReadFile("testdata/grype.json")
+	require.NoError(t, err)
+
+	scanner, err := GetScanner("grype")
+	require.NoError(t, err)
+
+	vulnerabilities, err := scanner.ParseResults(string(data))
+	require.NoError(t, err)
+	require.Len(t, vulnerabilities, 3)
+
+	openssl := vulnerabilities[0]
+	assert.Equal(t, "CVE-2023-5363", openssl.ID)
+	assert.Equal(t, "High", openssl.Severity)
+	assert.Equal(t, 7.5, openssl.CVSSScore)
+	assert.Equal(t, "fixed", openssl.FixState)
+	assert.Equal(t, []string{"3.0.11-1~deb12u2", "3.0.11-1~deb12u3"}, openssl.FixedVersions)
+	assert.Contains(t, openssl.References, "https://www.openssl.org/news/secadv/20231024.txt")
+	require.Len(t, openssl.Packages, 2)
+	assert.Equal(t, vuln.Package{
+		Name:             "libssl3",
+		InstalledVersion: "3.0.11-1~deb12u1",
+		FixedVersion:     "3.0.11-1~deb12u2",
+		Path:             "/var/lib/dpkg/status",
+		Type:             "deb",
+	}, openssl.Packages[0])
+	assert.Equal(t, "openssl", openssl.Packages[1].Name)
+
+	log4j := vulnerabilities[1]
+	assert.Equal(t, "CVE-2021-44228", log4j.ID)
+	assert.Contains(t, log4j.References, "https://github.com/advisories/GHSA-jfh8-c2jp-5v3q")
+	assert.Equal(t, "Critical", log4j.Severity)
+	assert.Equal(t, 10.0, log4j.CVSSScore)
+	assert.Contains(t, log4j.Description, "Apache Log4j2 JNDI")
+	assert.Equal(t, []string{"2.15.0"}, log4j.FixedVersions)
+
+	apt := vulnerabilities[2]
+	assert.Equal(t, "CVE-2011-3374", apt.ID)
+	assert.Equal(t, "Low", apt.Severity)
+	assert.Equal(t, 3.7, apt.CVSSScore)
+	assert.Equal(t, "not-fixed", apt.FixState)
+	assert.Empty(t, apt.FixedVersions)
+
+	_, err = scanner.ParseResults("not json")
+	assert.Error(t, err)
+}
+
+func TestOSVScannerImplementation(t *testing.T) {
+	scanner, err := GetScanner("osv-scanner")
+	assert.NoError(t, err)
+
+	assert.Equal(t, "osv-scanner", scanner.Name())
+	assert.Equal(t, []int32{1, 128}, ExitCodes(scanner))
+
+	payload, err := scanner.CalculateCommand("linux", "/srv", decodeFlags(t, scanner, nil))
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"--format", "json", "--recursive", "/srv"}, payload)
+
+	payload, err = scanner.CalculateCommand("windows", `C:\src`, decodeFlags(t, scanner, flags.FlagSet{
+		{Label: "Recursive", Value: false},
+		{Label: "Lockfiles", Value: []any{`C:\src\go.mod`, `C:\src\package-lock.json`}},
+		{Label: "SkipGit", Value: true},
+	}))
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"--format", "json", "--skip-git", "--lockfile", `C:\src\go.mod`, "--lockfile", `C:\src\package-lock.json`, `C:\src`}, payload)
+}
+
+func TestOSVScannerParseResults(t *testing.T) {
+	data, err := os.ReadFile("testdata/osv-scanner.json")
+	require.NoError(t, err)
+
+	scanner, err := GetScanner("osv-scanner")
+	require.NoError(t, err)
+
+	vulnerabilities, err := scanner.ParseResults(string(data))
+	require.NoError(t, err)
+	require.Len(t, vulnerabilities, 4)
+
+	lodash := vulnerabilities[0]
+	assert.Equal(t, "CVE-2021-23337", lodash.ID)
+	assert.Equal(t, "Command Injection in lodash", lodash.Name)
+	assert.Equal(t, "High", lodash.Severity)
+	assert.Equal(t, 7.2, lodash.CVSSScore)
+	assert.Equal(t, []string{"4.17.21"}, lodash.FixedVersions)
+	require.Len(t, lodash.Packages, 1)
+	assert.Equal(t, "lodash", lodash.Packages[0].Name)
+	assert.Equal(t, "4.17.21", lodash.Packages[0].FixedVersion)
+	assert.Equal(t, "npm", lodash.Packages[0].Type)
+	assert.NotEmpty(t, lodash.Packages[0].Target)
+	assert.Equal(t, 2021, lodash.CreatedOn.Year())
+
+	urllib3 := vulnerabilities[1]
+	assert.Equal(t, "CVE-2023-43804", urllib3.ID)
+	assert.Equal(t, 8.1, urllib3.CVSSScore)
+	assert.Equal(t, []string{"1.26.17", "2.0.6"}, urllib3.FixedVersions)
+	assert.Len(t, urllib3.References, 2)
+	assert.Equal(t, 5, int(urllib3.LastModified.Month()))
+
+	goNet := vulnerabilities[2]
+	assert.Equal(t, "GHSA-fxg5-wq6x-vr4w", goNet.ID)
+	assert.Equal(t, "Medium", goNet.Severity)
+
+	smallvec := vulnerabilities[3]
+	assert.Equal(t, "RUSTSEC-2021-0003", smallvec.ID)
+	assert.Equal(t, "Unknown", smallvec.Severity)
+	assert.Equal(t, "fixed", smallvec.FixState)
+	assert.Equal(t, []string{"0.6.14", "1.6.1"}, smallvec.FixedVersions)
+
+	vulnerabilities, err = scanner.ParseResults("")
+	assert.NoError(t, err)
+	assert.Empty(t, vulnerabilities)
+}
+
+func TestPreferredIDAgreesAcrossScanners(t *testing.T) {
+	// Grype and osv-scanner list different aliases for the same advisory.
+	grypeOutput := `{"matches":[{
+		"vulnerability":{"id":"GHSA-jfh8-c2jp-5v3q","severity":"Critical"},
+		"relatedVulnerabilities":[{"id":"CVE-2021-10000"},{"id":"CVE-2021-9999"}],
+		"artifact":{"name":"log4j-core","version":"2.14.1"}}]}`
+	osvOutput := `{"results":[{"source":{"path":"/srv/pom.xml"},"packages":[{
+		"package":{"name":"log4j-core","version":"2.14.1","ecosystem":"Maven"},
+		"vulnerabilities":[{"id":"GHSA-jfh8-c2jp-5v3q","aliases":["CVE-2021-10000"]}],
+		"groups":[{"ids":["GHSA-jfh8-c2jp-5v3q"],"aliases":["CVE-2021-9999","GHSA-jfh8-c2jp-5v3q"]}]}]}]}`
+
+	var ids []string
+	for scannerName, output := range map[string]string{"grype": grypeOutput, "osv-scanner": osvOutput} {
+		scanner, err := GetScanner(scannerName)
+		require.NoError(t, err)
+
+		vulnerabilities, err := scanner.ParseResults(output)
+		require.NoError(t, err)
+		require.Len(t, vulnerabilities, 1)
+		ids = append(ids, vulnerabilities[0].ID)
+	}
+
+	assert.Equal(t, []string{"CVE-2021-9999", "CVE-2021-9999"}, ids)
+}
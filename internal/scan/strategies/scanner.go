@@ -1,19 +1,65 @@
 package strategies
 
 import (
+	"github.com/SyntinelNyx/syntinel-server/internal/finding"
 	"github.com/SyntinelNyx/syntinel-server/internal/scan/flags"
 	"github.com/SyntinelNyx/syntinel-server/internal/vuln"
 )
 
 type Scanner interface {
 	Name() string
-	DefaultFlags() flags.FlagSet
+	// FlagSchema declares the flags the scanner accepts. CalculateCommand
+	// is only given flags decoded with it.
+	FlagSchema() flags.Schema
 
-	CalculateCommand(OS string, filePath string, flags flags.FlagSet) (string, error)
+	CalculateCommand(OS string, filePath string, flags flags.FlagSet) ([]string, error)
 
+	// ParseResults returns one vulnerability per ID, with every package it
+	// was found in listed in its Packages.
 	ParseResults(jsonOutput string) ([]vuln.Vulnerability, error)
 
-	PayloadForLinux() (string, error)
-	PayloadForWindows() (string, error)
-	PayloadForMac() (string, error)
+	PayloadForLinux(filePath string, flags flags.FlagSet) ([]string, error)
+	PayloadForWindows(filePath string, flags flags.FlagSet) ([]string, error)
+	PayloadForMac(filePath string, flags flags.FlagSet) ([]string, error)
+}
+
+// SuccessExitCodes is implemented by scanners that exit non-zero after a
+// successful scan, e.g. to signal that vulnerabilities were found.
+type SuccessExitCodes interface {
+	SuccessExitCodes() []int32
+}
+
+// ExitCodes returns the non-zero exit codes that do not mean scanner failed.
+func ExitCodes(scanner Scanner) []int32 {
+	if s, ok := scanner.(SuccessExitCodes); ok {
+		return s.SuccessExitCodes()
+	}
+	return nil
+}
+
+// FindingParser is implemented by scanners that can report findings other
+// than vulnerabilities, such as secrets or misconfigurations.
+type FindingParser interface {
+	// FindingTypes returns the types of finding a scan with flags looks for,
+	// including finding.TypeVulnerability if it looks for vulnerabilities.
+	FindingTypes(flags flags.FlagSet) []string
+	ParseFindings(jsonOutput string) ([]finding.Finding, error)
+}
+
+// FindingTypes returns the finding types that a scan with flags reports.
+// Scanners that only report vulnerabilities report finding.TypeVulnerability.
+func FindingTypes(scanner Scanner, flags flags.FlagSet) []string {
+	if p, ok := scanner.(FindingParser); ok {
+		return p.FindingTypes(flags)
+	}
+	return []string{finding.TypeVulnerability}
+}
+
+// ParseFindings returns the findings, besides vulnerabilities, in a
+// scanner's output.
+func ParseFindings(scanner Scanner, jsonOutput string) ([]finding.Finding, error) {
+	if p, ok := scanner.(FindingParser); ok {
+		return p.ParseFindings(jsonOutput)
+	}
+	return nil, nil
 }
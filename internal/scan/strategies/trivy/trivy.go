@@ -3,9 +3,11 @@ package trivy
 import (
 	"encoding/json"
 	"fmt"
+	"slices"
 	"strings"
 	"time"
 
+	"github.com/SyntinelNyx/syntinel-server/internal/finding"
 	"github.com/SyntinelNyx/syntinel-server/internal/scan/flags"
 	base "github.com/SyntinelNyx/syntinel-server/internal/scan/strategies/base"
 	"github.com/SyntinelNyx/syntinel-server/internal/vuln"
@@ -18,19 +20,55 @@ type TrivyScanner struct {
 type TrivyOutput struct {
 	Results []struct {
 		Target          string `json:"Target"`
+		Type            string `json:"Type"`
 		Vulnerabilities []struct {
-			VulnerabilityID string   `json:"VulnerabilityID"`
-			Title           string   `json:"Title"`
-			Description     string   `json:"Description"`
-			Severity        string   `json:"Severity"`
-			References      []string `json:"References"`
-			CVSS            map[string]struct {
+			VulnerabilityID  string   `json:"VulnerabilityID"`
+			PkgName          string   `json:"PkgName"`
+			PkgPath          string   `json:"PkgPath"`
+			InstalledVersion string   `json:"InstalledVersion"`
+			FixedVersion     string   `json:"FixedVersion"`
+			Title            string   `json:"Title"`
+			Description      string   `json:"Description"`
+			Severity         string   `json:"Severity"`
+			References       []string `json:"References"`
+			CVSS             map[string]struct {
 				V3Score float64 `json:"V3Score"`
 			} `json:"CVSS"`
 			VendorSeverity   map[string]int `json:"VendorSeverity"`
 			PublishedDate    string         `json:"PublishedDate"`
 			LastModifiedDate string         `json:"LastModifiedDate"`
 		} `json:"Vulnerabilities"`
+		Secrets []struct {
+			RuleID    string `json:"RuleID"`
+			Category  string `json:"Category"`
+			Severity  string `json:"Severity"`
+			Title     string `json:"Title"`
+			StartLine int    `json:"StartLine"`
+			EndLine   int    `json:"EndLine"`
+		} `json:"Secrets"`
+		Misconfigurations []struct {
+			ID            string   `json:"ID"`
+			Title         string   `json:"Title"`
+			Description   string   `json:"Description"`
+			Message       string   `json:"Message"`
+			Resolution    string   `json:"Resolution"`
+			Severity      string   `json:"Severity"`
+			PrimaryURL    string   `json:"PrimaryURL"`
+			References    []string `json:"References"`
+			Status        string   `json:"Status"`
+			CauseMetadata struct {
+				StartLine int `json:"StartLine"`
+				EndLine   int `json:"EndLine"`
+			} `json:"CauseMetadata"`
+		} `json:"Misconfigurations"`
+		Licenses []struct {
+			Severity string `json:"Severity"`
+			Category string `json:"Category"`
+			PkgName  string `json:"PkgName"`
+			FilePath string `json:"FilePath"`
+			Name     string `json:"Name"`
+			Link     string `json:"Link"`
+		} `json:"Licenses"`
 	} `json:"Results"`
 }
 
@@ -42,35 +80,44 @@ func (t *TrivyScanner) Name() string {
 	return t.BaseScanner.ScannerName
 }
 
-func (t *TrivyScanner) CalculateCommand(OS string, filePath string, flags flags.FlagSet) (string, error) {
+func (t *TrivyScanner) CalculateCommand(OS string, filePath string, flags flags.FlagSet) ([]string, error) {
 	return t.BaseScanner.CalculateCommand(OS, filePath, flags, t)
 }
 
-func (t *TrivyScanner) DefaultFlags() flags.FlagSet {
-	return flags.FlagSet{
+func (t *TrivyScanner) FlagSchema() flags.Schema {
+	return flags.Schema{
 		{
-			Label:     "Severity",
-			InputType: "string",
-			Value:     "HIGH,CRITICAL",
-			Required:  false,
+			Label:   "Scanners",
+			Type:    flags.Strings,
+			Default: []string{finding.TypeVulnerability},
+			Options: []string{finding.TypeVulnerability, finding.TypeSecret, finding.TypeMisconfig, finding.TypeLicense},
+			Validate: func(value any) error {
+				if len(value.([]string)) == 0 {
+					return fmt.Errorf("must include at least one scanner")
+				}
+				return nil
+			},
 		},
 		{
-			Label:     "IgnoreUnfixed",
-			InputType: "boolean",
-			Value:     false,
-			Required:  false,
+			Label:   "Severity",
+			Type:    flags.Strings,
+			Default: []string{"HIGH", "CRITICAL"},
+			Options: []string{"UNKNOWN", "LOW", "MEDIUM", "HIGH", "CRITICAL"},
 		},
 		{
-			Label:     "SkipFiles",
-			InputType: "strings",
-			Value:     []string{"./file.js", "./docs/**/*.md"},
-			Required:  false,
+			Label:   "IgnoreUnfixed",
+			Type:    flags.Bool,
+			Default: false,
 		},
 		{
-			Label:     "SkipDirectory",
-			InputType: "strings",
-			Value:     []string{"/docs/", "/testfiles/*"},
-			Required:  false,
+			Label:   "SkipFiles",
+			Type:    flags.Strings,
+			Default: []string{"./file.js", "./docs/**/*.md"},
+		},
+		{
+			Label:   "SkipDirectory",
+			Type:    flags.Strings,
+			Default: []string{"/docs/", "/testfiles/*"},
 		},
 	}
 }
@@ -79,25 +126,30 @@ func (t *TrivyScanner) ParseResults(jsonOutput string) ([]vuln.Vulnerability, er
 	// Source: https://gobyexample.com/json
 	var output TrivyOutput
 
-	index := strings.Index(jsonOutput, "{")
-	if index != -1 {
-		jsonOutput = jsonOutput[index:]
-	}
-
-	if err := json.Unmarshal([]byte(jsonOutput), &output); err != nil {
+	if err := json.Unmarshal([]byte(skipToJSON(jsonOutput)), &output); err != nil {
 		return nil, fmt.Errorf("Error Unmarshal: %s", err)
 	}
 
 	var results []vuln.Vulnerability
-	seenCVE := make(map[string]struct{})
+	seenCVE := make(map[string]int)
 
 	for _, result := range output.Results {
 		for _, vulnData := range result.Vulnerabilities {
-			if _, exists := seenCVE[vulnData.VulnerabilityID]; exists {
+			affected := vuln.Package{
+				Name:             vulnData.PkgName,
+				InstalledVersion: vulnData.InstalledVersion,
+				FixedVersion:     vulnData.FixedVersion,
+				Path:             vulnData.PkgPath,
+				Target:           result.Target,
+				Type:             result.Type,
+			}
+
+			if i, exists := seenCVE[vulnData.VulnerabilityID]; exists {
+				results[i].AddPackage(affected)
 				continue
 			}
 
-			seenCVE[vulnData.VulnerabilityID] = struct{}{}
+			seenCVE[vulnData.VulnerabilityID] = len(results)
 
 			var vendor string
 			for vendorKey := range vulnData.CVSS {
@@ -145,6 +197,7 @@ func (t *TrivyScanner) ParseResults(jsonOutput string) ([]vuln.Vulnerability, er
 				LastModified: lastModified,
 				References:   vulnData.References,
 			}
+			vuln.AddPackage(affected)
 
 			results = append(results, vuln)
 		}
@@ -153,59 +206,123 @@ func (t *TrivyScanner) ParseResults(jsonOutput string) ([]vuln.Vulnerability, er
 	return results, nil
 }
 
-func (t *TrivyScanner) PayloadForLinux() (string, error) {
-	payload := fmt.Sprintf("trivy fs %s -f json --scanners vuln", t.FilePath)
+// FindingTypes: Trivy's scanners are named after the finding types they
+// report.
+func (t *TrivyScanner) FindingTypes(flags flags.FlagSet) []string {
+	return flags.Strings("Scanners")
+}
 
-	for _, flag := range t.Flags {
-		label := flag.Label
-		inputType := flag.InputType
+func (t *TrivyScanner) ParseFindings(jsonOutput string) ([]finding.Finding, error) {
+	var output TrivyOutput
 
-		switch label {
-		case "Severity":
-			if inputType != "string" {
-				continue
-			}
-			strVal, ok := flag.Value.(string)
-			if !ok || strVal == "" {
-				continue
-			}
-			payload += " --severity " + strVal
+	if err := json.Unmarshal([]byte(skipToJSON(jsonOutput)), &output); err != nil {
+		return nil, fmt.Errorf("Error Unmarshal: %s", err)
+	}
 
-		case "IgnoreUnfixed":
-			if inputType != "bool" {
-				continue
-			}
-			boolVal, ok := flag.Value.(bool)
-			if !ok || !boolVal {
-				continue
-			}
-			payload += " --ignore-unfixed"
+	var results []finding.Finding
+	for _, result := range output.Results {
+		for _, secret := range result.Secrets {
+			results = append(results, finding.Finding{
+				Type:        finding.TypeSecret,
+				RuleID:      secret.RuleID,
+				Title:       secret.Title,
+				Description: secret.Category,
+				Severity:    findingSeverity(secret.Severity),
+				FilePath:    result.Target,
+				StartLine:   secret.StartLine,
+				EndLine:     secret.EndLine,
+				Target:      result.Target,
+			})
+		}
 
-		case "SkipFiles":
-			if inputType != "strings" {
+		for _, misconfig := range result.Misconfigurations {
+			if misconfig.Status != "FAIL" {
 				continue
 			}
-			arrVal, ok := flag.Value.([]string)
-			if !ok || len(arrVal) == 0 {
-				continue
-			}
-			for _, file := range arrVal {
-				payload += " --skip-files " + file
-			}
 
-		case "SkipDirectory":
-			if inputType != "strings" {
-				continue
-			}
-			arrVal, ok := flag.Value.([]string)
-			if !ok || len(arrVal) == 0 {
-				continue
+			references := misconfig.References
+			if misconfig.PrimaryURL != "" && !slices.Contains(references, misconfig.PrimaryURL) {
+				references = append([]string{misconfig.PrimaryURL}, references...)
 			}
-			for _, dir := range arrVal {
-				payload += " --skip-dir " + dir
+
+			results = append(results, finding.Finding{
+				Type:        finding.TypeMisconfig,
+				RuleID:      misconfig.ID,
+				Title:       misconfig.Title,
+				Description: misconfig.Description,
+				Severity:    findingSeverity(misconfig.Severity),
+				Resolution:  misconfig.Resolution,
+				References:  references,
+				FilePath:    result.Target,
+				StartLine:   misconfig.CauseMetadata.StartLine,
+				EndLine:     misconfig.CauseMetadata.EndLine,
+				Target:      result.Target,
+				Message:     misconfig.Message,
+			})
+		}
+
+		for _, license := range result.Licenses {
+			var references []string
+			if license.Link != "" {
+				references = []string{license.Link}
 			}
+
+			results = append(results, finding.Finding{
+				Type:        finding.TypeLicense,
+				RuleID:      license.Name,
+				Title:       license.Name,
+				Description: license.Category,
+				Severity:    findingSeverity(license.Severity),
+				References:  references,
+				FilePath:    license.FilePath,
+				LicenseName: license.Name,
+				Package:     license.PkgName,
+				Target:      result.Target,
+			})
 		}
 	}
 
-	return payload, nil
+	return results, nil
+}
+
+func findingSeverity(s string) string {
+	switch strings.ToUpper(s) {
+	case "LOW":
+		return "Low"
+	case "MEDIUM":
+		return "Medium"
+	case "HIGH":
+		return "High"
+	case "CRITICAL":
+		return "Critical"
+	default:
+		return "Unknown"
+	}
+}
+
+func (t *TrivyScanner) PayloadForLinux(filePath string, flags flags.FlagSet) ([]string, error) {
+	args := []string{"fs", filePath, "-f", "json", "--scanners", strings.Join(flags.Strings("Scanners"), ",")}
+
+	if severity := flags.Strings("Severity"); len(severity) > 0 {
+		args = append(args, "--severity", strings.Join(severity, ","))
+	}
+	if flags.Bool("IgnoreUnfixed") {
+		args = append(args, "--ignore-unfixed")
+	}
+	for _, file := range flags.Strings("SkipFiles") {
+		args = append(args, "--skip-files", file)
+	}
+	for _, dir := range flags.Strings("SkipDirectory") {
+		args = append(args, "--skip-dir", dir)
+	}
+
+	return args, nil
+}
+
+// skipToJSON drops anything older agents wrote to stderr before the report.
+func skipToJSON(output string) string {
+	if index := strings.Index(output, "{"); index != -1 {
+		return output[index:]
+	}
+	return output
 }
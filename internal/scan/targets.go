@@ -0,0 +1,128 @@
+package scan
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/jackc/pgx/v5/pgtype"
+
+	"github.com/SyntinelNyx/syntinel-server/internal/database/query"
+	"github.com/SyntinelNyx/syntinel-server/internal/response"
+)
+
+// ScanTarget selects the assets a scan runs against. Each field is resolved
+// within the caller's root account and an asset matching any of them is
+// scanned. Hostnames are only accepted for launching a scan straight away,
+// as they are not unique.
+type ScanTarget struct {
+	Assets       []string `json:"assets"`
+	Environments []string `json:"environments"`
+	Tags         []string `json:"tags"`
+	Hostnames    []string `json:"hostnames,omitempty"`
+}
+
+// targetParams is a parsed ScanTarget.
+type targetParams struct {
+	assetIDs       []pgtype.UUID
+	environmentIDs []pgtype.UUID
+	tags           []string
+	hostnames      []string
+}
+
+func (t ScanTarget) params() (targetParams, error) {
+	params := targetParams{tags: []string{}, hostnames: []string{}}
+
+	var err error
+	if params.assetIDs, err = parseUUIDs(t.Assets); err != nil {
+		return targetParams{}, fmt.Errorf("invalid asset ID: %v", err)
+	}
+	if params.environmentIDs, err = parseUUIDs(t.Environments); err != nil {
+		return targetParams{}, fmt.Errorf("invalid environment ID: %v", err)
+	}
+	for _, tag := range t.Tags {
+		if tag != "" {
+			params.tags = append(params.tags, tag)
+		}
+	}
+	for _, hostname := range t.Hostnames {
+		if hostname != "" {
+			params.hostnames = append(params.hostnames, hostname)
+		}
+	}
+
+	if len(params.assetIDs)+len(params.environmentIDs)+len(params.tags)+len(params.hostnames) == 0 {
+		return targetParams{}, fmt.Errorf("target must include at least one asset, environment or tag")
+	}
+	return params, nil
+}
+
+// target is the form of the target recorded on scan jobs and scans.
+func (p targetParams) target() ScanTarget {
+	target := ScanTarget{
+		Assets:       []string{},
+		Environments: []string{},
+		Tags:         p.tags,
+		Hostnames:    p.hostnames,
+	}
+	for _, id := range p.assetIDs {
+		target.Assets = append(target.Assets, response.UuidToString(id))
+	}
+	for _, id := range p.environmentIDs {
+		target.Environments = append(target.Environments, response.UuidToString(id))
+	}
+	if target.Tags == nil {
+		target.Tags = []string{}
+	}
+	return target
+}
+
+func (p targetParams) marshal() ([]byte, error) {
+	target, err := json.Marshal(p.target())
+	if err != nil {
+		return nil, fmt.Errorf("error encoding scan target: %v", err)
+	}
+	return target, nil
+}
+
+// resolveTarget returns the assets of the root account that the target
+// currently matches.
+func (h *Handler) resolveTarget(ctx context.Context, rootID pgtype.UUID, p targetParams) ([]pgtype.UUID, error) {
+	params := query.GetScanTargetAssetsParams{
+		RootAccountID:  rootID,
+		AssetIds:       p.assetIDs,
+		EnvironmentIds: p.environmentIDs,
+		Tags:           p.tags,
+		Hostnames:      p.hostnames,
+	}
+	if params.AssetIds == nil {
+		params.AssetIds = []pgtype.UUID{}
+	}
+	if params.EnvironmentIds == nil {
+		params.EnvironmentIds = []pgtype.UUID{}
+	}
+	if params.Tags == nil {
+		params.Tags = []string{}
+	}
+	if params.Hostnames == nil {
+		params.Hostnames = []string{}
+	}
+
+	assetIDs, err := h.queries.GetScanTargetAssets(ctx, params)
+	if err != nil {
+		return nil, fmt.Errorf("error resolving scan target: %v", err)
+	}
+	return assetIDs, nil
+}
+
+func parseUUIDs(values []string) ([]pgtype.UUID, error) {
+	ids := []pgtype.UUID{}
+	for _, value := range values {
+		var id pgtype.UUID
+		if err := id.Scan(value); err != nil {
+			return nil, fmt.Errorf("%q: %v", value, err)
+		}
+		ids = append(ids, id)
+	}
+	return ids, nil
+}
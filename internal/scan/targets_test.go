@@ -0,0 +1,76 @@
+package scan
+
+import (
+	"context"
+	"testing"
+
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/SyntinelNyx/syntinel-server/internal/scan/flags"
+)
+
+func TestScanTargetParams(t *testing.T) {
+	params, err := ScanTarget{
+		Assets:    []string{"3F1C2F7E-8A4B-4E0C-9D1A-5B6E7F8A9B0C"},
+		Tags:      []string{"", "web"},
+		Hostnames: []string{"web-01", ""},
+	}.params()
+	require.NoError(t, err)
+	assert.Len(t, params.assetIDs, 1)
+	assert.Empty(t, params.environmentIDs)
+	assert.Equal(t, []string{"web"}, params.tags)
+	assert.Equal(t, []string{"web-01"}, params.hostnames)
+
+	assert.Equal(t, ScanTarget{
+		Assets:       []string{"3f1c2f7e-8a4b-4e0c-9d1a-5b6e7f8a9b0c"},
+		Environments: []string{},
+		Tags:         []string{"web"},
+		Hostnames:    []string{"web-01"},
+	}, params.target())
+
+	for name, target := range map[string]ScanTarget{
+		"empty":           {},
+		"blank tags":      {Tags: []string{""}},
+		"bad asset":       {Assets: []string{"web-01"}},
+		"bad environment": {Environments: []string{"prod"}},
+	} {
+		_, err := target.params()
+		assert.Error(t, err, name)
+	}
+}
+
+func TestCreateJobRejectsInvalidRequests(t *testing.T) {
+	h := NewHandler(nil)
+	target := ScanTarget{Tags: []string{"web"}}
+
+	_, err := h.CreateJob(context.Background(), "nessus", nil, target, pgtype.UUID{}, "root")
+	assert.ErrorIs(t, err, ErrInvalidScan)
+
+	_, err = h.CreateJob(context.Background(), "trivy", nil, ScanTarget{Assets: []string{"web-01"}}, pgtype.UUID{}, "root")
+	assert.ErrorIs(t, err, ErrInvalidScan)
+
+	_, err = h.CreateJob(context.Background(), "trivy", flags.FlagSet{{Label: "Verbose", Value: true}}, target, pgtype.UUID{}, "root")
+	assert.ErrorIs(t, err, ErrInvalidScan)
+	var flagErr *flags.ValidationError
+	assert.ErrorAs(t, err, &flagErr)
+}
+
+func TestDecodeStoredFlags(t *testing.T) {
+	stored := flags.FlagSet{
+		{Label: "Severity", Value: "HIGH,CRITICAL"},
+		{Label: "Skip Files", Value: "a.txt"},
+	}
+
+	_, _, err := decodeFlags("trivy", stored)
+	assert.Error(t, err)
+
+	_, decoded, err := decodeStoredFlags("trivy", stored)
+	require.NoError(t, err)
+	assert.Equal(t, "/", decoded.String(filesystemFlag.Label))
+	assert.Equal(t, []string{"HIGH", "CRITICAL"}, decoded.Strings("Severity"))
+
+	_, _, err = decodeStoredFlags("trivy", flags.FlagSet{{Label: filesystemFlag.Label, Value: ""}})
+	assert.Error(t, err)
+}
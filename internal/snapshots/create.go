@@ -2,11 +2,13 @@ package snapshots
 
 import (
 	"context"
+	"errors"
 	"fmt"
-	"net"
 	"net/http"
 
+	"github.com/SyntinelNyx/syntinel-server/internal/capability"
 	"github.com/SyntinelNyx/syntinel-server/internal/commands"
+	"github.com/SyntinelNyx/syntinel-server/internal/endpoint"
 	"github.com/SyntinelNyx/syntinel-server/internal/logger"
 	"github.com/SyntinelNyx/syntinel-server/internal/proto/controlpb"
 	"github.com/SyntinelNyx/syntinel-server/internal/response"
@@ -36,48 +38,35 @@ func (h *Handler) CreateSnapshot(w http.ResponseWriter, r *http.Request) {
 	}
 	assetID = uuid
 
-	agentip, err := h.queries.GetIPByAssetID(context.Background(), assetID)
+	target, err := endpoint.Resolve(context.Background(), h.queries, assetID)
 	if err != nil {
-		logger.Error("Error retrieving agent IP: %v", err)
-		response.RespondWithError(w, r, http.StatusInternalServerError, "Error retrieving agent IP", err)
+		logger.Error("Error resolving agent endpoint: %v", err)
+		response.RespondWithError(w, r, http.StatusInternalServerError, "Error resolving agent endpoint", err)
 		return
 	}
 
-	var target string
-
-	ip := net.ParseIP(agentip.String())
-	if ip == nil {
-		response.RespondWithError(w, r, http.StatusInternalServerError, "invalid ip address", nil)
-	}
-	if ip.To4() != nil {
-		target = fmt.Sprintf("%s:50051", agentip)
-	} else {
-		target = fmt.Sprintf("[%s]:50051", agentip)
+	controlMessages := []*controlpb.ControlMessage{
+		commands.Snapshot(kopiaRepository(), "./"),
 	}
 
-	err = ConnectKopiaS3Repository(target)
-	if err != nil {
-		response.RespondWithError(w, r, http.StatusInternalServerError, "Failed to connect to Kopia S3 repository", err)
+	responses, err := commands.Command(r.Context(), assetID, target, controlMessages)
+	if errors.Is(err, capability.ErrMissingCapability) {
+		response.RespondWithError(w, r, http.StatusUnprocessableEntity, "Asset cannot create snapshots", err)
 		return
 	}
-
-	controlMessages := []*controlpb.ControlMessage{
-		{
-			Command: "exec",
-			Payload: fmt.Sprintf("kopia snapshot create ./"),
-		},
+	if commands.IsTimeout(err) {
+		response.RespondWithError(w, r, http.StatusGatewayTimeout, "Timed out creating snapshot", err)
+		return
 	}
-
-	responses, err := commands.Command(target, controlMessages)
 	if err != nil {
 		response.RespondWithError(w, r, http.StatusInternalServerError, "Failed to create snapshot", err)
+		return
 	}
 
-	for _, responder := range responses {
-		if responder.GetStatus() != "error" {
-			response.RespondWithJSON(w, http.StatusOK, map[string]string{"message": "Snapshot created successfully"})
-		} else {
-			response.RespondWithError(w, r, http.StatusInternalServerError, "Failed to create snapshot", fmt.Errorf("error creating snapshot"))
-		}
+	if err := commands.CheckAll(responses); err != nil {
+		response.RespondWithError(w, r, http.StatusInternalServerError, "Failed to create snapshot", err)
+		return
 	}
+
+	response.RespondWithJSON(w, http.StatusOK, map[string]string{"message": "Snapshot created successfully"})
 }
@@ -1,10 +1,8 @@
 package snapshots
 
 import (
-	"fmt"
 	"os"
 
-	"github.com/SyntinelNyx/syntinel-server/internal/commands"
 	"github.com/SyntinelNyx/syntinel-server/internal/proto/controlpb"
 )
 
@@ -46,31 +44,15 @@ import (
 // 	logger.Info("Repository created successfully")
 // }
 
-func ConnectKopiaS3Repository(agentip string) error {
-	bucket := os.Getenv("S3_BUCKET")
-	endpoint := os.Getenv("S3_ENDPOINT")
-	accessKey := os.Getenv("S3_ACCESS_KEY")
-	secretKey := os.Getenv("S3_SECRET_KEY")
-	repoPwd := os.Getenv("KOPIA_REPO_PASSWORD")
-
-	controlMessages := []*controlpb.ControlMessage{
-		{
-			Command: "exec",
-			Payload: fmt.Sprintf("kopia repository connect s3 --bucket %s --endpoint %s --access-key %s --secret-access-key %s --password %s --disable-tls", bucket, endpoint, accessKey, secretKey, repoPwd),
-		},
-	}
-
-	responses, err := commands.Command(agentip, controlMessages)
-	if err != nil {
-		return fmt.Errorf("Error connecting to Kopia S3 repository: %v", err)
-	}
-	// Process the responses
-	for _, responder := range responses {
-		if responder.GetStatus() != "error" {
-			return nil
-		} else {
-			return fmt.Errorf("Failed to to connect")
-		}
+// kopiaRepository describes the S3 repository that agents connect Kopia to
+// before creating or listing snapshots.
+func kopiaRepository() *controlpb.S3Repository {
+	return &controlpb.S3Repository{
+		Bucket:     os.Getenv("S3_BUCKET"),
+		Endpoint:   os.Getenv("S3_ENDPOINT"),
+		AccessKey:  os.Getenv("S3_ACCESS_KEY"),
+		SecretKey:  os.Getenv("S3_SECRET_KEY"),
+		Password:   os.Getenv("KOPIA_REPO_PASSWORD"),
+		DisableTls: true,
 	}
-	return nil
 }
@@ -3,12 +3,14 @@ package snapshots
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
-	"net"
 	"net/http"
 	"time"
 
+	"github.com/SyntinelNyx/syntinel-server/internal/capability"
 	"github.com/SyntinelNyx/syntinel-server/internal/commands"
+	"github.com/SyntinelNyx/syntinel-server/internal/endpoint"
 	"github.com/SyntinelNyx/syntinel-server/internal/proto/controlpb"
 	"github.com/SyntinelNyx/syntinel-server/internal/response"
 	"github.com/go-chi/chi/v5"
@@ -49,48 +51,39 @@ func (h *Handler) ListSnapshots(w http.ResponseWriter, r *http.Request) {
 	}
 	assetID = uuid
 
-	agentip, err := h.queries.GetIPByAssetID(context.Background(), assetID)
+	target, err := endpoint.Resolve(context.Background(), h.queries, assetID)
 	if err != nil {
-		response.RespondWithError(w, r, http.StatusInternalServerError, "Error retrieving agent IP", err)
+		response.RespondWithError(w, r, http.StatusInternalServerError, "Error resolving agent endpoint", err)
 		return
 	}
 
-	var target string
-
-	ip := net.ParseIP(agentip.String())
-	if ip == nil {
-		response.RespondWithError(w, r, http.StatusInternalServerError, "invalid ip address", nil)
-	}
-	if ip.To4() != nil {
-		target = fmt.Sprintf("%s:50051", agentip)
-	} else {
-		target = fmt.Sprintf("[%s]:50051", agentip)
+	controlMessages := []*controlpb.ControlMessage{
+		commands.ListSnapshots(kopiaRepository()),
 	}
 
-	err = ConnectKopiaS3Repository(target)
-	if err != nil {
-		response.RespondWithError(w, r, http.StatusInternalServerError, "Failed to connect to Kopia S3 repository", err)
+	responses, err := commands.Command(r.Context(), assetID, target, controlMessages)
+	if errors.Is(err, capability.ErrMissingCapability) {
+		response.RespondWithError(w, r, http.StatusUnprocessableEntity, "Asset cannot list snapshots", err)
 		return
 	}
-
-	controlMessages := []*controlpb.ControlMessage{
-		{
-			Command: "exec",
-			Payload: fmt.Sprintf("kopia snapshot list --json"),
-		},
+	if commands.IsTimeout(err) {
+		response.RespondWithError(w, r, http.StatusGatewayTimeout, "Timed out listing snapshots", err)
+		return
 	}
-
-	responses, err := commands.Command(target, controlMessages)
 	if err != nil {
 		response.RespondWithError(w, r, http.StatusBadRequest, "Error listing snapshots: %v", err)
 		return
 	}
 
 	if len(responses) > 0 {
-		result := responses[0].GetResult()
+		result, err := commands.CompleteOutput(responses[0])
+		if err != nil {
+			response.RespondWithError(w, r, http.StatusInternalServerError, "Failed to list snapshots", err)
+			return
+		}
 
 		var kopia KopiaOutput
-		err := json.Unmarshal([]byte(result), &kopia)
+		err = json.Unmarshal([]byte(result), &kopia)
 		if err != nil {
 			response.RespondWithError(w, r, http.StatusBadRequest, "error parsing snapshot JSON: %v", err)
 			return
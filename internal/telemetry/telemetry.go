@@ -3,12 +3,11 @@ package telemetry
 import (
 	"context"
 	"encoding/json"
-	"fmt"
-	"net"
 	"time"
 
 	"github.com/SyntinelNyx/syntinel-server/internal/commands"
 	"github.com/SyntinelNyx/syntinel-server/internal/database/query"
+	"github.com/SyntinelNyx/syntinel-server/internal/endpoint"
 	"github.com/SyntinelNyx/syntinel-server/internal/logger"
 	"github.com/SyntinelNyx/syntinel-server/internal/proto/controlpb"
 )
@@ -54,30 +53,17 @@ func (h *Handler) TelemetryRunner() error {
 
 			for _, asset := range assets {
 
-				if asset.IpAddress.IsValid() == false {
-					continue
-				}
-
 				controlMessages := []*controlpb.ControlMessage{
-					{
-						Command: "sysinfo",
-					},
+					commands.SysInfo(),
 				}
 
-				var target string
-
-				ip := net.ParseIP(asset.IpAddress.String())
-				if ip == nil {
-					return fmt.Errorf("Ip address is nil")
-				}
-				if ip.To4() != nil {
-					target = fmt.Sprintf("%s:50051", asset.IpAddress)
-				} else {
-					target = fmt.Sprintf("[%s]:50051", asset.IpAddress)
+				target, err := endpoint.New(asset.IpAddress, asset.AgentHostname, asset.AgentPort, asset.AgentEndpointOverride).Target()
+				if err != nil {
+					continue
 				}
 				logger.Info("Collecting telemetry from %s", target)
 
-				responses, err := commands.Command(target, controlMessages)
+				responses, err := commands.Command(ctx, asset.AssetID, target, controlMessages)
 				if err != nil {
 					logger.Error("Failed to execute sysinfo on %s: %v", asset.IpAddress.String(), err)
 					continue
@@ -86,8 +72,13 @@ func (h *Handler) TelemetryRunner() error {
 				if len(responses) > 0 {
 					var sysinfo SysInfo
 
-					result := responses[0].GetResult()
-					err := json.Unmarshal([]byte(result), &sysinfo)
+					result, err := commands.CompleteOutput(responses[0])
+					if err != nil {
+						logger.Error("Sysinfo failed on %s: %v", asset.IpAddress.String(), err)
+						continue
+					}
+
+					err = json.Unmarshal([]byte(result), &sysinfo)
 					if err != nil {
 						logger.Error("Failed to parse sysinfo response from %s: %v", asset.IpAddress.String(), err)
 						continue
@@ -3,11 +3,14 @@ package terminal
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
-	"net"
 	"net/http"
 
+	"github.com/SyntinelNyx/syntinel-server/internal/capability"
 	"github.com/SyntinelNyx/syntinel-server/internal/commands"
+	"github.com/SyntinelNyx/syntinel-server/internal/endpoint"
+	"github.com/SyntinelNyx/syntinel-server/internal/liveness"
 	"github.com/SyntinelNyx/syntinel-server/internal/logger"
 	"github.com/SyntinelNyx/syntinel-server/internal/proto/controlpb"
 	"github.com/SyntinelNyx/syntinel-server/internal/response"
@@ -20,7 +23,12 @@ type TerminalRequest struct {
 }
 
 type TerminalResponse struct {
-	Result string `json:"result"`
+	Result     string `json:"result"`
+	Stdout     string `json:"stdout"`
+	Stderr     string `json:"stderr"`
+	ExitCode   int32  `json:"exitCode"`
+	DurationMs int64  `json:"durationMs"`
+	Truncated  bool   `json:"truncated"`
 }
 
 func (h *Handler) Terminal(w http.ResponseWriter, r *http.Request) {
@@ -52,32 +60,29 @@ func (h *Handler) Terminal(w http.ResponseWriter, r *http.Request) {
 	}
 	assetID = uuid
 
-	agentip, err := h.queries.GetIPByAssetID(context.Background(), assetID)
+	target, err := endpoint.Resolve(context.Background(), h.queries, assetID)
 	if err != nil {
-		response.RespondWithError(w, r, http.StatusInternalServerError, "Error retrieving agent IP", err)
+		response.RespondWithError(w, r, http.StatusInternalServerError, "Error resolving agent endpoint", err)
 		return
 	}
 
-	var target string
+	controlMessages := []*controlpb.ControlMessage{
+		commands.Shell(terminalRequest.Command),
+	}
 
-	ip := net.ParseIP(agentip.String())
-	if ip == nil {
-		response.RespondWithError(w, r, http.StatusInternalServerError, "invalid ip address", nil)
+	responses, err := commands.Command(r.Context(), assetID, target, controlMessages)
+	if errors.Is(err, liveness.ErrAgentOffline) {
+		response.RespondWithError(w, r, http.StatusServiceUnavailable, "Asset is offline", err)
+		return
 	}
-	if ip.To4() != nil {
-		target = fmt.Sprintf("%s:50051", agentip)
-	} else {
-		target = fmt.Sprintf("[%s]:50051", agentip)
+	if errors.Is(err, capability.ErrMissingCapability) {
+		response.RespondWithError(w, r, http.StatusUnprocessableEntity, "Asset agent does not support commands", err)
+		return
 	}
-
-	controlMessages := []*controlpb.ControlMessage{
-		{
-			Command: "exec",
-			Payload: terminalRequest.Command,
-		},
+	if commands.IsTimeout(err) {
+		response.RespondWithError(w, r, http.StatusGatewayTimeout, "Timed out waiting for agent", err)
+		return
 	}
-
-	responses, err := commands.Command(target, controlMessages)
 	if err != nil {
 		logger.Error("Error sending command to gRPC agent: %s", err)
 		response.RespondWithError(w, r, http.StatusBadRequest, "Error executing command: %v", err)
@@ -87,16 +92,29 @@ func (h *Handler) Terminal(w http.ResponseWriter, r *http.Request) {
 		response.RespondWithError(w, r, http.StatusInternalServerError, "No response from agent", nil)
 		return
 	}
-	result := responses[0].GetResult()
-	if result == "" {
-		response.RespondWithError(w, r, http.StatusInternalServerError, "Empty response from agent", nil)
+
+	// A non-zero exit code is a normal terminal outcome, not a request failure.
+	resp := responses[0]
+	result, err := commands.Output(resp)
+	var execErr *commands.ExecError
+	if err != nil && !errors.As(err, &execErr) {
+		response.RespondWithError(w, r, http.StatusInternalServerError, "Invalid response from agent", err)
 		return
 	}
 
-	// Marshal the result into JSON
 	terminalResponse := TerminalResponse{
-		Result: result,
+		Result:     result,
+		Stdout:     resp.GetStdout(),
+		Stderr:     resp.GetStderr(),
+		ExitCode:   resp.GetExitCode(),
+		DurationMs: commands.Duration(resp).Milliseconds(),
+		Truncated:  resp.GetTruncated(),
 	}
+	if execErr != nil {
+		terminalResponse.ExitCode = execErr.ExitCode
+		terminalResponse.Stderr = execErr.Stderr
+	}
+
 	responseJSON, err := json.Marshal(terminalResponse)
 	if err != nil {
 		response.RespondWithError(w, r, http.StatusInternalServerError, "Failed to marshal response", err)
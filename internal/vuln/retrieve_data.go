@@ -4,17 +4,45 @@ import (
 	"net/http"
 	"time"
 
+	"github.com/SyntinelNyx/syntinel-server/internal/auth"
+	"github.com/SyntinelNyx/syntinel-server/internal/database/query"
 	"github.com/SyntinelNyx/syntinel-server/internal/response"
 	"github.com/go-chi/chi/v5"
 )
 
 type VulnerabilityData struct {
-	VulnerabilityName        string   `json:"vulnerabilityName"`
-	VulnerabilityDescription string   `json:"vulnerabilityDescription"`
-	CvssScore                float64  `json:"cvssScore"`
-	Reference                []string `json:"reference"`
-	CreatedOn                string   `json:"createdOn"`
-	LastModified             string   `json:"lastModified"`
+	VulnerabilityName        string            `json:"vulnerabilityName"`
+	VulnerabilityDescription string            `json:"vulnerabilityDescription"`
+	CvssScore                float64           `json:"cvssScore"`
+	Reference                []string          `json:"reference"`
+	CreatedOn                string            `json:"createdOn"`
+	LastModified             string            `json:"lastModified"`
+	AffectedPackages         []AffectedPackage `json:"affectedPackages"`
+	AssetStates              []AssetState      `json:"assetStates"`
+}
+
+// AssetState is the lifecycle state of a vulnerability on one asset.
+type AssetState struct {
+	AssetID   string `json:"assetId"`
+	Hostname  string `json:"hostname"`
+	State     string `json:"state"`
+	ChangedAt string `json:"changedAt"`
+}
+
+// AffectedPackage is a package that a vulnerability was found in on an asset.
+type AffectedPackage struct {
+	AssetID          string `json:"assetId"`
+	Hostname         string `json:"hostname"`
+	ScanID           string `json:"scanId,omitempty"`
+	LastSeen         string `json:"lastSeen,omitempty"`
+	Vulnerability    string `json:"vulnerability,omitempty"`
+	Severity         string `json:"severity,omitempty"`
+	Package          string `json:"package"`
+	InstalledVersion string `json:"installedVersion"`
+	FixedVersion     string `json:"fixedVersion"`
+	Path             string `json:"path,omitempty"`
+	Target           string `json:"target,omitempty"`
+	Type             string `json:"type,omitempty"`
 }
 
 func (h *Handler) RetrieveData(w http.ResponseWriter, r *http.Request) {
@@ -32,6 +60,36 @@ func (h *Handler) RetrieveData(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	account := auth.GetClaims(r.Context())
+	rootId := account.AccountID
+	if account.AccountType != "root" {
+		rootId, err = h.queries.GetRootAccountIDForIAMUser(r.Context(), account.AccountID)
+		if err != nil {
+			response.RespondWithError(w, r, http.StatusInternalServerError, "Failed to get associated root account", err)
+			return
+		}
+	}
+
+	// Packages come from the latest scan of each asset that reported this
+	// vulnerability.
+	packages, err := h.queries.GetVulnerabilityPackages(r.Context(), query.GetVulnerabilityPackagesParams{
+		VulnerabilityID: vulnID,
+		RootAccountID:   rootId,
+	})
+	if err != nil {
+		response.RespondWithError(w, r, http.StatusInternalServerError, "Failed to retrieve affected packages", err)
+		return
+	}
+
+	states, err := h.queries.GetAssetVulnerabilityStates(r.Context(), query.GetAssetVulnerabilityStatesParams{
+		VulnerabilityID: vulnID,
+		RootAccountID:   rootId,
+	})
+	if err != nil {
+		response.RespondWithError(w, r, http.StatusInternalServerError, "Failed to retrieve asset states", err)
+		return
+	}
+
 	score, _ := vulnData.CvssScore.Float64Value()
 	vulnResponse := VulnerabilityData{
 		VulnerabilityName:        vulnData.VulnerabilityName.String,
@@ -40,6 +98,30 @@ func (h *Handler) RetrieveData(w http.ResponseWriter, r *http.Request) {
 		Reference:                vulnData.Reference,
 		CreatedOn:                vulnData.CreatedOn.Time.Format(time.RFC3339),
 		LastModified:             vulnData.LastModified.Time.Format(time.RFC3339),
+		AffectedPackages:         []AffectedPackage{},
+		AssetStates:              []AssetState{},
+	}
+	for _, state := range states {
+		vulnResponse.AssetStates = append(vulnResponse.AssetStates, AssetState{
+			AssetID:   response.UuidToString(state.AssetID),
+			Hostname:  state.Hostname.String,
+			State:     string(state.VulnerabilityState),
+			ChangedAt: state.StateChangedAt.Time.Format(time.RFC3339),
+		})
+	}
+	for _, pkg := range packages {
+		vulnResponse.AffectedPackages = append(vulnResponse.AffectedPackages, AffectedPackage{
+			AssetID:          response.UuidToString(pkg.AssetID),
+			Hostname:         pkg.Hostname.String,
+			ScanID:           response.UuidToString(pkg.ScanID),
+			LastSeen:         pkg.ScanDate.Time.Format(time.RFC3339),
+			Package:          pkg.PackageName,
+			InstalledVersion: pkg.InstalledVersion,
+			FixedVersion:     pkg.FixedVersion,
+			Path:             pkg.PackagePath,
+			Target:           pkg.Target,
+			Type:             pkg.PackageType,
+		})
 	}
 
 	response.RespondWithJSON(w, http.StatusOK, vulnResponse)
@@ -66,3 +66,51 @@ func (h *Handler) RetrieveScan(w http.ResponseWriter, r *http.Request) {
 
 	response.RespondWithJSON(w, http.StatusOK, vulnList)
 }
+
+// RetrieveScanPackages lists the affected packages behind each finding of a
+// scan.
+func (h *Handler) RetrieveScanPackages(w http.ResponseWriter, r *http.Request) {
+	account := auth.GetClaims(r.Context())
+	rootId := account.AccountID
+	if account.AccountType != "root" {
+		var err error
+		rootId, err = h.queries.GetRootAccountIDForIAMUser(r.Context(), account.AccountID)
+		if err != nil {
+			response.RespondWithError(w, r, http.StatusInternalServerError, "Failed to get associated root account", err)
+			return
+		}
+	}
+
+	var scanUUID pgtype.UUID
+	if err := scanUUID.Scan(chi.URLParam(r, "scanID")); err != nil {
+		response.RespondWithError(w, r, http.StatusBadRequest, "Invalid scan_id format", err)
+		return
+	}
+
+	rows, err := h.queries.GetScanPackages(r.Context(), query.GetScanPackagesParams{
+		ScanID:        scanUUID,
+		RootAccountID: rootId,
+	})
+	if err != nil {
+		response.RespondWithError(w, r, http.StatusInternalServerError, "Failed to retrieve affected packages", err)
+		return
+	}
+
+	packages := []AffectedPackage{}
+	for _, row := range rows {
+		packages = append(packages, AffectedPackage{
+			AssetID:          response.UuidToString(row.AssetID),
+			Hostname:         row.Hostname.String,
+			Vulnerability:    row.VulnerabilityID,
+			Severity:         row.VulnerabilitySeverity.String,
+			Package:          row.PackageName,
+			InstalledVersion: row.InstalledVersion,
+			FixedVersion:     row.FixedVersion,
+			Path:             row.PackagePath,
+			Target:           row.Target,
+			Type:             row.PackageType,
+		})
+	}
+
+	response.RespondWithJSON(w, http.StatusOK, packages)
+}
@@ -0,0 +1,232 @@
+package vuln
+
+import (
+	"context"
+	"errors"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+
+	"github.com/SyntinelNyx/syntinel-server/internal/database/query"
+	"github.com/SyntinelNyx/syntinel-server/internal/response"
+)
+
+type SeverityCounts struct {
+	Critical int `json:"critical"`
+	High     int `json:"high"`
+	Medium   int `json:"medium"`
+	Low      int `json:"low"`
+	Unknown  int `json:"unknown"`
+	Total    int `json:"total"`
+}
+
+type DiffCounts struct {
+	Introduced SeverityCounts `json:"introduced"`
+	Fixed      SeverityCounts `json:"fixed"`
+	Unchanged  SeverityCounts `json:"unchanged"`
+}
+
+type DiffFinding struct {
+	Vulnerability string `json:"vulnerability"`
+	Severity      string `json:"severity"`
+}
+
+type AssetDiff struct {
+	AssetID    string        `json:"assetId"`
+	Hostname   string        `json:"hostname"`
+	BaseScanID string        `json:"baseScanId,omitempty"`
+	Introduced []DiffFinding `json:"introduced"`
+	Fixed      []DiffFinding `json:"fixed"`
+	Unchanged  []DiffFinding `json:"unchanged"`
+	Counts     DiffCounts    `json:"counts"`
+}
+
+type ScanDiff struct {
+	ScanID     string      `json:"scanId"`
+	BaseScanID string      `json:"baseScanId,omitempty"`
+	Summary    DiffCounts  `json:"summary"`
+	Assets     []AssetDiff `json:"assets"`
+}
+
+// DiffScan compares the findings of a scan, per asset, with those of a base
+// scan. Without a base scan in the URL each asset is compared with the latest
+// earlier scan of it by the same scanner of the same path. An asset with
+// nothing to compare against reports all of its findings as introduced.
+func (h *Handler) DiffScan(w http.ResponseWriter, r *http.Request) {
+	rootId, err := h.rootAccountID(r)
+	if err != nil {
+		response.RespondWithError(w, r, http.StatusInternalServerError, "Failed to get associated root account", err)
+		return
+	}
+
+	scan, ok := h.diffScan(w, r, rootId, chi.URLParam(r, "scanID"))
+	if !ok {
+		return
+	}
+
+	assets, err := h.queries.GetScanAssets(context.Background(), scan.ScanID)
+	if err != nil {
+		response.RespondWithError(w, r, http.StatusInternalServerError, "Failed to get scanned assets", err)
+		return
+	}
+
+	baseScans := make(map[[16]byte]pgtype.UUID)
+	diff := ScanDiff{ScanID: response.UuidToString(scan.ScanID), Assets: []AssetDiff{}}
+
+	if baseParam := chi.URLParam(r, "baseScanID"); baseParam != "" {
+		base, ok := h.diffScan(w, r, rootId, baseParam)
+		if !ok {
+			return
+		}
+		baseAssets, err := h.queries.GetScanAssets(context.Background(), base.ScanID)
+		if err != nil {
+			response.RespondWithError(w, r, http.StatusInternalServerError, "Failed to get scanned assets", err)
+			return
+		}
+		for _, asset := range baseAssets {
+			baseScans[asset.AssetID.Bytes] = base.ScanID
+		}
+		diff.BaseScanID = response.UuidToString(base.ScanID)
+	} else {
+		assetIDs := []pgtype.UUID{}
+		for _, asset := range assets {
+			assetIDs = append(assetIDs, asset.AssetID)
+		}
+		previous, err := h.queries.GetPreviousScansForAssets(context.Background(), query.GetPreviousScansForAssetsParams{
+			AssetIds:      assetIDs,
+			RootAccountID: rootId,
+			ScannerName:   scan.ScannerName,
+			ScanPath:      scan.ScanPath,
+			ScanDate:      scan.ScanDate,
+		})
+		if err != nil {
+			response.RespondWithError(w, r, http.StatusInternalServerError, "Failed to get previous scans", err)
+			return
+		}
+		for _, row := range previous {
+			baseScans[row.AssetID.Bytes] = row.ScanID
+		}
+	}
+
+	scanIDs := []pgtype.UUID{scan.ScanID}
+	for _, id := range baseScans {
+		scanIDs = append(scanIDs, id)
+	}
+	rows, err := h.queries.GetScanFindings(context.Background(), query.GetScanFindingsParams{
+		RootAccountID: rootId,
+		ScanIds:       scanIDs,
+	})
+	if err != nil {
+		response.RespondWithError(w, r, http.StatusInternalServerError, "Failed to get scan findings", err)
+		return
+	}
+
+	type findingKey struct{ scan, asset [16]byte }
+	findings := make(map[findingKey][]DiffFinding)
+	for _, row := range rows {
+		key := findingKey{row.ScanID.Bytes, row.AssetID.Bytes}
+		findings[key] = append(findings[key], DiffFinding{Vulnerability: row.VulnerabilityID, Severity: row.VulnerabilitySeverity.String})
+	}
+
+	for _, asset := range assets {
+		current := findings[findingKey{scan.ScanID.Bytes, asset.AssetID.Bytes}]
+
+		var base []DiffFinding
+		baseScanID, hasBase := baseScans[asset.AssetID.Bytes]
+		if hasBase {
+			base = findings[findingKey{baseScanID.Bytes, asset.AssetID.Bytes}]
+		}
+
+		assetDiff := diffFindings(current, base)
+		assetDiff.AssetID = response.UuidToString(asset.AssetID)
+		assetDiff.Hostname = asset.Hostname.String
+		if hasBase {
+			assetDiff.BaseScanID = response.UuidToString(baseScanID)
+		}
+
+		diff.Summary.Introduced.add(assetDiff.Introduced...)
+		diff.Summary.Fixed.add(assetDiff.Fixed...)
+		diff.Summary.Unchanged.add(assetDiff.Unchanged...)
+		diff.Assets = append(diff.Assets, assetDiff)
+	}
+
+	response.RespondWithJSON(w, http.StatusOK, diff)
+}
+
+func (h *Handler) diffScan(w http.ResponseWriter, r *http.Request, rootId pgtype.UUID, id string) (query.GetScanForRootAccountRow, bool) {
+	var scanID pgtype.UUID
+	if err := scanID.Scan(id); err != nil {
+		response.RespondWithError(w, r, http.StatusBadRequest, "Invalid scan_id format", err)
+		return query.GetScanForRootAccountRow{}, false
+	}
+
+	scan, err := h.queries.GetScanForRootAccount(context.Background(), query.GetScanForRootAccountParams{
+		ScanID:        scanID,
+		RootAccountID: rootId,
+	})
+	if errors.Is(err, pgx.ErrNoRows) {
+		response.RespondWithError(w, r, http.StatusNotFound, "Scan not found", err)
+		return query.GetScanForRootAccountRow{}, false
+	}
+	if err != nil {
+		response.RespondWithError(w, r, http.StatusInternalServerError, "Failed to get scan", err)
+		return query.GetScanForRootAccountRow{}, false
+	}
+
+	return scan, true
+}
+
+// diffFindings splits one asset's findings into those introduced since the
+// base scan, those fixed since, and those present in both.
+func diffFindings(current []DiffFinding, base []DiffFinding) AssetDiff {
+	diff := AssetDiff{Introduced: []DiffFinding{}, Fixed: []DiffFinding{}, Unchanged: []DiffFinding{}}
+
+	inBase := make(map[string]bool)
+	for _, finding := range base {
+		inBase[finding.Vulnerability] = true
+	}
+	inCurrent := make(map[string]bool)
+	for _, finding := range current {
+		if inCurrent[finding.Vulnerability] {
+			continue
+		}
+		inCurrent[finding.Vulnerability] = true
+
+		if inBase[finding.Vulnerability] {
+			diff.Unchanged = append(diff.Unchanged, finding)
+		} else {
+			diff.Introduced = append(diff.Introduced, finding)
+		}
+	}
+	for _, finding := range base {
+		if !inCurrent[finding.Vulnerability] {
+			inCurrent[finding.Vulnerability] = true
+			diff.Fixed = append(diff.Fixed, finding)
+		}
+	}
+
+	diff.Counts.Introduced.add(diff.Introduced...)
+	diff.Counts.Fixed.add(diff.Fixed...)
+	diff.Counts.Unchanged.add(diff.Unchanged...)
+	return diff
+}
+
+func (c *SeverityCounts) add(findings ...DiffFinding) {
+	for _, finding := range findings {
+		switch finding.Severity {
+		case "Critical":
+			c.Critical++
+		case "High":
+			c.High++
+		case "Medium":
+			c.Medium++
+		case "Low":
+			c.Low++
+		default:
+			c.Unknown++
+		}
+		c.Total++
+	}
+}
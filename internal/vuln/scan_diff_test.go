@@ -0,0 +1,37 @@
+package vuln
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDiffFindings(t *testing.T) {
+	base := []DiffFinding{
+		{Vulnerability: "CVE-2021-44228", Severity: "Critical"},
+		{Vulnerability: "CVE-2021-23337", Severity: "High"},
+		{Vulnerability: "CVE-2020-8203", Severity: "High"},
+	}
+	current := []DiffFinding{
+		{Vulnerability: "CVE-2021-23337", Severity: "High"},
+		{Vulnerability: "CVE-2023-43804", Severity: "Medium"},
+		{Vulnerability: "GHSA-fxg5-wq6x-vr4w", Severity: ""},
+	}
+
+	diff := diffFindings(current, base)
+
+	assert.Equal(t, []DiffFinding{{"CVE-2023-43804", "Medium"}, {"GHSA-fxg5-wq6x-vr4w", ""}}, diff.Introduced)
+	assert.Equal(t, []DiffFinding{{"CVE-2021-44228", "Critical"}, {"CVE-2020-8203", "High"}}, diff.Fixed)
+	assert.Equal(t, []DiffFinding{{"CVE-2021-23337", "High"}}, diff.Unchanged)
+	assert.Equal(t, SeverityCounts{Medium: 1, Unknown: 1, Total: 2}, diff.Counts.Introduced)
+	assert.Equal(t, SeverityCounts{Critical: 1, High: 1, Total: 2}, diff.Counts.Fixed)
+	assert.Equal(t, SeverityCounts{High: 1, Total: 1}, diff.Counts.Unchanged)
+}
+
+func TestDiffFindingsWithoutBase(t *testing.T) {
+	diff := diffFindings([]DiffFinding{{"CVE-2021-23337", "High"}}, nil)
+
+	assert.Len(t, diff.Introduced, 1)
+	assert.Empty(t, diff.Fixed)
+	assert.Empty(t, diff.Unchanged)
+}
@@ -1,8 +1,12 @@
 package vuln
 
 import (
+	"cmp"
 	"encoding/json"
 	"fmt"
+	"slices"
+	"strconv"
+	"strings"
 	"time"
 )
 
@@ -15,6 +19,33 @@ type Vulnerability struct {
 	CreatedOn    time.Time `json:"CreatedOn"`
 	LastModified time.Time `json:"LastModified"`
 	References   []string  `json:"References"`
+
+	// FixState and FixedVersions are reported by scanners that know whether
+	// a fix is available, such as Grype.
+	FixState      string   `json:"FixState,omitempty"`
+	FixedVersions []string `json:"FixedVersions,omitempty"`
+
+	// Packages lists where the vulnerability was found.
+	Packages []Package `json:"Packages,omitempty"`
+}
+
+// Package is an installed package affected by a vulnerability.
+type Package struct {
+	Name             string `json:"PkgName"`
+	InstalledVersion string `json:"InstalledVersion"`
+	FixedVersion     string `json:"FixedVersion,omitempty"`
+	Path             string `json:"PkgPath,omitempty"`
+	Target           string `json:"Target,omitempty"`
+	Type             string `json:"PkgType,omitempty"`
+}
+
+// AddPackage records another package the vulnerability was found in,
+// ignoring packages without a name and ones already listed.
+func (v *Vulnerability) AddPackage(pkg Package) {
+	if pkg.Name == "" || slices.Contains(v.Packages, pkg) {
+		return
+	}
+	v.Packages = append(v.Packages, pkg)
 }
 
 func (v *Vulnerability) String() string {
@@ -39,6 +70,29 @@ func formatReferences(refs []string) string {
 	return result
 }
 
+// GetPackagesJSON flattens the packages of each vulnerability into a JSON
+// array of packages tagged with their VulnerabilityID.
+func GetPackagesJSON(vulnerabilities []Vulnerability) ([]byte, error) {
+	type finding struct {
+		VulnerabilityID string `json:"VulnerabilityID"`
+		Package
+	}
+
+	findings := []finding{}
+	for _, v := range vulnerabilities {
+		for _, pkg := range v.Packages {
+			findings = append(findings, finding{VulnerabilityID: v.ID, Package: pkg})
+		}
+	}
+
+	packagesJSON, err := json.Marshal(findings)
+	if err != nil {
+		return nil, fmt.Errorf("error marshalling packages to JSON: %w", err)
+	}
+
+	return packagesJSON, nil
+}
+
 func GetVulnerabilitiesJSON(vulnerabilities []Vulnerability) ([]byte, error) {
 	vulnerabilitiesJSON, err := json.Marshal(vulnerabilities)
 	if err != nil {
@@ -47,3 +101,71 @@ func GetVulnerabilitiesJSON(vulnerabilities []Vulnerability) ([]byte, error) {
 
 	return vulnerabilitiesJSON, nil
 }
+
+// PreferredID picks the ID a vulnerability is reported under, so that
+// scanners that list different aliases for one advisory still agree on it:
+// id itself if it is a CVE, otherwise the earliest CVE alias by year and
+// number, then id if it is a GHSA, otherwise the lowest GHSA alias.
+func PreferredID(id string, aliases []string) string {
+	if strings.HasPrefix(id, "CVE-") {
+		return id
+	}
+
+	var cves, ghsas []string
+	for _, alias := range aliases {
+		switch {
+		case strings.HasPrefix(alias, "CVE-"):
+			cves = append(cves, alias)
+		case strings.HasPrefix(alias, "GHSA-"):
+			ghsas = append(ghsas, alias)
+		}
+	}
+
+	if len(cves) > 0 {
+		return slices.MinFunc(cves, compareCVE)
+	}
+	if strings.HasPrefix(id, "GHSA-") {
+		return id
+	}
+	if len(ghsas) > 0 {
+		return slices.Min(ghsas)
+	}
+	return id
+}
+
+// compareCVE orders CVE IDs by year and then number, so CVE-2021-9999 comes
+// before CVE-2021-10000. IDs that do not parse come last.
+func compareCVE(a, b string) int {
+	ay, an, aok := parseCVE(a)
+	by, bn, bok := parseCVE(b)
+
+	switch {
+	case aok != bok:
+		if aok {
+			return -1
+		}
+		return 1
+	case ay != by:
+		return cmp.Compare(ay, by)
+	case an != bn:
+		return cmp.Compare(an, bn)
+	}
+	return strings.Compare(a, b)
+}
+
+func parseCVE(id string) (int, int, bool) {
+	year, number, found := strings.Cut(strings.TrimPrefix(id, "CVE-"), "-")
+	if !found {
+		return 0, 0, false
+	}
+
+	y, err := strconv.Atoi(year)
+	if err != nil {
+		return 0, 0, false
+	}
+	n, err := strconv.Atoi(number)
+	if err != nil {
+		return 0, 0, false
+	}
+	return y, n, true
+}
@@ -0,0 +1,29 @@
+package vuln
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPreferredID(t *testing.T) {
+	tests := []struct {
+		name    string
+		id      string
+		aliases []string
+		want    string
+	}{
+		{"primary CVE", "CVE-2022-0001", []string{"CVE-2021-0001", "GHSA-aaaa-bbbb-cccc"}, "CVE-2022-0001"},
+		{"earliest year", "GHSA-aaaa-bbbb-cccc", []string{"CVE-2022-0001", "CVE-2021-0002"}, "CVE-2021-0002"},
+		{"numeric order", "GHSA-aaaa-bbbb-cccc", []string{"CVE-2021-10000", "CVE-2021-9999"}, "CVE-2021-9999"},
+		{"primary GHSA", "GHSA-zzzz-bbbb-cccc", []string{"GHSA-aaaa-bbbb-cccc", "PYSEC-2021-1"}, "GHSA-zzzz-bbbb-cccc"},
+		{"lowest GHSA", "PYSEC-2021-1", []string{"GHSA-zzzz-bbbb-cccc", "GHSA-aaaa-bbbb-cccc"}, "GHSA-aaaa-bbbb-cccc"},
+		{"no aliases", "RUSTSEC-2021-0003", nil, "RUSTSEC-2021-0003"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, PreferredID(tt.id, tt.aliases))
+		})
+	}
+}